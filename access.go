@@ -0,0 +1,194 @@
+package operand
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type accessPolicy struct {
+	parents map[string]bool // Nil if unrestricted.
+	filter  *operandv1.Filter
+	allow   func(*filev1.File) bool
+}
+
+func (o *searchOptions) accessPolicy() *accessPolicy {
+	if o.access == nil {
+		o.access = new(accessPolicy)
+	}
+	return o.access
+}
+
+// WithAllowedParents restricts Search to files within (or equal to) the given set of
+// directories, e.g. the folders the calling user has access to. If no parent IDs are
+// given, nothing may be returned. Results are post-filtered against the set, so a
+// hit outside of it is never returned, even if the API were to return one.
+func WithAllowedParents(parentIDs ...string) SearchOption {
+	return func(o *searchOptions) {
+		p := o.accessPolicy()
+		if p.parents == nil {
+			p.parents = make(map[string]bool, len(parentIDs))
+		}
+		for _, id := range parentIDs {
+			p.parents[id] = true
+		}
+	}
+}
+
+// WithAccessFilter restricts Search to files whose properties satisfy the given
+// filter, e.g. an ACL derived from the calling user's permissions. The filter is
+// applied by the API and then re-evaluated on the client with MatchesFilter. If
+// allow is non-nil, it's called for each returned file as a final check.
+func WithAccessFilter(filter *operandv1.Filter, allow func(*filev1.File) bool) SearchOption {
+	return func(o *searchOptions) {
+		p := o.accessPolicy()
+		p.filter = andFilters(p.filter, filter)
+		if allow != nil {
+			prev := p.allow
+			p.allow = func(f *filev1.File) bool {
+				return (prev == nil || prev(f)) && allow(f)
+			}
+		}
+	}
+}
+
+// permits reports whether the policy allows the file to be returned.
+func (p *accessPolicy) permits(f *filev1.File) bool {
+	if f == nil {
+		return false
+	}
+	if p.parents != nil && !p.inScope(f) {
+		return false
+	}
+	if p.filter != nil && !MatchesFilter(p.filter, f.Properties) {
+		return false
+	}
+	if p.allow != nil && !p.allow(f) {
+		return false
+	}
+	return true
+}
+
+func (p *accessPolicy) inScope(f *filev1.File) bool {
+	if p.parents[f.Id] || p.parents[f.GetParentId()] {
+		return true
+	}
+	for _, parent := range f.Parents {
+		if p.parents[parent.Id] {
+			return true
+		}
+	}
+	return false
+}
+
+// searchRestricted runs a search subject to an access policy.
+func (c *Client) searchRestricted(
+	ctx context.Context,
+	req *operandv1.SearchRequest,
+	p *accessPolicy,
+) (*operandv1.SearchResponse, error) {
+	req.Filter = andFilters(req.Filter, p.filter)
+
+	var (
+		resp *operandv1.SearchResponse
+		err  error
+	)
+	if p.parents != nil {
+		// We need all the parents of each file to be able to verify its scope.
+		req.FileReturnOptions = &filev1.ReturnedFileOptions{IncludeParents: true}
+
+		if req.ParentId != nil {
+			resp, err = c.searchOnce(ctx, req)
+		} else {
+			resp, err = c.searchParents(ctx, req, p.parents)
+		}
+	} else {
+		resp, err = c.searchOnce(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := resp.Matches[:0]
+	for _, m := range resp.Matches {
+		if p.permits(resp.Files[m.FileId]) {
+			allowed = append(allowed, m)
+		}
+	}
+	resp.Matches = allowed
+	pruneFiles(resp)
+
+	return resp, nil
+}
+
+// searchParents runs a search within each of the given parents concurrently, and
+// merges the results by score.
+func (c *Client) searchParents(
+	ctx context.Context,
+	req *operandv1.SearchRequest,
+	parents map[string]bool,
+) (*operandv1.SearchResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		merged   = &operandv1.SearchResponse{Files: make(map[string]*filev1.File)}
+	)
+	for parentID := range parents {
+		wg.Add(1)
+		go func(parentID string) {
+			defer wg.Done()
+
+			scoped := proto.Clone(req).(*operandv1.SearchRequest)
+			scoped.ParentId = &parentID
+			resp, err := c.searchOnce(ctx, scoped)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			merged.Matches = append(merged.Matches, resp.Matches...)
+			for id, f := range resp.Files {
+				merged.Files[id] = f
+			}
+		}(parentID)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Nested allowed parents may cause the same match to be returned more than once.
+	seen := make(map[string]bool, len(merged.Matches))
+	unique := merged.Matches[:0]
+	for _, m := range merged.Matches {
+		if !seen[m.MatchId] {
+			seen[m.MatchId] = true
+			unique = append(unique, m)
+		}
+	}
+	merged.Matches = unique
+
+	sort.SliceStable(merged.Matches, func(i, j int) bool {
+		return merged.Matches[i].Score > merged.Matches[j].Score
+	})
+	if req.MaxResults > 0 && len(merged.Matches) > int(req.MaxResults) {
+		merged.Matches = merged.Matches[:req.MaxResults]
+	}
+	pruneFiles(merged)
+
+	return merged, nil
+}
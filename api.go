@@ -0,0 +1,82 @@
+package operand
+
+import (
+	"context"
+	"io"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/operandinc/go-sdk/file/v1/filev1connect"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"github.com/operandinc/go-sdk/operand/v1/operandv1connect"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+	"github.com/operandinc/go-sdk/tenant/v1/tenantv1connect"
+)
+
+//go:generate go run ./internal/mockgen -o operandmock/api.go
+
+// API is the interface of Client, covering its service clients and helpers, so that
+// code using the SDK can substitute it in tests, e.g. with operandmock.API. Methods
+// configuring the client aren't part of it.
+type API interface {
+	// FileService returns a client for the Operand File Service.
+	FileService() filev1connect.FileServiceClient
+	// TenantService returns a client for the Operand Tenant Service.
+	TenantService() tenantv1connect.TenantServiceClient
+	// OperandService returns a client for the Operand Operand Service.
+	OperandService() operandv1connect.OperandServiceClient
+
+	// CreateFile creates a file, or a folder if data is nil.
+	CreateFile(ctx context.Context, name string, parent *string, data io.Reader, properties *filev1.Properties) (*filev1.CreateFileResponse, error)
+	// CreateFileFromPath creates a file from a local file.
+	CreateFileFromPath(ctx context.Context, path string, parent *string, properties *filev1.Properties) (*filev1.CreateFileResponse, error)
+	// UploadDir uploads a local directory.
+	UploadDir(ctx context.Context, dir string, parentID string, opts UploadOptions) (map[string]string, error)
+	// DownloadFile downloads the content of a file.
+	DownloadFile(ctx context.Context, fileID string, opts ...DownloadFileOption) (io.ReadCloser, *FileInfo, error)
+	// DownloadDir downloads a folder to a local directory.
+	DownloadDir(ctx context.Context, folderID string, dir string, opts DownloadOptions) error
+	// Preview returns the beginning of the content of a file.
+	Preview(ctx context.Context, fileID string, maxBytes int64) (*FilePreview, error)
+	// GetFiles fetches files by ID.
+	GetFiles(ctx context.Context, ids []string) map[string]FileResult
+	// ListFolder lists the files in a folder.
+	ListFolder(ctx context.Context, parentID string) ([]*filev1.File, error)
+	// ListAll lists all of the files accessible to the client.
+	ListAll(ctx context.Context) ([]*filev1.File, error)
+	// IterFiles returns an iterator over the files listed by a request.
+	IterFiles(ctx context.Context, req *filev1.ListFilesRequest) *FilesIterator
+	// IterAPIKeys returns an iterator over the API keys of the authorized user.
+	IterAPIKeys(ctx context.Context) *Pager[*tenantv1.APIKey]
+	// ListChangedSince lists the files within scope changed since a time.
+	ListChangedSince(ctx context.Context, scope string, since time.Time) ([]*filev1.File, error)
+	// Walk walks the tree of files rooted at a folder.
+	Walk(ctx context.Context, rootID string, fn WalkFunc) error
+	// DeleteFile deletes a file.
+	DeleteFile(ctx context.Context, id string) error
+	// DeleteTree deletes a folder and everything within it.
+	DeleteTree(ctx context.Context, rootID string) error
+	// TrackIndexing tracks the indexing of a file by the API.
+	TrackIndexing(ctx context.Context, fileID string, interval time.Duration) *OperationHandle[*filev1.File]
+	// WaitForFileIndexed waits for the indexing of a file to finish.
+	WaitForFileIndexed(ctx context.Context, fileID string, opts *WaitOptions) (filev1.IndexingStatus, error)
+
+	// Search searches the content of files.
+	Search(ctx context.Context, query string, opts ...SearchOption) (*operandv1.SearchResponse, error)
+	// SearchHits is like Search, but returns the matches along with their files.
+	SearchHits(ctx context.Context, query string, opts ...SearchOption) ([]*Hit, error)
+	// SearchDocuments searches over chunks, returning the documents they belong to.
+	SearchDocuments(ctx context.Context, query string, chunks int, opts ...SearchOption) ([]*DocumentMatch, error)
+	// Cite maps a match on a chunk back to its location within the original file.
+	Cite(ctx context.Context, match *operandv1.ContentMatch, file *filev1.File) (*Citation, error)
+	// Ask answers a question, as a new conversation.
+	Ask(ctx context.Context, question string, opts *operandv1.ConversationOptions) (*Answer, error)
+	// AskStream is like Ask, but streams the answer as it's generated.
+	AskStream(ctx context.Context, question string, opts *operandv1.ConversationOptions) (*AnswerStream, error)
+	// NewConversation starts a conversation made of successive questions.
+	NewConversation(opts *operandv1.ConversationOptions) *Conversation
+	// SubmitFeedback reports a signal of the relevance of a search result.
+	SubmitFeedback(ctx context.Context, queryID string, fileID string, signal FeedbackSignal) error
+}
+
+var _ API = (*Client)(nil)
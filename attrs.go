@@ -0,0 +1,78 @@
+package operand
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// Properties holding the attributes of local files, captured by UploadDir and Sync
+// with UploadOptions.Attributes, and restored by DownloadDir.
+const (
+	// PropertyModTime holds the modification time of a file, in RFC 3339 format.
+	PropertyModTime = "operand_mtime"
+	// PropertyMode holds the permission bits of a file.
+	PropertyMode = "operand_mode"
+	// PropertyXattrPrefix prefixes the name of each extended attribute of a file,
+	// e.g. "operand_xattr_user.origin". Values are base64-encoded.
+	PropertyXattrPrefix = "operand_xattr_"
+)
+
+// captureAttributes records the attributes of a local file into a copy of the
+// properties.
+func captureAttributes(path string, properties *filev1.Properties) (*filev1.Properties, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	properties = cloneProperties(properties)
+	properties = SetProperty(properties, PropertyModTime, TimeProperty(info.ModTime()))
+	properties = SetProperty(properties, PropertyMode, NumberProperty(float64(info.Mode().Perm())))
+
+	xattrs, err := getXattrs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extended attributes: %w", err)
+	}
+	for name, value := range xattrs {
+		properties = SetProperty(properties, PropertyXattrPrefix+name, TextProperty(base64.StdEncoding.EncodeToString(value)))
+	}
+	return properties, nil
+}
+
+// restoreAttributes applies the attributes recorded in a file's properties (if
+// any) to a local file.
+func restoreAttributes(path string, file *filev1.File) error {
+	properties := file.GetProperties()
+	for key, value := range properties.GetProperties() {
+		name, ok := strings.CutPrefix(key, PropertyXattrPrefix)
+		if !ok {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(value.GetText())
+		if err != nil {
+			return fmt.Errorf("invalid extended attribute %q: %w", name, err)
+		}
+		if err := setXattr(path, name, b); err != nil {
+			return fmt.Errorf("failed to set extended attribute %q: %w", name, err)
+		}
+	}
+	if mode, ok := PropertyNumber(properties, PropertyMode); ok {
+		if err := os.Chmod(path, os.FileMode(mode).Perm()); err != nil {
+			return err
+		}
+	}
+	if v, ok := PropertyText(properties, PropertyModTime); ok {
+		mtime, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fmt.Errorf("invalid modification time %q: %w", v, err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package operand
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SearchEvent describes a call to Client.Search, as reported to the audit hook.
+type SearchEvent struct {
+	Time time.Time `json:"time"`
+	// Query is masked by the client's redactor (see Redactor.ShowContent).
+	Query      string        `json:"query"`
+	ParentID   string        `json:"parent_id,omitempty"`
+	Filtered   bool          `json:"filtered"`
+	MaxResults int32         `json:"max_results"`
+	Matches    int           `json:"matches"`
+	Duration   time.Duration `json:"duration"`
+	Err        string        `json:"error,omitempty"`
+	// OperationID is the operation ID of the search (see WithOperationID).
+	OperationID string `json:"operation_id"`
+}
+
+// AuditHook is called after each search made with the client. It's called
+// synchronously, so it shouldn't block.
+type AuditHook func(ctx context.Context, event *SearchEvent)
+
+// WithAuditHook sets the hook called after each search.
+func WithAuditHook(hook AuditHook) Option {
+	return func(c *Client) { c.auditHook = hook }
+}
+
+// auditSearch reports a search to the audit hook and query log, if any.
+func (c *Client) auditSearch(
+	ctx context.Context,
+	started time.Time,
+	query string,
+	o *searchOptions,
+	matches int,
+	err error,
+) {
+	if c.queryLog != nil {
+		c.queryLog.record(ctx, started, query, o)
+	}
+	if c.auditHook == nil {
+		return
+	}
+	event := &SearchEvent{
+		Time:        started.UTC(),
+		Query:       c.redactor.Content(query),
+		Filtered:    o.filter != nil,
+		MaxResults:  o.maxResults,
+		Matches:     matches,
+		Duration:    c.clock.Now().Sub(started),
+		OperationID: OperationID(ctx),
+	}
+	if o.parentID != nil {
+		event.ParentID = *o.parentID
+	}
+	if err != nil {
+		event.Err = err.Error()
+	}
+	c.auditHook(ctx, event)
+}
+
+// SearchEventRecorder collects search events in memory, e.g. to be exported for
+// analysis. Its Record method is used as the audit hook.
+type SearchEventRecorder struct {
+	mu     sync.Mutex
+	events []*SearchEvent
+}
+
+// Record records the event.
+func (r *SearchEventRecorder) Record(_ context.Context, event *SearchEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// Events returns the events recorded since the last call to Events.
+func (r *SearchEventRecorder) Events() []*SearchEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
@@ -0,0 +1,12 @@
+package operand
+
+// BlobStore is a store of named blobs, such as a local directory or an object
+// storage bucket (see the s3blob package). Blob stores can be used both as the
+// target of exports and backups, and as the source of imports and restores.
+// Implementations should stream blobs, rather than buffering them in full.
+type BlobStore interface {
+	ExportTarget
+	BundleSource
+}
+
+var _ BlobStore = DirTarget("")
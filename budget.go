@@ -0,0 +1,117 @@
+package operand
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Budget bounds the cost and latency of Search and Ask calls, so that
+// high-traffic callers can trade the quality of results for speed predictably.
+// Zero fields are unbounded.
+//
+// The API doesn't expose model tiers, nor the size of the context of answers, so
+// Ask is only bounded by MaxLatency.
+type Budget struct {
+	// MaxLatency bounds the duration of the call, as timed by the client's clock
+	// (see WithClock), after which it fails with connect.CodeDeadlineExceeded. An
+	// earlier deadline of the context is kept.
+	// Answers streamed by AskStream must be complete within it.
+	MaxLatency time.Duration
+	// MaxResults caps the number of matches requested from the API by Search,
+	// including the candidates fetched for a reranker (see WithReranker).
+	MaxResults int32
+	// MaxAdjacentSnippets caps the number of adjacent snippets returned with each
+	// match (see WithAdjacentSnippets), and is requested when the caller doesn't
+	// ask for a number. Set it to a negative value to return none.
+	MaxAdjacentSnippets int32
+}
+
+type budgetKey struct{}
+
+// WithBudget returns a context whose Search and Ask calls are bounded by the given
+// budget, e.g. in the handlers of a high-traffic endpoint.
+func WithBudget(ctx context.Context, budget Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+// BudgetOf returns the budget of a context, if any.
+func BudgetOf(ctx context.Context) (Budget, bool) {
+	budget, ok := ctx.Value(budgetKey{}).(Budget)
+	return budget, ok
+}
+
+// budgetTimeout applies the latency budget of the context, if any, timed by the
+// client's clock.
+func (c *Client) budgetTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	budget, _ := BudgetOf(ctx)
+	if budget.MaxLatency <= 0 {
+		return ctx, func() {}
+	}
+	bctx := &budgetContext{Context: ctx, done: make(chan struct{})}
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-c.clock.After(budget.MaxLatency):
+			bctx.cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+			bctx.cancel(ctx.Err())
+		case <-stop:
+		}
+	}()
+	var once sync.Once
+	return bctx, func() {
+		once.Do(func() { close(stop) })
+		bctx.cancel(context.Canceled)
+	}
+}
+
+// budgetContext is a context which is cancelled once its latency budget has
+// elapsed, and then fails with context.DeadlineExceeded, like a context with a
+// deadline. Its deadline isn't reported, as it's kept by the client's clock.
+type budgetContext struct {
+	context.Context
+	done chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+func (ctx *budgetContext) Done() <-chan struct{} {
+	return ctx.done
+}
+
+func (ctx *budgetContext) Err() error {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return ctx.err
+}
+
+func (ctx *budgetContext) cancel(err error) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.err == nil {
+		ctx.err = err
+		close(ctx.done)
+	}
+}
+
+// limitSearch caps the search options to the budget.
+func (b Budget) limitSearch(o *searchOptions) {
+	if b.MaxResults > 0 {
+		if o.maxResults <= 0 || o.maxResults > b.MaxResults {
+			o.maxResults = b.MaxResults
+		}
+		if o.reranker != nil && (o.candidates <= 0 || o.candidates > b.MaxResults) {
+			o.candidates = b.MaxResults
+		}
+	}
+	switch {
+	case b.MaxAdjacentSnippets < 0:
+		none := int32(0)
+		o.adjacentSnippets = &none
+	case b.MaxAdjacentSnippets > 0 && (o.adjacentSnippets == nil || *o.adjacentSnippets > b.MaxAdjacentSnippets):
+		n := b.MaxAdjacentSnippets
+		o.adjacentSnippets = &n
+	}
+}
@@ -0,0 +1,97 @@
+package operand_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"github.com/operandinc/go-sdk/operand/v1/operandv1connect"
+)
+
+// searchService is a fake Operand Service, which passes search requests to its
+// function.
+type searchService struct {
+	operandv1connect.UnimplementedOperandServiceHandler
+	search func(ctx context.Context, req *operandv1.SearchRequest) (*operandv1.SearchResponse, error)
+}
+
+func (s *searchService) Search(ctx context.Context, req *connect.Request[operandv1.SearchRequest]) (*connect.Response[operandv1.SearchResponse], error) {
+	resp, err := s.search(ctx, req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func searchClient(t *testing.T, svc *searchService, opts ...operand.Option) *operand.Client {
+	mux := http.NewServeMux()
+	mux.Handle(operandv1connect.NewOperandServiceHandler(svc))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return operand.NewClient("key", append([]operand.Option{operand.WithEndpoint(srv.URL)}, opts...)...)
+}
+
+func TestBudgetCapsAdjacentSnippets(t *testing.T) {
+	var got *int32
+	client := searchClient(t, &searchService{search: func(_ context.Context, req *operandv1.SearchRequest) (*operandv1.SearchResponse, error) {
+		got = req.AdjacentSnippets
+		return &operandv1.SearchResponse{}, nil
+	}})
+
+	ctx := operand.WithBudget(context.Background(), operand.Budget{MaxAdjacentSnippets: 2})
+	if _, err := client.Search(ctx, "query"); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got != 2 {
+		t.Errorf("requested %v adjacent snippets, want 2", got)
+	}
+}
+
+// manualClock is a clock whose timers fire when told to.
+type manualClock struct {
+	operand.SystemClock
+	fire chan time.Time
+}
+
+func (c *manualClock) After(time.Duration) <-chan time.Time {
+	return c.fire
+}
+
+func TestBudgetLatencyUsesTheClientClock(t *testing.T) {
+	clock := &manualClock{fire: make(chan time.Time)}
+	started := make(chan struct{})
+	client := searchClient(t, &searchService{search: func(ctx context.Context, _ *operandv1.SearchRequest) (*operandv1.SearchResponse, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}, operand.WithClock(clock))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = operand.WithBudget(ctx, operand.Budget{MaxLatency: time.Hour})
+	errs := make(chan error, 1)
+	go func() {
+		_, err := client.Search(ctx, "query")
+		errs <- err
+	}()
+	<-started
+	select {
+	case clock.fire <- time.Now():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the budget isn't timed by the client's clock")
+	}
+
+	select {
+	case err := <-errs:
+		if connect.CodeOf(err) != connect.CodeDeadlineExceeded {
+			t.Errorf("got %v, want a deadline_exceeded error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the search outlived its budget")
+	}
+}
@@ -0,0 +1,446 @@
+package operand
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// A bundle is a self-contained copy of a tree of files, which can be restored with
+// ImportBundle, or read by other systems. It's made up of:
+//
+//   - bundle.json, the manifest (see BundleManifest), listing every file and folder
+//     in the tree, parents before their children.
+//   - files/<id>, the content of each file (but not folders), by original file ID.
+//
+// Paths within a bundle are slash-separated, and relative to its root.
+//
+// Incremental bundles (see BundleOptions.Base) list every file in the tree, but only
+// hold the content of the files which changed since their base bundle. The content
+// of the other files is held by an earlier bundle in the chain, identified by
+// BundleFile.Bundle.
+const (
+	BundleManifestName = "bundle.json"
+	BundleVersion      = 1
+)
+
+// BundleManifest is the manifest of a bundle.
+type BundleManifest struct {
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+	// BaseID is the ID of the bundle which an incremental bundle is based on.
+	BaseID     string       `json:"base_id,omitempty"`
+	RootID     string       `json:"root_id"` // Empty if the bundle is of the root.
+	ExportedAt time.Time    `json:"exported_at"`
+	Files      []BundleFile `json:"files"`
+}
+
+// BundleFile is a single file or folder within a bundle.
+type BundleFile struct {
+	ID       string `json:"id"`
+	ParentID string `json:"parent_id"` // Empty for the top level of the bundle.
+	Name     string `json:"name"`
+	Path     string `json:"path"` // Path of the file within the tree.
+	Folder   bool   `json:"folder"`
+	// Content is the path of the content within the bundle, empty for folders.
+	Content string `json:"content,omitempty"`
+	// Bundle is the ID of the earlier bundle which holds the content, if the file
+	// is unchanged within an incremental bundle. Empty if this bundle holds it.
+	Bundle    string    `json:"bundle,omitempty"`
+	SizeBytes int64     `json:"size_bytes"`
+	SHA256    string    `json:"sha256,omitempty"` // Hex-encoded digest of the content.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Properties are the file's properties, encoded with protojson.
+	Properties json.RawMessage `json:"properties,omitempty"`
+	// Embedding is set if the bundle was exported with an Embedder.
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+// Embedder computes embeddings for files as they're exported, for use by systems
+// which search bundles offline. The API doesn't expose its own embeddings.
+type Embedder interface {
+	Embed(ctx context.Context, file *filev1.File, content []byte) ([]float32, error)
+}
+
+// BundleOptions configures ExportBundle.
+type BundleOptions struct {
+	// Embedder, if set, is used to compute an embedding for each file.
+	Embedder Embedder
+	// Base, if set, makes the bundle incremental: the content of files which are
+	// unchanged since the base bundle (i.e. have the same size and update time) is
+	// omitted, and restored from the base (or its own bases) instead.
+	Base *BundleManifest
+}
+
+// BundleSource is a bundle to be read, e.g. a local directory or an archive.
+type BundleSource interface {
+	// Open opens the file with the given slash-separated name within the source.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+var _ BundleSource = DirTarget("")
+
+// Open opens the file within the directory. Names which aren't valid paths (see
+// fs.ValidPath), e.g. which would escape the directory, are rejected.
+func (d DirTarget) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// ZipSource is a BundleSource which reads from a zip archive.
+type ZipSource struct {
+	zr *zip.Reader
+}
+
+var _ BundleSource = (*ZipSource)(nil)
+
+// NewZipSource returns a new ZipSource which reads the archive from r.
+func NewZipSource(r io.ReaderAt, size int64) (*ZipSource, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	return &ZipSource{zr: zr}, nil
+}
+
+// Open opens the file within the archive.
+func (z *ZipSource) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	return z.zr.Open(name)
+}
+
+// ExportBundle exports the tree of files below the given folder (empty for the root)
+// to the target as a bundle.
+func (c *Client) ExportBundle(
+	ctx context.Context,
+	rootID string,
+	target ExportTarget,
+	opts BundleOptions,
+) (*BundleManifest, error) {
+	manifest := &BundleManifest{
+		Version:    BundleVersion,
+		ID:         c.newBundleID(),
+		RootID:     rootID,
+		ExportedAt: c.clock.Now().UTC(),
+	}
+	base := make(map[string]*BundleFile)
+	if opts.Base != nil {
+		if opts.Base.RootID != rootID {
+			return nil, fmt.Errorf("base bundle is of %q, not %q", opts.Base.RootID, rootID)
+		}
+		manifest.BaseID = opts.Base.ID
+		for i := range opts.Base.Files {
+			base[opts.Base.Files[i].ID] = &opts.Base.Files[i]
+		}
+	}
+
+	err := c.Walk(ctx, rootID, func(file *filev1.File, p string) error {
+		bf, err := c.exportBundleFile(ctx, file, p, target, opts, base[file.Id])
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", p, err)
+		}
+		if bf.ParentID == rootID {
+			bf.ParentID = ""
+		}
+		manifest.Files = append(manifest.Files, *bf)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeBundleManifest(ctx, target, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (c *Client) exportBundleFile(
+	ctx context.Context,
+	file *filev1.File,
+	p string,
+	target ExportTarget,
+	opts BundleOptions,
+	base *BundleFile,
+) (*BundleFile, error) {
+	bf := &BundleFile{
+		ID:        file.Id,
+		ParentID:  file.GetParentId(),
+		Name:      file.Name,
+		Path:      p,
+		Folder:    IsFolder(file),
+		SizeBytes: file.GetSizeBytes(),
+		CreatedAt: file.GetCreatedAt().AsTime(),
+		UpdatedAt: file.GetUpdatedAt().AsTime(),
+	}
+	if file.Properties != nil {
+		properties, err := protojson.Marshal(file.Properties)
+		if err != nil {
+			return nil, err
+		}
+		bf.Properties = properties
+	}
+	if bf.Folder {
+		return bf, nil
+	}
+	if base != nil && !base.Folder && base.SizeBytes == bf.SizeBytes && base.UpdatedAt.Equal(bf.UpdatedAt) {
+		bf.Content, bf.SHA256, bf.Embedding = base.Content, base.SHA256, base.Embedding
+		bf.Bundle = base.Bundle
+		if bf.Bundle == "" {
+			bf.Bundle = opts.Base.ID
+		}
+		return bf, nil
+	}
+
+	resp, err := c.download(ctx, file.DownloadUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bf.Content = path.Join("files", file.Id)
+	w, err := target.Create(ctx, bf.Content)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	dst := io.MultiWriter(w, h)
+
+	// The content is only buffered if it's needed to compute an embedding.
+	var content []byte
+	if opts.Embedder != nil {
+		if content, err = io.ReadAll(resp.Body); err == nil {
+			_, err = dst.Write(content)
+			bf.SizeBytes = int64(len(content))
+		}
+	} else {
+		bf.SizeBytes, err = io.Copy(dst, resp.Body)
+	}
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	bf.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	if opts.Embedder != nil {
+		if bf.Embedding, err = opts.Embedder.Embed(ctx, file, content); err != nil {
+			return nil, fmt.Errorf("failed to embed: %w", err)
+		}
+	}
+	return bf, nil
+}
+
+func writeBundleManifest(ctx context.Context, target ExportTarget, manifest *BundleManifest) error {
+	w, err := target.Create(ctx, BundleManifestName)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// ReadBundleManifest reads the manifest of a bundle.
+func ReadBundleManifest(ctx context.Context, source BundleSource) (*BundleManifest, error) {
+	r, err := source.Open(ctx, BundleManifestName)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	manifest := new(BundleManifest)
+	if err := json.NewDecoder(r).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("failed to read bundle manifest: %w", err)
+	}
+	if manifest.Version != BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", manifest.Version)
+	}
+	// The manifest isn't trusted, so content must be within the files of a bundle.
+	for _, bf := range manifest.Files {
+		if bf.Content != "" && (!fs.ValidPath(bf.Content) || !strings.HasPrefix(bf.Content, "files/")) {
+			return nil, fmt.Errorf("invalid content path %q of %s in bundle manifest", bf.Content, bf.ID)
+		}
+	}
+	return manifest, nil
+}
+
+// ImportBundle restores a bundle into the given folder (empty for the root), and
+// returns a mapping from the IDs of the files in the bundle to those of the files
+// created. Properties which refer to other files in the bundle (e.g. those of
+// summary sidecars) are updated to refer to the restored files.
+func (c *Client) ImportBundle(
+	ctx context.Context,
+	source BundleSource,
+	parentID string,
+) (map[string]string, error) {
+	return c.ImportBundles(ctx, []BundleSource{source}, parentID)
+}
+
+// ImportBundles restores a chain of bundles, i.e. a full bundle followed by the
+// incremental bundles based on it, in order. The tree is restored as of the last
+// bundle in the chain. See ImportBundle.
+func (c *Client) ImportBundles(
+	ctx context.Context,
+	chain []BundleSource,
+	parentID string,
+) (map[string]string, error) {
+	manifest, sources, err := readBundleChain(ctx, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]string, len(manifest.Files))
+	if parentID != "" {
+		ids[""] = parentID
+	}
+	restore := func(bf *BundleFile) error {
+		source, err := sources.source(bf)
+		if err != nil {
+			return err
+		}
+		return c.importBundleFile(ctx, source, bf, ids)
+	}
+
+	// Files which refer to others are restored last, once their IDs are known.
+	var deferred []*BundleFile
+	for i := range manifest.Files {
+		bf := &manifest.Files[i]
+		if isReference(bf) {
+			deferred = append(deferred, bf)
+			continue
+		}
+		if err := restore(bf); err != nil {
+			return ids, err
+		}
+	}
+	for _, bf := range deferred {
+		if err := restore(bf); err != nil {
+			return ids, err
+		}
+	}
+	return ids, nil
+}
+
+// bundleChain maps the IDs of the bundles in a chain to their sources.
+type bundleChain struct {
+	last    string
+	sources map[string]BundleSource
+}
+
+// readBundleChain reads the manifests of a chain of bundles, checking that each is
+// based on the one before it. It returns the manifest of the last bundle.
+func readBundleChain(ctx context.Context, chain []BundleSource) (*BundleManifest, *bundleChain, error) {
+	if len(chain) == 0 {
+		return nil, nil, errors.New("no bundles given")
+	}
+	bc := &bundleChain{sources: make(map[string]BundleSource, len(chain))}
+	var manifest *BundleManifest
+	for i, source := range chain {
+		m, err := ReadBundleManifest(ctx, source)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i > 0 && m.BaseID != manifest.ID {
+			return nil, nil, fmt.Errorf("bundle %s is based on %q, not %s", m.ID, m.BaseID, manifest.ID)
+		}
+		bc.sources[m.ID] = source
+		bc.last = m.ID
+		manifest = m
+	}
+	return manifest, bc, nil
+}
+
+// source returns the source holding the content of the file.
+func (bc *bundleChain) source(bf *BundleFile) (BundleSource, error) {
+	id := bf.Bundle
+	if id == "" {
+		id = bc.last
+	}
+	source, ok := bc.sources[id]
+	if !ok {
+		return nil, fmt.Errorf("content of %s is in bundle %s, which isn't in the chain", bf.Path, id)
+	}
+	return source, nil
+}
+
+// newBundleID returns a new, unique bundle ID.
+func (c *Client) newBundleID() string {
+	var b [8]byte
+	c.rand.Read(b[:])
+	return c.clock.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b[:])
+}
+
+// isReference reports whether the file's properties refer to another file.
+func isReference(bf *BundleFile) bool {
+	var props struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	json.Unmarshal(bf.Properties, &props)
+	_, ok := props.Properties[PropertySummaryOf]
+	return ok
+}
+
+func (c *Client) importBundleFile(
+	ctx context.Context,
+	source BundleSource,
+	bf *BundleFile,
+	ids map[string]string,
+) error {
+	var properties *filev1.Properties
+	if len(bf.Properties) > 0 {
+		properties = new(filev1.Properties)
+		if err := protojson.Unmarshal(bf.Properties, properties); err != nil {
+			return fmt.Errorf("invalid properties for %s: %w", bf.Path, err)
+		}
+		if original, ok := PropertyText(properties, PropertySummaryOf); ok {
+			if id, ok := ids[original]; ok {
+				properties = SetProperty(properties, PropertySummaryOf, TextProperty(id))
+			}
+		}
+	}
+
+	var parent *string
+	if id, ok := ids[bf.ParentID]; ok {
+		parent = &id
+	} else if bf.ParentID != "" {
+		return fmt.Errorf("parent of %s not found in bundle", bf.Path)
+	}
+
+	var data io.Reader
+	if !bf.Folder {
+		r, err := source.Open(ctx, bf.Content)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		data = r
+	}
+	resp, err := c.CreateFile(ctx, bf.Name, parent, data, properties)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", bf.Path, err)
+	}
+	ids[bf.ID] = resp.File.Id
+	return nil
+}
@@ -0,0 +1,64 @@
+package operand_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	operand "github.com/operandinc/go-sdk"
+	"github.com/operandinc/go-sdk/operandtest"
+)
+
+func TestImportBundleRejectsContentOutsideTheBundle(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "secret"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "bundle")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"../secret", "files/../../secret", "bundle.json"} {
+		t.Run(content, func(t *testing.T) {
+			manifest, err := json.Marshal(&operand.BundleManifest{
+				Version: operand.BundleVersion,
+				Files:   []operand.BundleFile{{ID: "file", Name: "secret", Path: "secret", Content: content}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, operand.BundleManifestName), manifest, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := client.ImportBundle(context.Background(), operand.DirTarget(dir), ""); err == nil {
+				t.Error("ImportBundle succeeded")
+			}
+			if _, err := operand.VerifyBundles(context.Background(), []operand.BundleSource{operand.DirTarget(dir)}, client); err == nil {
+				t.Error("VerifyBundles succeeded")
+			}
+			if files := srv.Files(); len(files) != 0 {
+				t.Errorf("imported %d files", len(files))
+			}
+		})
+	}
+}
+
+func TestDirTargetOpenRejectsEscapingNames(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bundle")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"../secret", "/etc/passwd", "files/../../secret"} {
+		if r, err := operand.DirTarget(dir).Open(context.Background(), name); err == nil {
+			r.Close()
+			t.Errorf("opened %q", name)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package operand
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// BundleDiscrepancy is a problem found when verifying a bundle.
+type BundleDiscrepancy struct {
+	ID      string `json:"id,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Problem string `json:"problem"`
+}
+
+// BundleVerification is the result of verifying a bundle.
+type BundleVerification struct {
+	BundleID string `json:"bundle_id"`
+	Files    int    `json:"files"`
+	Folders  int    `json:"folders"`
+	// Live is set if the bundle was compared against the live tenant.
+	Live          bool                `json:"live"`
+	Discrepancies []BundleDiscrepancy `json:"discrepancies"`
+}
+
+// OK reports whether no discrepancies were found.
+func (v *BundleVerification) OK() bool {
+	return len(v.Discrepancies) == 0
+}
+
+// VerifyBundles verifies a chain of bundles (see ImportBundles), as of its last
+// bundle. It checks that the manifest is well-formed, and that the content of every
+// file is present and matches its recorded size and checksum. If live is set, the
+// bundle is also compared against the tree it was exported from, reporting files
+// which have since been added, removed, or changed. An error is only returned if
+// verification couldn't be carried out, e.g. because a manifest is unreadable.
+func VerifyBundles(ctx context.Context, chain []BundleSource, live *Client) (*BundleVerification, error) {
+	manifest, sources, err := readBundleChain(ctx, chain)
+	if err != nil {
+		return nil, err
+	}
+	v := &BundleVerification{BundleID: manifest.ID, Live: live != nil}
+	problem := func(bf *BundleFile, format string, args ...any) {
+		d := BundleDiscrepancy{Problem: fmt.Sprintf(format, args...)}
+		if bf != nil {
+			d.ID, d.Path = bf.ID, bf.Path
+		}
+		v.Discrepancies = append(v.Discrepancies, d)
+	}
+
+	byID := make(map[string]*BundleFile, len(manifest.Files))
+	for i := range manifest.Files {
+		bf := &manifest.Files[i]
+		if bf.Folder {
+			v.Folders++
+		} else {
+			v.Files++
+		}
+
+		if byID[bf.ID] != nil {
+			problem(bf, "duplicate file ID")
+			continue
+		}
+		byID[bf.ID] = bf
+		if bf.ParentID == "" {
+			if bf.Path != bf.Name {
+				problem(bf, "path doesn't match name %q", bf.Name)
+			}
+		} else if parent := byID[bf.ParentID]; parent == nil {
+			problem(bf, "parent %s isn't listed before it", bf.ParentID)
+		} else if !parent.Folder {
+			problem(bf, "parent %s isn't a folder", bf.ParentID)
+		} else if bf.Path != path.Join(parent.Path, bf.Name) {
+			problem(bf, "path doesn't match parent path %q", parent.Path)
+		}
+
+		if !bf.Folder {
+			if err := verifyBundleContent(ctx, sources, bf); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				problem(bf, "%v", err)
+			}
+		}
+	}
+
+	if live == nil {
+		return v, nil
+	}
+	seen := make(map[string]bool, len(byID))
+	err = live.Walk(ctx, manifest.RootID, func(file *filev1.File, p string) error {
+		bf := byID[file.Id]
+		if bf == nil {
+			problem(&BundleFile{ID: file.Id, Path: p}, "missing from bundle")
+			return nil
+		}
+		seen[file.Id] = true
+		if bf.Path != p {
+			problem(bf, "moved to %q", p)
+		}
+		if !IsFolder(file) && (file.GetSizeBytes() != bf.SizeBytes || !file.GetUpdatedAt().AsTime().Equal(bf.UpdatedAt)) {
+			problem(bf, "changed since the bundle was exported")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range manifest.Files {
+		if bf := &manifest.Files[i]; !seen[bf.ID] {
+			problem(bf, "no longer exists")
+		}
+	}
+	return v, nil
+}
+
+// verifyBundleContent checks the size and checksum of a file's content.
+func verifyBundleContent(ctx context.Context, sources *bundleChain, bf *BundleFile) error {
+	source, err := sources.source(bf)
+	if err != nil {
+		return err
+	}
+	r, err := source.Open(ctx, bf.Content)
+	if err != nil {
+		return fmt.Errorf("content unreadable: %w", err)
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return fmt.Errorf("content unreadable: %w", err)
+	}
+	if n != bf.SizeBytes {
+		return fmt.Errorf("content is %d bytes, expected %d", n, bf.SizeBytes)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != bf.SHA256 {
+		return fmt.Errorf("checksum mismatch: content is %s, expected %s", sum, bf.SHA256)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+)
+
+// HeaderRequestID is the header of responses which holds the ID the API assigned to
+// the request, for support tickets.
+const HeaderRequestID = "X-Request-Id"
+
+// CallInfo holds the response metadata of a call, e.g. rate-limit headers, request
+// IDs, or server timing, which is otherwise hidden by the helper methods.
+type CallInfo struct {
+	// OperationID is the operation ID of the call (see WithOperationID).
+	OperationID string
+	// RequestID is the ID the API assigned to the request (see HeaderRequestID), if
+	// reported.
+	RequestID string
+	Header    http.Header
+	Trailer   http.Header
+	// Stale is set if the call was served by a fallback (see WithFallback), whose
+	// data is current as of StaleAsOf.
+	Stale     bool
+	StaleAsOf time.Time
+}
+
+// Get returns the first value of the given key from the headers, falling back to
+// the trailers.
+func (ci *CallInfo) Get(key string) string {
+	if v := ci.Header.Get(key); v != "" {
+		return v
+	}
+	return ci.Trailer.Get(key)
+}
+
+type callInfoKey struct{}
+
+type callInfoSink struct {
+	mu   sync.Mutex
+	info *CallInfo
+}
+
+// WithCallInfo returns a context which captures the response metadata of calls made
+// with it into info. This works for the helper methods on Client, as well as for
+// the service clients. If several calls are made with the context, info holds the
+// metadata of the last one to complete.
+func WithCallInfo(ctx context.Context, info *CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, &callInfoSink{info: info})
+}
+
+// recordCallInfo records response metadata into the context's CallInfo, if any.
+func recordCallInfo(ctx context.Context, header, trailer http.Header) {
+	sink, ok := ctx.Value(callInfoKey{}).(*callInfoSink)
+	if !ok {
+		return
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.info.OperationID = OperationID(ctx)
+	sink.info.Header = header.Clone()
+	sink.info.Trailer = trailer.Clone()
+	sink.info.RequestID = sink.info.Get(HeaderRequestID)
+	sink.info.Stale = false
+	sink.info.StaleAsOf = time.Time{}
+}
+
+// recordStale marks the context's CallInfo, if any, as served by a fallback.
+func recordStale(ctx context.Context, asOf time.Time) {
+	sink, ok := ctx.Value(callInfoKey{}).(*callInfoSink)
+	if !ok {
+		return
+	}
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	sink.info.OperationID = OperationID(ctx)
+	sink.info.Stale = true
+	sink.info.StaleAsOf = asOf
+}
+
+// callInfoInterceptor records the response metadata of RPCs.
+type callInfoInterceptor struct{}
+
+var _ connect.Interceptor = callInfoInterceptor{}
+
+func (callInfoInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if err != nil {
+			var ce *connect.Error
+			if errors.As(err, &ce) {
+				recordCallInfo(ctx, ce.Meta(), nil)
+			}
+			return nil, err
+		}
+		recordCallInfo(ctx, resp.Header(), resp.Trailer())
+		return resp, nil
+	}
+}
+
+func (callInfoInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		if _, ok := ctx.Value(callInfoKey{}).(*callInfoSink); !ok {
+			return conn
+		}
+		return &callInfoConn{StreamingClientConn: conn, ctx: ctx}
+	}
+}
+
+func (callInfoInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
+
+// callInfoConn records the response metadata of a stream once it has been closed,
+// at which point the trailers are available.
+type callInfoConn struct {
+	connect.StreamingClientConn
+	ctx context.Context
+}
+
+func (c *callInfoConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	recordCallInfo(c.ctx, c.ResponseHeader(), c.ResponseTrailer())
+	return err
+}
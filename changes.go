@@ -0,0 +1,33 @@
+package operand
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// ListChangedSince returns the files and folders below a folder (empty for the whole
+// tenant) which were created or updated after the given time, oldest change first.
+// Incremental consumers can pass the update time of the last file returned as since
+// on their next call.
+//
+// The API has no changes feed, so the tree is listed and filtered by update time,
+// and deleted files aren't reported.
+func (c *Client) ListChangedSince(ctx context.Context, scope string, since time.Time) ([]*filev1.File, error) {
+	var changed []*filev1.File
+	err := c.Walk(ctx, scope, func(file *filev1.File, _ string) error {
+		if file.GetUpdatedAt().AsTime().After(since) {
+			changed = append(changed, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(changed, func(i, j int) bool {
+		return changed[i].GetUpdatedAt().AsTime().Before(changed[j].GetUpdatedAt().AsTime())
+	})
+	return changed, nil
+}
@@ -0,0 +1,338 @@
+// Package chunk splits documents into smaller pieces prior to upload, using
+// profiles tuned to the kind of content being split (e.g. code, or markdown).
+package chunk
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Chunk is a piece of a document. Chunks may overlap.
+type Chunk struct {
+	Index int
+	Text  string
+	// Start and End are the byte offsets of the chunk within the document.
+	Start, End int
+	// StartLine and EndLine are the (1-based, inclusive) lines spanned by the chunk.
+	StartLine, EndLine int
+	// StartPage and EndPage are the (1-based, inclusive) pages spanned by the chunk,
+	// where pages are separated by form feeds.
+	StartPage, EndPage int
+}
+
+// Segmenter divides a document into segments, the units which chunks are made of,
+// by returning the byte offsets at which segments start. Segments are kept whole
+// where possible, and only split if they're larger than a chunk.
+type Segmenter func(text string) []int
+
+// Profile configures how a kind of content is chunked.
+type Profile struct {
+	Name string
+	// Size is the target size of a chunk, in bytes.
+	Size int
+	// Overlap is the maximum number of bytes repeated from the end of one chunk at
+	// the start of the next, made up of whole segments.
+	Overlap int
+	Segment Segmenter
+}
+
+// Built-in profiles.
+var (
+	Text = &Profile{
+		Name:    "text",
+		Size:    2000,
+		Overlap: 200,
+		Segment: Paragraphs,
+	}
+	Markdown = &Profile{
+		Name:    "markdown",
+		Size:    2000,
+		Overlap: 200,
+		Segment: MarkdownSections,
+	}
+	Code = &Profile{
+		Name:    "code",
+		Size:    1500,
+		Overlap: 0,
+		Segment: CodeBlocks,
+	}
+	Chat = &Profile{
+		Name:    "chat",
+		Size:    1000,
+		Overlap: 300,
+		Segment: Lines,
+	}
+	Legal = &Profile{
+		Name:    "legal",
+		Size:    3000,
+		Overlap: 400,
+		Segment: Clauses,
+	}
+)
+
+// Profiles holds the profiles which can be selected by name, e.g. via a property.
+var Profiles = map[string]*Profile{
+	Text.Name:     Text,
+	Markdown.Name: Markdown,
+	Code.Name:     Code,
+	Chat.Name:     Chat,
+	Legal.Name:    Legal,
+}
+
+// Extensions maps file extensions to the profile used for them by ForFile.
+var Extensions = map[string]*Profile{
+	".md":       Markdown,
+	".markdown": Markdown,
+	".mdx":      Markdown,
+	".go":       Code,
+	".py":       Code,
+	".js":       Code,
+	".jsx":      Code,
+	".ts":       Code,
+	".tsx":      Code,
+	".java":     Code,
+	".kt":       Code,
+	".c":        Code,
+	".h":        Code,
+	".cc":       Code,
+	".cpp":      Code,
+	".hpp":      Code,
+	".cs":       Code,
+	".rs":       Code,
+	".rb":       Code,
+	".php":      Code,
+	".swift":    Code,
+	".scala":    Code,
+	".sh":       Code,
+	".sql":      Code,
+	".vtt":      Chat,
+	".srt":      Chat,
+}
+
+// ForName returns the named profile, or nil if there's no such profile.
+func ForName(name string) *Profile {
+	return Profiles[strings.ToLower(name)]
+}
+
+// ForFile returns the profile for a file, based on its extension. It falls back to
+// the Text profile.
+func ForFile(name string) *Profile {
+	if p, ok := Extensions[strings.ToLower(path.Ext(name))]; ok {
+		return p
+	}
+	return Text
+}
+
+// Split splits the text into chunks.
+func (p *Profile) Split(text string) []Chunk {
+	if text == "" {
+		return nil
+	}
+	size := p.Size
+	if size <= 0 {
+		size = Text.Size
+	}
+
+	segments := p.segments(text, size)
+	lines, pages := Index(text, '\n'), Index(text, '\f')
+
+	var (
+		chunks []Chunk
+		first  int // Index of the first segment of the current chunk.
+	)
+	for first < len(segments) {
+		last := first + 1 // Exclusive.
+		for last < len(segments) && segments[last].end-segments[first].start <= size {
+			last++
+		}
+		start, end := segments[first].start, segments[last-1].end
+		chunks = append(chunks, Chunk{
+			Index:     len(chunks),
+			Text:      text[start:end],
+			Start:     start,
+			End:       end,
+			StartLine: lines.At(start),
+			EndLine:   lines.At(end - 1),
+			StartPage: pages.At(start),
+			EndPage:   pages.At(end - 1),
+		})
+		if last == len(segments) {
+			break
+		}
+
+		// Back up over whole segments to form the overlap, making sure to always move
+		// forward by at least one segment.
+		next := last
+		for next-1 > first && end-segments[next-1].start <= p.Overlap {
+			next--
+		}
+		first = next
+	}
+	return chunks
+}
+
+// Positions records the offsets at which lines (or pages) start within a document.
+type Positions []int
+
+// Index returns the positions of the lines (or pages) of the text, which are
+// separated by sep.
+func Index(text string, sep byte) Positions {
+	positions := Positions{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == sep {
+			positions = append(positions, i+1)
+		}
+	}
+	return positions
+}
+
+// At returns the (1-based) line or page at the given byte offset.
+func (p Positions) At(offset int) int {
+	return sort.Search(len(p), func(i int) bool { return p[i] > offset })
+}
+
+type span struct {
+	start, end int
+}
+
+// segments returns the segments of the text, splitting any larger than size.
+func (p *Profile) segments(text string, size int) []span {
+	segment := p.Segment
+	if segment == nil {
+		segment = Paragraphs
+	}
+	offsets := append([]int{0}, segment(text)...)
+	sort.Ints(offsets)
+
+	var spans []span
+	for i, start := range offsets {
+		end := len(text)
+		if i+1 < len(offsets) {
+			end = offsets[i+1]
+		}
+		if start >= end || start < 0 || end > len(text) {
+			continue
+		}
+		for end-start > size {
+			cut := breakBefore(text[start:start+size]) + start
+			spans = append(spans, span{start, cut})
+			start = cut
+		}
+		spans = append(spans, span{start, end})
+	}
+	return spans
+}
+
+// breakBefore returns the offset at which to break text which is too large for a
+// single chunk, preferring the end of a line, then a sentence, then a word.
+func breakBefore(text string) int {
+	if i := strings.LastIndexByte(text, '\n'); i > len(text)/2 {
+		return i + 1
+	}
+	if i := strings.LastIndex(text, ". "); i > len(text)/2 {
+		return i + 2
+	}
+	if i := strings.LastIndexFunc(text, unicode.IsSpace); i > len(text)/2 {
+		return i + 1
+	}
+	// Avoid splitting a UTF-8 sequence.
+	i := len(text)
+	for i > 1 && text[i-1]&0xC0 == 0x80 {
+		i--
+	}
+	if i > 1 && text[i-1] >= 0xC0 {
+		i--
+	}
+	return i
+}
+
+// lineStarts calls fn with the offset and content of each line, and returns the
+// offsets for which it returns true.
+func lineStarts(text string, fn func(prev, line string) bool) []int {
+	var (
+		offsets []int
+		prev    string
+	)
+	for start := 0; start < len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start + 1
+		}
+		line := strings.TrimRight(text[start:end], "\r\n")
+		if start > 0 && fn(prev, line) {
+			offsets = append(offsets, start)
+		}
+		prev = line
+		start = end
+	}
+	return offsets
+}
+
+func blank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// Lines is a Segmenter which treats each line as a segment, e.g. a message within
+// a chat transcript.
+func Lines(text string) []int {
+	return lineStarts(text, func(string, string) bool { return true })
+}
+
+// Paragraphs is a Segmenter which splits text at blank lines.
+func Paragraphs(text string) []int {
+	return lineStarts(text, func(prev, line string) bool {
+		return blank(prev) && !blank(line)
+	})
+}
+
+// MarkdownSections is a Segmenter which splits markdown at headings, and at
+// paragraphs within sections.
+func MarkdownSections(text string) []int {
+	fenced := false
+	return lineStarts(text, func(prev, line string) bool {
+		if strings.HasPrefix(strings.TrimSpace(prev), "```") {
+			fenced = !fenced
+		}
+		if fenced {
+			return false
+		}
+		return strings.HasPrefix(line, "#") || (blank(prev) && !blank(line))
+	})
+}
+
+// CodeBlocks is a Segmenter which splits source code at unindented lines that
+// follow a blank line, which typically start top-level declarations.
+func CodeBlocks(text string) []int {
+	return lineStarts(text, func(prev, line string) bool {
+		return blank(prev) && !blank(line) && !unicode.IsSpace(rune(line[0]))
+	})
+}
+
+// Clauses is a Segmenter which splits legal documents at numbered sections and
+// clauses (e.g. "1.2", "(a)", "Section 4", "Article IV", or "§ 3"), and paragraphs.
+func Clauses(text string) []int {
+	return lineStarts(text, func(prev, line string) bool {
+		return (blank(prev) && !blank(line)) || clauseHeading(strings.TrimSpace(line))
+	})
+}
+
+func clauseHeading(line string) bool {
+	for _, prefix := range []string{"§", "Section ", "SECTION ", "Article ", "ARTICLE ", "Clause ", "CLAUSE "} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	if strings.HasPrefix(line, "(") {
+		i := strings.IndexByte(line, ')')
+		return i > 1 && i <= 5 && strings.IndexFunc(line[1:i], func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) < 0
+	}
+	// Numbered, e.g. "1." or "2.3.1".
+	i := strings.IndexFunc(line, func(r rune) bool { return !unicode.IsDigit(r) && r != '.' })
+	return i > 0 && line[0] != '.' && strings.Contains(line[:i], ".")
+}
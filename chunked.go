@@ -0,0 +1,204 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/operandinc/go-sdk/chunk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Properties set on chunked files.
+const (
+	// PropertyChunkProfile selects the chunk profile (see chunk.Profiles) used for a
+	// file. It may be set on the file itself, or on the folder it's uploaded into.
+	PropertyChunkProfile = "operand_chunk_profile"
+	// PropertyChunkIndex holds the index of a chunk within the original file.
+	PropertyChunkIndex = "operand_chunk_index"
+	// The span of a chunk within the original file, as byte offsets (with an
+	// exclusive end), and as 1-based inclusive lines and pages.
+	PropertyChunkStart     = "operand_chunk_start"
+	PropertyChunkEnd       = "operand_chunk_end"
+	PropertyChunkStartLine = "operand_chunk_start_line"
+	PropertyChunkEndLine   = "operand_chunk_end_line"
+	PropertyChunkStartPage = "operand_chunk_start_page"
+	PropertyChunkEndPage   = "operand_chunk_end_page"
+)
+
+// ChunkedFile is a file which has been split into chunks. The chunks are uploaded as
+// individual files within a folder that stands in for the original file.
+type ChunkedFile struct {
+	Folder *filev1.File
+	Chunks []*filev1.File
+}
+
+// CreateChunkedFile splits a text file into chunks, and uploads them into a new
+// folder with the given name. The chunk profile is taken from the PropertyChunkProfile
+// property of the file, falling back to that of the parent folder, and then to the
+// file's extension (see chunk.ForFile).
+func (c *Client) CreateChunkedFile(
+	ctx context.Context,
+	name string,
+	parent *string,
+	data io.Reader,
+	properties *filev1.Properties,
+) (*ChunkedFile, error) {
+	profile, err := c.ChunkProfile(ctx, name, parent, properties)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+
+	folderProperties := SetProperty(
+		cloneProperties(properties),
+		PropertyChunkProfile,
+		TextProperty(profile.Name),
+	)
+	folder, err := c.CreateFile(ctx, name, parent, nil, folderProperties)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChunkedFile{Folder: folder.File}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for _, ch := range profile.Split(string(content)) {
+		chunkProperties := cloneProperties(folderProperties)
+		for key, v := range map[string]int{
+			PropertyChunkIndex:     ch.Index,
+			PropertyChunkStart:     ch.Start,
+			PropertyChunkEnd:       ch.End,
+			PropertyChunkStartLine: ch.StartLine,
+			PropertyChunkEndLine:   ch.EndLine,
+			PropertyChunkStartPage: ch.StartPage,
+			PropertyChunkEndPage:   ch.EndPage,
+		} {
+			chunkProperties = SetProperty(chunkProperties, key, NumberProperty(float64(v)))
+		}
+		chunkName := fmt.Sprintf("%s-%04d%s", base, ch.Index, ext)
+		resp, err := c.CreateFile(ctx, chunkName, &folder.File.Id, strings.NewReader(ch.Text), chunkProperties)
+		if err != nil {
+			return result, fmt.Errorf("failed to upload chunk %d: %w", ch.Index, err)
+		}
+		result.Chunks = append(result.Chunks, resp.File)
+	}
+	return result, nil
+}
+
+// Citation is the location of (part of) a chunk within the original file.
+type Citation struct {
+	// FileID is the ID of the original file, i.e. the folder holding the chunk.
+	FileID  string
+	ChunkID string
+	// Start and End are byte offsets within the original file (End is exclusive).
+	Start, End int
+	// Lines and pages are 1-based and inclusive.
+	StartLine, EndLine int
+	StartPage, EndPage int
+}
+
+// ChunkSpan returns the span of a chunk within the original file, as recorded by
+// CreateChunkedFile. It returns false if the file isn't a chunk.
+func ChunkSpan(file *filev1.File) (*Citation, bool) {
+	props := file.GetProperties()
+	span := &Citation{FileID: file.GetParentId(), ChunkID: file.GetId()}
+	for key, v := range map[string]*int{
+		PropertyChunkStart:     &span.Start,
+		PropertyChunkEnd:       &span.End,
+		PropertyChunkStartLine: &span.StartLine,
+		PropertyChunkEndLine:   &span.EndLine,
+		PropertyChunkStartPage: &span.StartPage,
+		PropertyChunkEndPage:   &span.EndPage,
+	} {
+		n, ok := PropertyNumber(props, key)
+		if !ok {
+			return nil, false
+		}
+		*v = int(n)
+	}
+	return span, true
+}
+
+// Cite maps a search match on a chunk back to its location within the original
+// file, for citation display. The chunk is downloaded to find the snippet within
+// it; if the snippet can't be found (e.g. it was normalized by the server), the
+// span of the whole chunk is returned.
+func (c *Client) Cite(ctx context.Context, match *operandv1.ContentMatch, file *filev1.File) (*Citation, error) {
+	span, ok := ChunkSpan(file)
+	if !ok {
+		return nil, fmt.Errorf("file %s is not a chunk", file.GetId())
+	}
+	if match.Snippet == "" {
+		return span, nil
+	}
+
+	resp, err := c.download(ctx, file.DownloadUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	i := strings.Index(string(content), match.Snippet)
+	if i < 0 {
+		return span, nil
+	}
+	j := i + len(match.Snippet)
+	lines, pages := chunk.Index(string(content), '\n'), chunk.Index(string(content), '\f')
+	return &Citation{
+		FileID:    span.FileID,
+		ChunkID:   span.ChunkID,
+		Start:     span.Start + i,
+		End:       span.Start + j,
+		StartLine: span.StartLine + lines.At(i) - 1,
+		EndLine:   span.StartLine + lines.At(j-1) - 1,
+		StartPage: span.StartPage + pages.At(i) - 1,
+		EndPage:   span.StartPage + pages.At(j-1) - 1,
+	}, nil
+}
+
+// ChunkProfile returns the chunk profile which CreateChunkedFile would use for a file.
+func (c *Client) ChunkProfile(
+	ctx context.Context,
+	name string,
+	parent *string,
+	properties *filev1.Properties,
+) (*chunk.Profile, error) {
+	profileName, ok := PropertyText(properties, PropertyChunkProfile)
+	if !ok && parent != nil {
+		resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+			Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: *parent}},
+		}))
+		if err != nil {
+			return nil, err
+		}
+		profileName, ok = PropertyText(resp.Msg.File.GetProperties(), PropertyChunkProfile)
+	}
+	if !ok {
+		return chunk.ForFile(name), nil
+	}
+	profile := chunk.ForName(profileName)
+	if profile == nil {
+		return nil, fmt.Errorf("unknown chunk profile %q", profileName)
+	}
+	return profile, nil
+}
+
+func cloneProperties(properties *filev1.Properties) *filev1.Properties {
+	if properties == nil {
+		return nil
+	}
+	return proto.Clone(properties).(*filev1.Properties)
+}
@@ -0,0 +1,84 @@
+package operand
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Clock is a source of time for everything in the SDK that waits or keeps time,
+// e.g. backoff, batching, and schedulers. It can be replaced with a fake clock to
+// make tests of such behavior deterministic and fast.
+type Clock interface {
+	Now() time.Time
+	// After waits for the duration to elapse, and then sends the current time on
+	// the returned channel.
+	After(d time.Duration) <-chan time.Time
+	// NewTicker returns a ticker which ticks with the given period.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is a ticker created by a Clock.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is a Clock backed by the time package.
+type SystemClock struct{}
+
+var _ Clock = SystemClock{}
+
+// Now returns the current time.
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// After calls time.After.
+func (SystemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// NewTicker calls time.NewTicker.
+func (SystemClock) NewTicker(d time.Duration) Ticker { return systemTicker{time.NewTicker(d)} }
+
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
+
+// WithClock sets the clock used by the client, and by everything built on it.
+func WithClock(clock Clock) Option {
+	return func(c *Client) { c.clock = clock }
+}
+
+// WithRand sets the source of randomness used by the client, e.g. for jitter and
+// generated identifiers. The source doesn't need to be safe for concurrent use.
+func WithRand(r *rand.Rand) Option {
+	return func(c *Client) { c.rand = &lockedRand{r: r} }
+}
+
+// Clock returns the clock used by the client.
+func (c *Client) Clock() Clock {
+	return c.clock
+}
+
+// lockedRand makes a *rand.Rand safe for concurrent use.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func newLockedRand() *lockedRand {
+	return &lockedRand{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (lr *lockedRand) Float64() float64 {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.r.Float64()
+}
+
+func (lr *lockedRand) Read(p []byte) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.r.Read(p)
+}
@@ -0,0 +1,212 @@
+// Command operand-backup exports, verifies and restores bundles (see
+// operand.Client.ExportBundle). Bundles are directories, or zip archives if their
+// path ends in ".zip".
+//
+//	operand-backup export [-root id] [-base bundle] bundle
+//	operand-backup verify [-live] bundle...
+//	operand-backup restore [-parent id] bundle...
+//
+// Chains of incremental bundles are given in order, starting with the full bundle.
+// The Operand API key is read from OPERAND_API_KEY.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	operand "github.com/operandinc/go-sdk"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal("usage: operand-backup export|verify|restore [flags] bundle...")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "export":
+		err = export(ctx, args)
+	case "verify":
+		err = verify(ctx, args)
+	case "restore":
+		err = restore(ctx, args)
+	default:
+		err = fmt.Errorf("unknown command %q", cmd)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func newClient(endpoint string) *operand.Client {
+	apiKey := os.Getenv("OPERAND_API_KEY")
+	if apiKey == "" {
+		log.Fatal("OPERAND_API_KEY must be set")
+	}
+	var opts []operand.Option
+	if endpoint != "" {
+		opts = append(opts, operand.WithEndpoint(endpoint))
+	}
+	client := operand.NewClient(apiKey, opts...)
+	return client
+}
+
+func export(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "", "Operand API endpoint (defaults to the SDK default)")
+	root := fs.String("root", "", "ID of the folder to export (defaults to the root)")
+	base := fs.String("base", "", "bundle to base an incremental bundle on")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("expected a single bundle path")
+	}
+
+	var opts operand.BundleOptions
+	if *base != "" {
+		source, closeSource, err := openBundle(*base)
+		if err != nil {
+			return err
+		}
+		defer closeSource()
+		if opts.Base, err = operand.ReadBundleManifest(ctx, source); err != nil {
+			return err
+		}
+	}
+
+	path := fs.Arg(0)
+	var (
+		target operand.ExportTarget = operand.DirTarget(path)
+		finish                      = func() error { return nil }
+	)
+	if strings.HasSuffix(path, ".zip") {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		zt := operand.NewZipTarget(f)
+		target = zt
+		finish = func() error {
+			if err := zt.Close(); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}
+	}
+
+	manifest, err := newClient(*endpoint).ExportBundle(ctx, *root, target, opts)
+	if err != nil {
+		return err
+	}
+	if err := finish(); err != nil {
+		return err
+	}
+	log.Printf("exported %d files to bundle %s", len(manifest.Files), manifest.ID)
+	return nil
+}
+
+func verify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "", "Operand API endpoint (defaults to the SDK default)")
+	live := fs.Bool("live", false, "also compare the bundle against the live tenant")
+	fs.Parse(args)
+
+	chain, closeChain, err := openChain(fs.Args())
+	if err != nil {
+		return err
+	}
+	defer closeChain()
+
+	var client *operand.Client
+	if *live {
+		client = newClient(*endpoint)
+	}
+	v, err := operand.VerifyBundles(ctx, chain, client)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if !v.OK() {
+		return fmt.Errorf("found %d discrepancies", len(v.Discrepancies))
+	}
+	return nil
+}
+
+func restore(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	endpoint := fs.String("endpoint", "", "Operand API endpoint (defaults to the SDK default)")
+	parent := fs.String("parent", "", "ID of the folder to restore into (defaults to the root)")
+	fs.Parse(args)
+
+	chain, closeChain, err := openChain(fs.Args())
+	if err != nil {
+		return err
+	}
+	defer closeChain()
+
+	ids, err := newClient(*endpoint).ImportBundles(ctx, chain, *parent)
+	if err != nil {
+		return err
+	}
+	log.Printf("restored %d files", len(ids))
+	return nil
+}
+
+func openChain(paths []string) ([]operand.BundleSource, func(), error) {
+	if len(paths) == 0 {
+		return nil, nil, fmt.Errorf("expected at least one bundle path")
+	}
+	var (
+		chain   []operand.BundleSource
+		closers []func()
+	)
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+	for _, path := range paths {
+		source, closeSource, err := openBundle(path)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		chain = append(chain, source)
+		closers = append(closers, closeSource)
+	}
+	return chain, closeAll, nil
+}
+
+func openBundle(path string) (operand.BundleSource, func(), error) {
+	if !strings.HasSuffix(path, ".zip") {
+		return operand.DirTarget(path), func() {}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	source, err := operand.NewZipSource(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return source, func() { f.Close() }, nil
+}
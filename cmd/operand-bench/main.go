@@ -0,0 +1,350 @@
+// Command operand-bench load tests an Operand endpoint with a mix of uploads,
+// searches and downloads, at increasing levels of concurrency, and reports the
+// throughput and latency percentiles of each level. All calls are made through the
+// SDK, so the results reflect real client behavior.
+//
+//	operand-bench [-mix upload=1,search=8,download=1] [-ramp 1,4,16] [-step 30s] [-queries log]
+//
+// The benchmark runs in a scratch folder, which is deleted afterwards unless -keep
+// is given. The Operand API key is read from OPERAND_API_KEY.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	operand "github.com/operandinc/go-sdk"
+)
+
+// The kinds of operation which are benchmarked.
+const (
+	opUpload   = "upload"
+	opSearch   = "search"
+	opDownload = "download"
+)
+
+// words make up the generated documents and default queries.
+var words = strings.Fields(`
+	account archive budget calendar contract customer deadline design estimate
+	feature forecast invoice launch meeting metric migration onboarding outage
+	payment pipeline policy pricing proposal quarter release report review
+	roadmap schedule security shipment strategy support survey vendor warranty
+`)
+
+func main() {
+	log.SetFlags(0)
+	endpoint := flag.String("endpoint", "", "Operand API endpoint (defaults to the SDK default)")
+	mixFlag := flag.String("mix", "upload=1,search=8,download=1", "relative weights of the operations")
+	rampFlag := flag.String("ramp", "1,2,4,8,16", "concurrency of each step")
+	step := flag.Duration("step", 30*time.Second, "duration of each step")
+	size := flag.Int("size", 16<<10, "size of uploaded documents, in bytes")
+	seed := flag.Int("seed", 8, "number of documents uploaded before the benchmark, for downloads")
+	queries := flag.String("queries", "", "query log (see operand.QueryLog) to draw searches from")
+	keep := flag.Bool("keep", false, "keep the scratch folder")
+	flag.Parse()
+
+	mix, err := parseMix(*mixFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ramp, err := parseRamp(*rampFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	apiKey := os.Getenv("OPERAND_API_KEY")
+	if apiKey == "" {
+		log.Fatal("OPERAND_API_KEY must be set")
+	}
+	var opts []operand.Option
+	if *endpoint != "" {
+		opts = append(opts, operand.WithEndpoint(*endpoint))
+	}
+	client := operand.NewClient(apiKey, opts...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	b := &bench{client: client, mix: mix, size: *size}
+	if *queries != "" {
+		if err := b.loadQueries(*queries); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := b.setup(ctx, *seed); err != nil {
+		log.Fatal(err)
+	}
+	if !*keep {
+		defer func() {
+			if err := client.DeleteTree(context.Background(), b.folderID); err != nil {
+				log.Printf("failed to delete scratch folder %s: %v", b.folderID, err)
+			}
+		}()
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "concurrency\top\tcalls\terrors\tops/s\tp50\tp95\tp99\tmax\t")
+	for _, concurrency := range ramp {
+		results := b.run(ctx, concurrency, *step)
+		for _, r := range results {
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t\n",
+				concurrency, r.op, r.calls, r.errors, r.throughput,
+				r.p50.Round(time.Millisecond), r.p95.Round(time.Millisecond),
+				r.p99.Round(time.Millisecond), r.max.Round(time.Millisecond))
+		}
+		tw.Flush()
+		if ctx.Err() != nil {
+			break
+		}
+	}
+}
+
+// weight is the relative weight of an operation in the mix.
+type weight struct {
+	op     string
+	weight int
+}
+
+func parseMix(s string) ([]weight, error) {
+	var mix []weight
+	for _, part := range strings.Split(s, ",") {
+		op, w, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q, expected op=weight", part)
+		}
+		if op != opUpload && op != opSearch && op != opDownload {
+			return nil, fmt.Errorf("unknown operation %q", op)
+		}
+		n, err := strconv.Atoi(w)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid weight %q for %s", w, op)
+		}
+		if n > 0 {
+			mix = append(mix, weight{op, n})
+		}
+	}
+	if len(mix) == 0 {
+		return nil, fmt.Errorf("mix is empty")
+	}
+	return mix, nil
+}
+
+func parseRamp(s string) ([]int, error) {
+	var ramp []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid concurrency %q", part)
+		}
+		ramp = append(ramp, n)
+	}
+	return ramp, nil
+}
+
+// bench holds the state of a benchmark.
+type bench struct {
+	client *operand.Client
+	mix    []weight
+	size   int
+
+	folderID string
+	// targets are the documents downloaded by download operations.
+	targets []string
+	queries []string
+}
+
+func (b *bench) loadQueries(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	entries, err := operand.ReadQueryLog(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	for _, e := range entries {
+		b.queries = append(b.queries, e.Query)
+	}
+	if len(b.queries) == 0 {
+		return fmt.Errorf("%s has no queries", path)
+	}
+	return nil
+}
+
+// setup creates the scratch folder, and the documents which are downloaded.
+func (b *bench) setup(ctx context.Context, seed int) error {
+	name := fmt.Sprintf("operand-bench-%s", time.Now().UTC().Format("20060102T150405Z"))
+	resp, err := b.client.CreateFile(ctx, name, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create scratch folder: %w", err)
+	}
+	b.folderID = resp.File.Id
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < seed && b.weighs(opDownload); i++ {
+		doc := bytes.NewReader(b.document(rng))
+		resp, err := b.client.CreateFile(ctx, fmt.Sprintf("seed-%d.txt", i), &b.folderID, doc, nil)
+		if err != nil {
+			return fmt.Errorf("failed to seed: %w", err)
+		}
+		b.targets = append(b.targets, resp.File.Id)
+	}
+	if b.weighs(opDownload) && len(b.targets) == 0 {
+		return fmt.Errorf("downloads need at least one seed document")
+	}
+	return nil
+}
+
+func (b *bench) weighs(op string) bool {
+	for _, w := range b.mix {
+		if w.op == op {
+			return true
+		}
+	}
+	return false
+}
+
+// document generates a text document of roughly the configured size.
+func (b *bench) document(rng *rand.Rand) []byte {
+	var buf bytes.Buffer
+	for buf.Len() < b.size {
+		buf.WriteString(words[rng.Intn(len(words))])
+		if rng.Intn(12) == 0 {
+			buf.WriteString(".\n")
+		} else {
+			buf.WriteByte(' ')
+		}
+	}
+	return buf.Bytes()
+}
+
+func (b *bench) query(rng *rand.Rand) string {
+	if len(b.queries) > 0 {
+		return b.queries[rng.Intn(len(b.queries))]
+	}
+	return words[rng.Intn(len(words))] + " " + words[rng.Intn(len(words))]
+}
+
+func (b *bench) pick(rng *rand.Rand) string {
+	total := 0
+	for _, w := range b.mix {
+		total += w.weight
+	}
+	n := rng.Intn(total)
+	for _, w := range b.mix {
+		if n < w.weight {
+			return w.op
+		}
+		n -= w.weight
+	}
+	return b.mix[len(b.mix)-1].op
+}
+
+// do performs a single operation.
+func (b *bench) do(ctx context.Context, rng *rand.Rand, op string, worker, seq int) error {
+	switch op {
+	case opUpload:
+		name := fmt.Sprintf("doc-%d-%d.txt", worker, seq)
+		_, err := b.client.CreateFile(ctx, name, &b.folderID, bytes.NewReader(b.document(rng)), nil)
+		return err
+	case opSearch:
+		_, err := b.client.Search(ctx, b.query(rng), operand.WithParent(b.folderID))
+		return err
+	default:
+		content, _, err := b.client.DownloadFile(ctx, b.targets[rng.Intn(len(b.targets))])
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+		_, err = io.Copy(io.Discard, content)
+		return err
+	}
+}
+
+// result summarizes the calls of an operation in a step.
+type result struct {
+	op                 string
+	calls, errors      int
+	throughput         float64
+	p50, p95, p99, max time.Duration
+}
+
+// run runs a step, and returns the results of each operation.
+func (b *bench) run(ctx context.Context, concurrency int, d time.Duration) []result {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		latencies = make(map[string][]time.Duration)
+		errors    = make(map[string]int)
+	)
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(worker)))
+			for seq := 0; ctx.Err() == nil; seq++ {
+				op := b.pick(rng)
+				began := time.Now()
+				err := b.do(ctx, rng, op, worker, seq)
+				latency := time.Since(began)
+				if ctx.Err() != nil {
+					return // Calls cut short by the end of the step aren't counted.
+				}
+				mu.Lock()
+				latencies[op] = append(latencies[op], latency)
+				if err != nil {
+					errors[op]++
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	var results []result
+	for _, w := range b.mix {
+		l := latencies[w.op]
+		if len(l) == 0 {
+			continue
+		}
+		sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+		results = append(results, result{
+			op:         w.op,
+			calls:      len(l),
+			errors:     errors[w.op],
+			throughput: float64(len(l)) / elapsed.Seconds(),
+			p50:        percentile(l, 50),
+			p95:        percentile(l, 95),
+			p99:        percentile(l, 99),
+			max:        l[len(l)-1],
+		})
+	}
+	return results
+}
+
+// percentile returns the p-th percentile of sorted latencies, by nearest rank.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p + 99) / 100
+	if i < 1 {
+		i = 1
+	}
+	return sorted[i-1]
+}
@@ -0,0 +1,55 @@
+// Command operand-proxy serves a small REST/JSON API backed by the Operand SDK, for
+// use from languages without a connect or protobuf toolchain. See
+// proxy.NewRESTHandler for the routes.
+//
+// The Operand API key is read from OPERAND_API_KEY. If OPERAND_PROXY_TOKEN is set,
+// callers must present it as a bearer token.
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	"github.com/operandinc/go-sdk/proxy"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	endpoint := flag.String("endpoint", "", "Operand API endpoint (defaults to the SDK default)")
+	flag.Parse()
+
+	apiKey := os.Getenv("OPERAND_API_KEY")
+	if apiKey == "" {
+		log.Fatal("OPERAND_API_KEY must be set")
+	}
+	var opts []operand.Option
+	if *endpoint != "" {
+		opts = append(opts, operand.WithEndpoint(*endpoint))
+	}
+	client := operand.NewClient(apiKey, opts...)
+	token := os.Getenv("OPERAND_PROXY_TOKEN")
+
+	handler := proxy.NewRESTHandler(proxy.Config{
+		Authenticate: func(_ context.Context, _ string, header http.Header) (*operand.Client, error) {
+			if token == "" {
+				return client, nil
+			}
+			got := []byte(header.Get("Authorization"))
+			want := []byte("Bearer " + token)
+			if subtle.ConstantTimeCompare(got, want) != 1 {
+				return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("invalid token"))
+			}
+			return client, nil
+		},
+	})
+
+	log.Printf("listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
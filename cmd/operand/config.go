@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config is the configuration of the CLI.
+type config struct {
+	APIKey   string `yaml:"api_key"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+// loadConfig loads the config file (see configPath), if it exists, and overrides
+// it with the OPERAND_API_KEY and OPERAND_ENDPOINT environment variables, if set.
+// The config file is YAML:
+//
+//	api_key: ...
+//	endpoint: https://mcp.operand.ai
+func loadConfig() (*config, error) {
+	cfg := new(config)
+	path := configPath()
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	if key := os.Getenv("OPERAND_API_KEY"); key != "" {
+		cfg.APIKey = key
+	}
+	if endpoint := os.Getenv("OPERAND_ENDPOINT"); endpoint != "" {
+		cfg.Endpoint = endpoint
+	}
+	return cfg, nil
+}
+
+// configPath returns the path of the config file: OPERAND_CONFIG, or
+// operand/config.yaml in the user's config directory.
+func configPath() string {
+	if path := os.Getenv("OPERAND_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "config.yaml"
+	}
+	return filepath.Join(dir, "operand", "config.yaml")
+}
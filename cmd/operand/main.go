@@ -0,0 +1,309 @@
+// Command operand is a command-line client of the Operand API, built on the SDK.
+//
+//	operand upload [-parent id] path...
+//	operand download [-o path] id
+//	operand ls [-l] [-r] [folder-id]
+//	operand rm [-r] id...
+//	operand search [-n max] [-parent id] query...
+//	operand tenant whoami|keys
+//
+// Uploading a directory uploads its tree of files into the folder; downloading a folder requires
+// -o, the directory to download its tree into. The API key is read from
+// OPERAND_API_KEY, or else from the config file (see loadConfig), along with the
+// endpoint.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+)
+
+const usage = "usage: operand upload|download|ls|rm|search|tenant [flags] [args]"
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "upload":
+		err = upload(ctx, args)
+	case "download":
+		err = download(ctx, args)
+	case "ls":
+		err = ls(ctx, args)
+	case "rm":
+		err = rm(ctx, args)
+	case "search":
+		err = search(ctx, args)
+	case "tenant":
+		err = tenant(ctx, args)
+	case "help", "-h", "-help", "--help":
+		fmt.Println(usage)
+	default:
+		err = fmt.Errorf("unknown command %q\n%s", cmd, usage)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newClient returns a client configured from the environment and config file.
+func newClient() *operand.Client {
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.APIKey == "" {
+		log.Fatalf("OPERAND_API_KEY must be set, or api_key in %s", configPath())
+	}
+	opts := []operand.Option{operand.WithUserAgent("operand-cli")}
+	if cfg.Endpoint != "" {
+		opts = append(opts, operand.WithEndpoint(cfg.Endpoint))
+	}
+	return operand.NewClient(cfg.APIKey, opts...)
+}
+
+func upload(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("upload", flag.ExitOnError)
+	parent := fs.String("parent", "", "ID of the folder to upload into (defaults to the root)")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("expected paths to upload")
+	}
+
+	client := newClient()
+	var parentID *string
+	if *parent != "" {
+		parentID = parent
+	}
+	for _, path := range fs.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			ids, err := client.UploadDir(ctx, path, *parent, operand.UploadOptions{})
+			if err != nil {
+				return fmt.Errorf("uploading %s: %w", path, err)
+			}
+			rels := make([]string, 0, len(ids))
+			for rel := range ids {
+				rels = append(rels, rel)
+			}
+			sort.Strings(rels)
+			for _, rel := range rels {
+				fmt.Printf("%s\t%s\n", ids[rel], filepath.Join(path, filepath.FromSlash(rel)))
+			}
+			continue
+		}
+		resp, err := client.CreateFileFromPath(ctx, path, parentID, nil)
+		if err != nil {
+			return fmt.Errorf("uploading %s: %w", path, err)
+		}
+		fmt.Printf("%s\t%s\n", resp.File.Id, path)
+	}
+	return nil
+}
+
+func download(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("download", flag.ExitOnError)
+	out := fs.String("o", "", "file (or directory, for folders) to download to (defaults to stdout)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("expected a single file ID")
+	}
+
+	client := newClient()
+	id := fs.Arg(0)
+	resp, err := client.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+	}))
+	if err != nil {
+		return err
+	}
+	if operand.IsFolder(resp.Msg.File) {
+		if *out == "" {
+			return fmt.Errorf("%s is a folder: -o is required", resp.Msg.File.Name)
+		}
+		return client.DownloadDir(ctx, id, *out, operand.DownloadOptions{})
+	}
+
+	content, _, err := client.DownloadFile(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	if *out == "" {
+		_, err = io.Copy(os.Stdout, content)
+		return err
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func ls(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ExitOnError)
+	long := fs.Bool("l", false, "show the size, indexing status and update time of files")
+	recursive := fs.Bool("r", false, "list the whole tree below the folder")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		return errors.New("expected at most one folder ID")
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	show := func(file *filev1.File, path string) {
+		name := path
+		if operand.IsFolder(file) {
+			name += "/"
+		}
+		if !*long {
+			fmt.Fprintf(tw, "%s\t%s\n", file.Id, name)
+			return
+		}
+		size, status := "-", "-"
+		if !operand.IsFolder(file) {
+			size = fmt.Sprint(file.GetSizeBytes())
+			status = strings.ToLower(strings.TrimPrefix(file.IndexingStatus.String(), "INDEXING_STATUS_"))
+		}
+		updated := file.UpdatedAt.AsTime().Local().Format(time.DateTime)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", file.Id, size, status, updated, name)
+	}
+
+	client := newClient()
+	folderID := fs.Arg(0)
+	if *recursive {
+		err := client.Walk(ctx, folderID, func(file *filev1.File, path string) error {
+			show(file, path)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tw.Flush()
+	}
+	files, err := client.ListFolder(ctx, folderID)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		show(file, file.Name)
+	}
+	return tw.Flush()
+}
+
+func rm(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rm", flag.ExitOnError)
+	recursive := fs.Bool("r", false, "delete folders along with everything within them")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("expected IDs of files to delete")
+	}
+
+	client := newClient()
+	for _, id := range fs.Args() {
+		// The API deletes folders along with everything within them, so folders are
+		// only deleted with -r.
+		if !*recursive {
+			resp, err := client.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+				Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+			}))
+			if err != nil {
+				return fmt.Errorf("deleting %s: %w", id, err)
+			}
+			if operand.IsFolder(resp.Msg.File) {
+				return fmt.Errorf("deleting %s: is a folder: use -r", id)
+			}
+		}
+		if err := client.DeleteFile(ctx, id); err != nil {
+			return fmt.Errorf("deleting %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func search(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	n := fs.Int("n", 10, "maximum number of results")
+	parent := fs.String("parent", "", "ID of the folder to search within (defaults to everything)")
+	fs.Parse(args)
+	query := strings.Join(fs.Args(), " ")
+	if query == "" {
+		return errors.New("expected a query")
+	}
+
+	opts := []operand.SearchOption{operand.WithMaxResults(int32(*n))}
+	if *parent != "" {
+		opts = append(opts, operand.WithParent(*parent))
+	}
+	hits, err := newClient().SearchHits(ctx, query, opts...)
+	if err != nil {
+		return err
+	}
+	for _, hit := range hits {
+		name := hit.Match.FileId
+		if hit.File != nil {
+			name = hit.File.Name
+		}
+		fmt.Printf("%.3f\t%s\t%s\n\t%s\n", hit.Match.Score, hit.Match.FileId, name, hit.Match.Snippet)
+	}
+	return nil
+}
+
+func tenant(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: operand tenant whoami|keys")
+	}
+	client := newClient().TenantService()
+	switch args[0] {
+	case "whoami":
+		resp, err := client.AuthorizedUser(ctx, connect.NewRequest(&tenantv1.AuthorizedUserRequest{}))
+		if err != nil {
+			return err
+		}
+		user := resp.Msg.User
+		name := strings.TrimSpace(user.Profile.GetFirstName() + " " + user.Profile.GetLastName())
+		fmt.Printf("%s\t%s\t%s\n", user.Profile.Id, user.Profile.EmailAddress, name)
+	case "keys":
+		resp, err := client.ListAPIKeys(ctx, connect.NewRequest(&tenantv1.ListAPIKeysRequest{}))
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, key := range resp.Msg.Keys {
+			created := key.CreatedAt.AsTime().Local().Format(time.DateTime)
+			fmt.Fprintf(tw, "%s\t%s…\t%s\t%s\n", key.Id, key.GetPartial(), created, key.Name)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown tenant command %q", args[0])
+	}
+	return nil
+}
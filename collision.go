@@ -0,0 +1,142 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// CollisionPolicy decides what happens when a file is created with the same name as
+// an existing file in the same folder.
+type CollisionPolicy int
+
+const (
+	// CollisionAllow creates the file alongside the existing one. This is the
+	// default, and matches the behavior of the API.
+	CollisionAllow CollisionPolicy = iota
+	// CollisionError fails with ErrNameExists.
+	CollisionError
+	// CollisionOverwrite replaces the existing file. The new file is created before
+	// the existing one is deleted, so the name is never missing from search results.
+	// Creating a folder over an existing folder reuses the existing folder.
+	CollisionOverwrite
+	// CollisionRename creates the file with a numeric suffix, e.g. "notes (2).txt".
+	CollisionRename
+	// CollisionSkip leaves the existing file in place, and returns it instead.
+	CollisionSkip
+)
+
+// ErrNameExists is returned by CreateFile under CollisionError.
+var ErrNameExists = errors.New("a file with the same name already exists")
+
+var collisionPolicyNames = map[CollisionPolicy]string{
+	CollisionAllow:     "allow",
+	CollisionError:     "error",
+	CollisionOverwrite: "overwrite",
+	CollisionRename:    "rename",
+	CollisionSkip:      "skip",
+}
+
+func (p CollisionPolicy) String() string {
+	if name, ok := collisionPolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("CollisionPolicy(%d)", int(p))
+}
+
+// ParseCollisionPolicy parses the name of a collision policy, e.g. "overwrite".
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	for p, n := range collisionPolicyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown collision policy %q", name)
+}
+
+type collisionPolicyKey struct{}
+
+// WithCollisionPolicy returns a context with which CreateFile applies the given
+// collision policy. Applying a policy other than CollisionAllow costs an extra
+// listing of the folder per file.
+func WithCollisionPolicy(ctx context.Context, policy CollisionPolicy) context.Context {
+	return context.WithValue(ctx, collisionPolicyKey{}, policy)
+}
+
+func collisionPolicy(ctx context.Context) CollisionPolicy {
+	policy, _ := ctx.Value(collisionPolicyKey{}).(CollisionPolicy)
+	return policy
+}
+
+// createWithPolicy creates a file, applying a collision policy against the existing
+// files in the folder. Under CollisionOverwrite, if the existing file can't be
+// deleted, the new file is returned along with the error.
+func (c *Client) createWithPolicy(
+	ctx context.Context,
+	policy CollisionPolicy,
+	name string,
+	parent *string,
+	data io.Reader,
+	properties *filev1.Properties,
+	siblings []*filev1.File,
+) (*filev1.CreateFileResponse, error) {
+	var existing *filev1.File
+	for _, f := range siblings {
+		if f.Name == name {
+			existing = f
+			break
+		}
+	}
+	if existing == nil || policy == CollisionAllow {
+		return c.createFile(ctx, name, parent, data, properties)
+	}
+
+	switch policy {
+	case CollisionError:
+		return nil, fmt.Errorf("%w: %q", ErrNameExists, name)
+	case CollisionSkip:
+		return &filev1.CreateFileResponse{File: existing}, nil
+	case CollisionRename:
+		return c.createFile(ctx, uniqueName(name, siblings), parent, data, properties)
+	case CollisionOverwrite:
+		if data == nil && IsFolder(existing) {
+			return &filev1.CreateFileResponse{File: existing}, nil
+		}
+		resp, err := c.createFile(ctx, name, parent, data, properties)
+		if err != nil {
+			return nil, err
+		}
+		remove := c.DeleteFile
+		if IsFolder(existing) {
+			remove = c.DeleteTree
+		}
+		if err := remove(ctx, existing.Id); err != nil {
+			return resp, fmt.Errorf("failed to delete existing file %s: %w", existing.Id, err)
+		}
+		return resp, nil
+	default:
+		return nil, fmt.Errorf("unknown collision policy %v", policy)
+	}
+}
+
+// uniqueName returns the name with the lowest numeric suffix, starting at 2, which
+// isn't taken by any of the siblings.
+func uniqueName(name string, siblings []*filev1.File) string {
+	taken := make(map[string]bool, len(siblings))
+	for _, f := range siblings {
+		taken[f.Name] = true
+	}
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// IssueKind is the kind of a consistency issue.
+type IssueKind string
+
+const (
+	// IssueMissingParent is a file whose parent doesn't exist. It's repaired by
+	// moving the file to the root.
+	IssueMissingParent IssueKind = "missing_parent"
+	// IssueDanglingSummary is a summary sidecar whose original file doesn't exist.
+	// It's repaired by deleting the sidecar.
+	IssueDanglingSummary IssueKind = "dangling_summary"
+	// IssueDanglingCatalogEntry is an entry of the local catalog whose file doesn't
+	// exist. It's repaired by deleting the entry.
+	IssueDanglingCatalogEntry IssueKind = "dangling_catalog_entry"
+	// IssueDuplicateExternalID is a file which shares its external ID with a more
+	// recently updated file in the same folder, e.g. a previous version which failed
+	// to be deleted. It's repaired by deleting the older file.
+	IssueDuplicateExternalID IssueKind = "duplicate_external_id"
+	// IssueDuplicateName is a file which shares its name with another in the same
+	// folder. It isn't repaired, since it's not clear which file is wanted.
+	IssueDuplicateName IssueKind = "duplicate_name"
+)
+
+// Issue is a single consistency issue.
+type Issue struct {
+	Kind       IssueKind `json:"kind"`
+	FileID     string    `json:"file_id,omitempty"`
+	ParentID   string    `json:"parent_id,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	ExternalID string    `json:"external_id,omitempty"`
+	// Repaired is set if the issue was repaired.
+	Repaired bool `json:"repaired"`
+	// RepairErr is set if the issue couldn't be repaired.
+	RepairErr error `json:"-"`
+}
+
+// Catalog is a local record of the files created from external documents, such as
+// ingest.DedupStore implementations.
+type Catalog interface {
+	// Entries returns the file ID recorded for each external ID.
+	Entries(ctx context.Context) (map[string]string, error)
+	Delete(ctx context.Context, externalID string) error
+}
+
+// ConsistencyOptions configures CheckConsistency.
+type ConsistencyOptions struct {
+	// Catalog, if set, is checked for entries referencing files which don't exist.
+	Catalog Catalog
+	// Repair repairs the issues which can be repaired, as described by IssueKind.
+	Repair bool
+}
+
+// ConsistencyReport is the result of CheckConsistency.
+type ConsistencyReport struct {
+	Scanned int      `json:"scanned"`
+	Issues  []*Issue `json:"issues"`
+}
+
+// CheckConsistency scans all of the files accessible to the client for orphans and
+// broken references, optionally repairing them. Repair failures are recorded in the
+// report, rather than stopping the check.
+func (c *Client) CheckConsistency(ctx context.Context, opts ConsistencyOptions) (*ConsistencyReport, error) {
+	files, err := c.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &ConsistencyReport{Scanned: len(files)}
+
+	byID := make(map[string]*filev1.File, len(files))
+	for _, f := range files {
+		byID[f.Id] = f
+	}
+	issue := func(kind IssueKind, f *filev1.File) *Issue {
+		i := &Issue{Kind: kind, FileID: f.Id, ParentID: f.GetParentId(), Name: f.Name}
+		i.ExternalID, _ = PropertyText(f.GetProperties(), PropertyExternalID)
+		report.Issues = append(report.Issues, i)
+		return i
+	}
+	repair := func(i *Issue, fn func() error) {
+		if !opts.Repair {
+			return
+		}
+		if i.RepairErr = fn(); i.RepairErr == nil {
+			i.Repaired = true
+		}
+	}
+
+	type key struct{ parent, value string }
+	var (
+		names       = make(map[key][]*filev1.File)
+		externalIDs = make(map[key][]*filev1.File)
+		superseded  = make(map[string]bool) // IDs of duplicate external IDs.
+	)
+	for _, f := range files {
+		parent := f.GetParentId()
+		if parent != "" && byID[parent] == nil {
+			i := issue(IssueMissingParent, f)
+			repair(i, func() error { return c.moveToRoot(ctx, f.Id) })
+		}
+		if original, ok := IsSummary(f); ok && byID[original] == nil {
+			i := issue(IssueDanglingSummary, f)
+			repair(i, func() error { return c.DeleteFile(ctx, f.Id) })
+		}
+		names[key{parent, f.Name}] = append(names[key{parent, f.Name}], f)
+		if id, ok := PropertyText(f.GetProperties(), PropertyExternalID); ok {
+			externalIDs[key{parent, id}] = append(externalIDs[key{parent, id}], f)
+		}
+	}
+
+	for _, dups := range externalIDs {
+		if len(dups) < 2 {
+			continue
+		}
+		sort.Slice(dups, func(i, j int) bool {
+			return dups[i].GetUpdatedAt().AsTime().After(dups[j].GetUpdatedAt().AsTime())
+		})
+		for _, f := range dups[1:] {
+			superseded[f.Id] = true
+			i := issue(IssueDuplicateExternalID, f)
+			repair(i, func() error { return c.DeleteFile(ctx, f.Id) })
+		}
+	}
+	for _, dups := range names {
+		var remaining []*filev1.File
+		for _, f := range dups {
+			if !superseded[f.Id] {
+				remaining = append(remaining, f)
+			}
+		}
+		if len(remaining) < 2 {
+			continue
+		}
+		for _, f := range remaining {
+			issue(IssueDuplicateName, f)
+		}
+	}
+
+	if opts.Catalog != nil {
+		entries, err := opts.Catalog.Entries(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read catalog: %w", err)
+		}
+		for externalID, fileID := range entries {
+			if byID[fileID] != nil {
+				continue
+			}
+			i := &Issue{Kind: IssueDanglingCatalogEntry, FileID: fileID, ExternalID: externalID}
+			report.Issues = append(report.Issues, i)
+			repair(i, func() error { return opts.Catalog.Delete(ctx, externalID) })
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.FileID < b.FileID
+	})
+	return report, nil
+}
+
+func (c *Client) moveToRoot(ctx context.Context, id string) error {
+	root := ""
+	_, err := c.FileService().UpdateFile(ctx, connect.NewRequest(&filev1.UpdateFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+		ParentId: &root,
+	}))
+	return err
+}
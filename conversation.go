@@ -0,0 +1,299 @@
+package operand
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultConversationWindow is the number of turns of a Conversation kept, by
+// default.
+const DefaultConversationWindow = 10
+
+// Answer is the answer to a question.
+type Answer struct {
+	// ConversationID is the ID of the conversation on the server.
+	ConversationID string
+	Text           string
+	// Files are the files relevant to the answer.
+	Files []*filev1.File
+}
+
+// Ask answers a question about the files accessible to the client, or those
+// allowed by the options, if any. Each call is a new conversation, with no memory
+// of previous ones; see Conversation for follow-up questions. Questions and answers
+// are checked by the client's guardrails, if any (see WithGuardrails), and the call
+// is bounded by the budget of the context, if any (see WithBudget).
+func (c *Client) Ask(ctx context.Context, question string, opts *operandv1.ConversationOptions) (*Answer, error) {
+	return c.converse(ctx, question, &operandv1.ConverseRequest{Input: question, Options: opts})
+}
+
+// AskStream is like Ask, but streams the answer as it's generated, e.g. to render
+// it incrementally. The stream must be closed once done with.
+func (c *Client) AskStream(ctx context.Context, question string, opts *operandv1.ConversationOptions) (*AnswerStream, error) {
+	return c.converseStream(ctx, question, &operandv1.ConverseRequest{Input: question, Options: opts})
+}
+
+// converse makes a Converse call asking a question, and collects its answer.
+func (c *Client) converse(ctx context.Context, question string, req *operandv1.ConverseRequest) (*Answer, error) {
+	stream, err := c.converseStream(ctx, question, req)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return stream.Answer(), nil
+}
+
+// converseStream makes a Converse call asking a question, returning the stream of
+// its answer. The question is checked by the client's guardrails, as is the answer
+// once complete.
+func (c *Client) converseStream(ctx context.Context, question string, req *operandv1.ConverseRequest) (*AnswerStream, error) {
+	ctx = c.withOperation(ctx)
+	if err := c.checkQuestion(ctx, question); err != nil {
+		return nil, err
+	}
+	ctx, cancel := c.budgetTimeout(ctx)
+	stream, err := c.OperandService().Converse(ctx, connect.NewRequest(req))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s := &AnswerStream{ctx: ctx, stream: stream, cancel: cancel, answer: Answer{ConversationID: req.GetConversationId()}}
+	if len(c.guardrails) > 0 {
+		s.check = func(answer *Answer) error { return c.checkAnswer(ctx, question, answer) }
+	}
+	return s, nil
+}
+
+// AnswerStream is an answer, streamed in parts as it's generated:
+//
+//	stream, err := client.AskStream(ctx, question, nil)
+//	if err != nil {
+//		...
+//	}
+//	defer stream.Close()
+//	for stream.Next() {
+//		fmt.Print(stream.Part())
+//	}
+//	if err := stream.Err(); err != nil {
+//		...
+//	}
+//	answer := stream.Answer() // The full text, and the files relevant to it.
+type AnswerStream struct {
+	ctx    context.Context
+	stream *connect.ServerStreamForClient[operandv1.ConverseResponse]
+	cancel context.CancelFunc  // Releases the latency budget of the call.
+	check  func(*Answer) error // Checks the complete answer, if set.
+	done   func(*Answer)       // Called with the complete answer, if set.
+
+	answer   Answer
+	text     strings.Builder
+	part     string
+	finished bool
+	err      error
+}
+
+// Next advances to the next part of the answer. It returns false once the answer
+// is complete, or the stream failed (see Err).
+func (s *AnswerStream) Next() bool {
+	if s.finished {
+		return false
+	}
+	for s.stream.Receive() {
+		msg := s.stream.Msg()
+		if msg.ConversationId != "" {
+			s.answer.ConversationID = msg.ConversationId
+		}
+		s.answer.Files = append(s.answer.Files, msg.RelevantFiles...)
+		if msg.MessagePart == "" {
+			continue
+		}
+		s.part = msg.MessagePart
+		s.text.WriteString(s.part)
+		return true
+	}
+	s.finished, s.part = true, ""
+	if err := s.ctx.Err(); err != nil {
+		// A stream cut short by its context may end cleanly, or with a protocol error.
+		s.err = connect.NewError(errorCode(err), err)
+		return false
+	}
+	if s.err = s.stream.Err(); s.err != nil {
+		return false
+	}
+	answer := s.Answer()
+	if s.check != nil {
+		s.err = s.check(answer)
+	}
+	if s.err == nil && s.done != nil {
+		s.done(answer)
+	}
+	return false
+}
+
+// Part returns the current part of the answer.
+func (s *AnswerStream) Part() string {
+	return s.part
+}
+
+// Answer returns the answer received so far. Once Next returns false without an
+// error, it's the complete answer, with the files relevant to it.
+func (s *AnswerStream) Answer() *Answer {
+	answer := s.answer
+	answer.Text = s.text.String()
+	return &answer
+}
+
+// Err returns the error which stopped the stream, if any, including the rejection
+// of the complete answer by a guardrail (see ErrAnswerRejected).
+func (s *AnswerStream) Err() error {
+	return s.err
+}
+
+// Close closes the stream, abandoning the rest of the answer if it's incomplete.
+func (s *AnswerStream) Close() error {
+	defer s.cancel()
+	return s.stream.Close()
+}
+
+// Turn is a question of a conversation, and its answer.
+type Turn struct {
+	Question string
+	Answer   *Answer
+}
+
+// Conversation is a conversation made of successive questions, which keeps track
+// of its ID on the server, so that follow-up questions are answered in the context
+// of the previous ones (e.g. "who wrote it?"). Questions are asked one at a time.
+//
+// The conversation keeps its most recent turns, up to Window. Once a conversation
+// on the server reaches Window turns, a new one is started, with the turns kept as
+// context, so that the context of each question stays bounded.
+type Conversation struct {
+	// Window is the number of turns kept, and the maximum number of turns of a
+	// conversation on the server. Negative keeps every turn, and never starts a new
+	// conversation.
+	Window int
+
+	c    *Client
+	opts *operandv1.ConversationOptions
+
+	mu      sync.Mutex
+	id      string // Empty until the first answer, or after a reset.
+	turns   int    // The number of turns of the conversation on the server.
+	history []Turn
+}
+
+// NewConversation starts a conversation about the files accessible to the client,
+// or those allowed by the options, if any, with the default window (see
+// DefaultConversationWindow).
+func (c *Client) NewConversation(opts *operandv1.ConversationOptions) *Conversation {
+	return &Conversation{Window: DefaultConversationWindow, c: c, opts: opts}
+}
+
+// Ask asks a question, in the context of the previous ones.
+func (cv *Conversation) Ask(ctx context.Context, question string) (*Answer, error) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	answer, err := cv.c.converse(ctx, question, cv.requestLocked(question))
+	if err != nil {
+		return nil, err
+	}
+	cv.recordLocked(question, answer)
+	return answer, nil
+}
+
+// AskStream is like Ask, but streams the answer as it's generated. The answer is
+// only kept in the conversation once complete, so the stream must be read to its
+// end before asking the next question.
+func (cv *Conversation) AskStream(ctx context.Context, question string) (*AnswerStream, error) {
+	cv.mu.Lock()
+	req := cv.requestLocked(question)
+	cv.mu.Unlock()
+
+	stream, err := cv.c.converseStream(ctx, question, req)
+	if err != nil {
+		return nil, err
+	}
+	stream.done = func(answer *Answer) {
+		cv.mu.Lock()
+		defer cv.mu.Unlock()
+		cv.recordLocked(question, answer)
+	}
+	return stream, nil
+}
+
+// requestLocked returns the request asking a question, continuing the conversation
+// on the server, or starting a new one.
+func (cv *Conversation) requestLocked(question string) *operandv1.ConverseRequest {
+	req := &operandv1.ConverseRequest{Input: question}
+	switch {
+	case cv.id != "" && (cv.Window < 0 || cv.turns < cv.Window):
+		req.ConversationId = proto.String(cv.id)
+	default:
+		// Options only apply to the first question of a conversation on the server.
+		req.Options = cv.opts
+		if cv.id != "" && len(cv.history) > 0 {
+			req.Input = transcript(cv.history, question)
+		}
+	}
+	return req
+}
+
+// recordLocked keeps a turn of the conversation.
+func (cv *Conversation) recordLocked(question string, answer *Answer) {
+	if answer.ConversationID != cv.id {
+		cv.id, cv.turns = answer.ConversationID, 0
+	}
+	cv.turns++
+	cv.history = append(cv.history, Turn{Question: question, Answer: answer})
+	if cv.Window >= 0 && len(cv.history) > cv.Window {
+		cv.history = append([]Turn(nil), cv.history[len(cv.history)-cv.Window:]...)
+	}
+}
+
+// transcript returns the input starting a new conversation on the server, with the
+// turns of the previous one as context.
+func transcript(history []Turn, question string) string {
+	var b strings.Builder
+	b.WriteString("Previous conversation:\n")
+	for _, turn := range history {
+		b.WriteString("Q: " + turn.Question + "\n")
+		b.WriteString("A: " + turn.Answer.Text + "\n")
+	}
+	b.WriteString("\nQuestion: " + question)
+	return b.String()
+}
+
+// ID returns the ID of the conversation on the server, or an empty string until
+// the first answer.
+func (cv *Conversation) ID() string {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.id
+}
+
+// History returns the turns kept, oldest first.
+func (cv *Conversation) History() []Turn {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return append([]Turn(nil), cv.history...)
+}
+
+// Reset forgets the conversation, so that the next question starts a new one.
+func (cv *Conversation) Reset() {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	cv.id, cv.turns, cv.history = "", 0, nil
+}
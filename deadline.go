@@ -0,0 +1,122 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+)
+
+// DeadlinePolicy configures WithDeadlineWarnings.
+type DeadlinePolicy struct {
+	// Minimums are the minimum time left before the deadline of calls, by method
+	// (as in MethodStats, e.g. MethodUpload, or the procedure of an RPC). Calls with
+	// less time left are warned of, as they're likely to time out spuriously.
+	Minimums map[string]time.Duration
+	// DefaultMinimum is the minimum of methods without one in Minimums. Zero
+	// disables the check for them.
+	DefaultMinimum time.Duration
+	// AllowNoDeadline stops the warnings about calls without a deadline, which may
+	// hang forever if the API doesn't respond.
+	AllowNoDeadline bool
+	// OnWarning is called with each warning. Defaults to logging it to the client's
+	// logger (see WithLogger) at slog.LevelWarn, if any.
+	OnWarning func(ctx context.Context, w DeadlineWarning)
+}
+
+// DeadlineWarning describes a call issued without a deadline, or with less time
+// left before its deadline than the minimum for its method.
+type DeadlineWarning struct {
+	Method string
+	// Remaining is the time left before the deadline, or zero if the call has none.
+	Remaining time.Duration
+	// Minimum is the minimum for the method, or zero if the call has no deadline.
+	Minimum time.Duration
+}
+
+func (w DeadlineWarning) String() string {
+	if w.Minimum == 0 {
+		return fmt.Sprintf("%s called without a deadline", w.Method)
+	}
+	return fmt.Sprintf("%s called with %s left before its deadline, below the minimum of %s", w.Method, w.Remaining, w.Minimum)
+}
+
+// WithDeadlineWarnings warns of calls (RPCs, uploads and downloads) issued without
+// a deadline, or with a deadline too short for their method, to catch hangs and
+// spurious timeouts early. The client's timeout (see WithTimeout) counts as the
+// deadline of unary RPCs and uploads without one. Calls are checked once, before
+// any retries.
+func WithDeadlineWarnings(policy DeadlinePolicy) Option {
+	return func(c *Client) { c.deadlines = &policy }
+}
+
+// checkDeadline warns of a call whose deadline breaks the client's policy. timeout
+// is set for calls which the client's timeout applies to.
+func (c *Client) checkDeadline(ctx context.Context, method string, timeout bool) {
+	p := c.deadlines
+	if p == nil {
+		return
+	}
+	var w DeadlineWarning
+	deadline, ok := ctx.Deadline()
+	switch {
+	case ok:
+		minimum, ok := p.Minimums[method]
+		if !ok {
+			minimum = p.DefaultMinimum
+		}
+		remaining := time.Until(deadline)
+		if remaining >= minimum {
+			return
+		}
+		w = DeadlineWarning{Method: method, Remaining: remaining, Minimum: minimum}
+	case timeout && c.timeout > 0, p.AllowNoDeadline:
+		return
+	default:
+		w = DeadlineWarning{Method: method}
+	}
+
+	if p.OnWarning != nil {
+		p.OnWarning(ctx, w)
+		return
+	}
+	if c.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("operation_id", OperationID(ctx)),
+	}
+	if w.Minimum > 0 {
+		attrs = append(attrs, slog.Duration("remaining", w.Remaining), slog.Duration("minimum", w.Minimum))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "operand call deadline", attrs...)
+}
+
+// deadlineInterceptor checks the deadlines of RPCs. It sits outside of any
+// interceptors which retry calls, so that calls are checked once.
+type deadlineInterceptor struct {
+	c *Client
+}
+
+var _ connect.Interceptor = deadlineInterceptor{}
+
+func (di deadlineInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		di.c.checkDeadline(ctx, req.Spec().Procedure, true)
+		return next(ctx, req)
+	}
+}
+
+func (di deadlineInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		di.c.checkDeadline(ctx, spec.Procedure, false)
+		return next(ctx, spec)
+	}
+}
+
+func (deadlineInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
@@ -0,0 +1,203 @@
+package operand
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultDebugMaxBody is the number of bytes of each body dumped by WithDebug, by
+// default.
+const DefaultDebugMaxBody = 4 << 10
+
+// DebugOptions configures WithDebug.
+type DebugOptions struct {
+	// Bodies dumps the bodies of requests and responses, up to MaxBody bytes each
+	// (DefaultDebugMaxBody if zero). Bodies aren't redacted, so they may hold
+	// document content, and shouldn't be dumped in production.
+	Bodies  bool
+	MaxBody int
+}
+
+// WithDebug dumps each HTTP request made by the client (RPCs, uploads and
+// downloads), and its response, to w, for troubleshooting. Headers are redacted by
+// the client's redactor (see WithRedactor), which always masks the Authorization
+// header, and the query strings of URLs, which may be signed, are masked. Fields of
+// responses dropped as unknown (see WithStrictDecoding) are noted too.
+func WithDebug(w io.Writer, opts DebugOptions) Option {
+	return func(c *Client) {
+		if opts.MaxBody <= 0 {
+			opts.MaxBody = DefaultDebugMaxBody
+		}
+		c.debug = &debugTransport{c: c, w: w, opts: opts}
+	}
+}
+
+// debugTransport dumps requests and responses. It wraps the transport of the
+// client's HTTP client, once all options have been applied.
+type debugTransport struct {
+	c    *Client
+	w    io.Writer
+	opts DebugOptions
+	base http.RoundTripper
+
+	mu sync.Mutex // Serializes writes to w.
+}
+
+// wrap returns a copy of the HTTP client which dumps its requests.
+func (t *debugTransport) wrap(httpClient *http.Client) *http.Client {
+	wrapped := *httpClient
+	t.base = httpClient.Transport
+	if t.base == nil {
+		t.base = http.DefaultTransport
+	}
+	wrapped.Transport = t
+	return &wrapped
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody *capture
+	if t.opts.Bodies && req.Body != nil && req.Body != http.NoBody {
+		reqBody = &capture{ReadCloser: req.Body, limit: t.opts.MaxBody}
+		req = req.Clone(req.Context())
+		req.Body = reqBody
+	}
+	start := t.c.clock.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := t.c.clock.Now().Sub(start)
+	if err != nil {
+		t.dump(req, reqBody, nil, nil, elapsed, err)
+		return nil, err
+	}
+	if !t.opts.Bodies {
+		t.dump(req, nil, resp, nil, elapsed, nil)
+		return resp, nil
+	}
+	// The response is dumped once its body has been read, along with it.
+	respBody := &capture{ReadCloser: resp.Body, limit: t.opts.MaxBody}
+	respBody.onClose = func() { t.dump(req, reqBody, resp, respBody, elapsed, nil) }
+	resp.Body = respBody
+	return resp, nil
+}
+
+// dump writes a request and its response, or the error it failed with.
+func (t *debugTransport) dump(
+	req *http.Request,
+	reqBody *capture,
+	resp *http.Response,
+	respBody *capture,
+	elapsed time.Duration,
+	err error,
+) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--> %s %s\n", req.Method, redactURL(req.URL.String()))
+	t.writeHeader(&b, req.Header)
+	reqBody.writeTo(&b)
+	switch {
+	case err != nil:
+		fmt.Fprintf(&b, "<-- error (%s): %v\n", elapsed, err)
+	default:
+		fmt.Fprintf(&b, "<-- %s (%s)\n", resp.Status, elapsed)
+		t.writeHeader(&b, resp.Header)
+		respBody.writeTo(&b)
+	}
+	b.WriteByte('\n')
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.w.Write(b.Bytes())
+}
+
+// note writes a note about a call to the output, e.g. about its response.
+func (t *debugTransport) note(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.w, "!!! "+format+"\n\n", args...)
+}
+
+func (t *debugTransport) writeHeader(b *bytes.Buffer, h http.Header) {
+	h = t.c.redactor.Header(h)
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, v := range h[key] {
+			fmt.Fprintf(b, "%s: %s\n", key, v)
+		}
+	}
+}
+
+// capture records the first bytes of a body as it's read. Request bodies may still
+// be read while the response is dumped, e.g. for streams.
+type capture struct {
+	io.ReadCloser
+	limit   int
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	n       int64 // The number of bytes read.
+	onClose func()
+	once    sync.Once
+}
+
+func (c *capture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if room := c.limit - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(n, room)])
+	}
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *capture) Close() error {
+	err := c.ReadCloser.Close()
+	if c.onClose != nil {
+		c.once.Do(c.onClose)
+	}
+	return err
+}
+
+// writeTo writes the captured body, if any, quoting it if it isn't text.
+func (c *capture) writeTo(b *bytes.Buffer) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.n == 0 {
+		return
+	}
+	b.WriteByte('\n')
+	if isText(c.buf.Bytes()) {
+		b.Write(c.buf.Bytes())
+	} else {
+		fmt.Fprintf(b, "%q", c.buf.Bytes())
+	}
+	if c.n > int64(c.buf.Len()) {
+		fmt.Fprintf(b, "... (%d bytes)", c.n)
+	}
+	b.WriteByte('\n')
+}
+
+// isText reports whether a body is printable text, rather than e.g. protobuf.
+func isText(body []byte) bool {
+	for len(body) > 0 {
+		r, size := utf8.DecodeRune(body)
+		if r == utf8.RuneError && size == 1 && len(body) >= utf8.UTFMax {
+			return false // Invalid, rather than truncated by the capture.
+		}
+		if r < ' ' && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+		body = body[size:]
+	}
+	return true
+}
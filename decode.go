@@ -0,0 +1,79 @@
+package operand
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WithStrictDecoding makes JSON responses of REST endpoints (e.g. the
+// CreateFileResponse of uploads) with fields unknown to the SDK fail to decode,
+// e.g. to catch additions to the API in tests. By default, unknown fields are
+// dropped, and listed in the debug output (see WithDebug), so that additions we
+// should adopt are noticed without breaking clients.
+func WithStrictDecoding() Option {
+	return func(c *Client) { c.strictDecoding = true }
+}
+
+// unmarshalJSON decodes the JSON response of a REST endpoint, as configured by
+// WithStrictDecoding.
+func (c *Client) unmarshalJSON(body []byte, m proto.Message) error {
+	if c.strictDecoding {
+		return protojson.Unmarshal(body, m)
+	}
+	if c.debug != nil {
+		md := m.ProtoReflect().Descriptor()
+		if unknown := unknownJSONFields(body, md, ""); len(unknown) > 0 {
+			c.debug.note("dropped unknown fields of %s: %s", md.FullName(), strings.Join(unknown, ", "))
+		}
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(body, m)
+}
+
+// unknownJSONFields returns the paths of the fields of a JSON object unknown to the
+// message it encodes, in order. Invalid JSON has none, and is left to fail to
+// decode.
+func unknownJSONFields(data []byte, md protoreflect.MessageDescriptor, prefix string) []string {
+	var object map[string]json.RawMessage
+	if json.Unmarshal(data, &object) != nil {
+		return nil
+	}
+	var unknown []string
+	for key, value := range object {
+		path := prefix + key
+		fd := md.Fields().ByJSONName(key)
+		if fd == nil {
+			fd = md.Fields().ByTextName(key)
+		}
+		switch {
+		case fd == nil:
+			unknown = append(unknown, path)
+		case fd.Message() == nil || strings.HasPrefix(string(fd.Message().FullName()), "google.protobuf."):
+			// Scalars, and well-known types with their own encoding.
+		case fd.IsMap():
+			var entries map[string]json.RawMessage
+			json.Unmarshal(value, &entries)
+			if fd.MapValue().Message() == nil {
+				break
+			}
+			for k, v := range entries {
+				unknown = append(unknown, unknownJSONFields(v, fd.MapValue().Message(), path+"."+k+".")...)
+			}
+		case fd.IsList():
+			var elems []json.RawMessage
+			json.Unmarshal(value, &elems)
+			for i, elem := range elems {
+				unknown = append(unknown, unknownJSONFields(elem, fd.Message(), fmt.Sprintf("%s[%d].", path, i))...)
+			}
+		default:
+			unknown = append(unknown, unknownJSONFields(value, fd.Message(), path+".")...)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,304 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Fallback serves reads from a local copy of the tenant (e.g. a mirror, or a bundle)
+// while the API is unavailable. See WithFallback.
+type Fallback interface {
+	// AsOf returns the time as of which the copy is current.
+	AsOf() time.Time
+	GetFile(ctx context.Context, id string) (*filev1.File, error)
+	// Open returns the content of a file.
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+	Search(ctx context.Context, req *operandv1.SearchRequest) (*operandv1.SearchResponse, error)
+}
+
+// WithFallback serves reads from the given fallback when the API is unavailable, so
+// that e.g. user-facing search keeps working during short outages. GetFile (by ID)
+// and Search, including the helpers built on them, and DownloadFile fall back;
+// writes still fail.
+//
+// Results served by the fallback are marked as stale: in the CallInfo of the
+// context (see WithCallInfo), and in the FileInfo returned by DownloadFile.
+func WithFallback(f Fallback) Option {
+	return func(c *Client) {
+		c.fallback = f
+	}
+}
+
+// unavailable reports whether an error means the API couldn't be reached, or
+// couldn't serve the call, as opposed to rejecting it.
+func unavailable(ctx context.Context, err error) bool {
+	return err != nil && ctx.Err() == nil && errorCode(err) == connect.CodeUnavailable
+}
+
+// fallbackInterceptor serves GetFile and Search from the fallback when the API is
+// unavailable.
+type fallbackInterceptor struct {
+	c *Client
+}
+
+var _ connect.Interceptor = fallbackInterceptor{}
+
+func (fi fallbackInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		if !unavailable(ctx, err) {
+			return resp, err
+		}
+		f := fi.c.fallback
+		var fresp connect.AnyResponse
+		switch msg := req.Any().(type) {
+		case *filev1.GetFileRequest:
+			id, ok := msg.GetSelector().GetSelector().(*filev1.FileSelector_Id)
+			if !ok {
+				return resp, err
+			}
+			file, ferr := f.GetFile(ctx, id.Id)
+			if ferr != nil {
+				return resp, err
+			}
+			fresp = connect.NewResponse(&filev1.GetFileResponse{File: file})
+		case *operandv1.SearchRequest:
+			sresp, ferr := f.Search(ctx, msg)
+			if ferr != nil {
+				return resp, err
+			}
+			fresp = connect.NewResponse(sresp)
+		default:
+			return resp, err
+		}
+		recordStale(ctx, f.AsOf())
+		return fresp, nil
+	}
+}
+
+func (fallbackInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next // Only unary reads fall back.
+}
+
+func (fallbackInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
+
+// BundleFallback is a Fallback which serves reads from a bundle (see ExportBundle),
+// or a chain of bundles. Search is a simple keyword search over the text content of
+// files, which is only meant to keep search usable until the API is back.
+type BundleFallback struct {
+	manifest *BundleManifest
+	files    map[string]*BundleFile
+	sources  map[string]BundleSource // Bundle ID -> source.
+}
+
+var _ Fallback = (*BundleFallback)(nil)
+
+// NewBundleFallback returns a fallback which serves reads from a chain of bundles,
+// i.e. a full bundle followed by the incremental bundles based on it, in order. The
+// tree is served as of the last bundle.
+func NewBundleFallback(ctx context.Context, sources ...BundleSource) (*BundleFallback, error) {
+	if len(sources) == 0 {
+		return nil, errors.New("no bundles given")
+	}
+	b := &BundleFallback{sources: make(map[string]BundleSource, len(sources))}
+	for _, source := range sources {
+		manifest, err := ReadBundleManifest(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		b.sources[manifest.ID] = source
+		b.manifest = manifest
+	}
+	b.files = make(map[string]*BundleFile, len(b.manifest.Files))
+	for i := range b.manifest.Files {
+		bf := &b.manifest.Files[i]
+		b.files[bf.ID] = bf
+	}
+	return b, nil
+}
+
+// AsOf returns the time the last bundle was exported.
+func (b *BundleFallback) AsOf() time.Time {
+	return b.manifest.ExportedAt
+}
+
+// GetFile returns a file from the bundle. Files have no download URL.
+func (b *BundleFallback) GetFile(_ context.Context, id string) (*filev1.File, error) {
+	bf, ok := b.files[id]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found in bundle", id)
+	}
+	return bundleFileProto(bf, b.manifest.RootID)
+}
+
+// Open returns the content of a file from the bundle which holds it.
+func (b *BundleFallback) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	bf, ok := b.files[id]
+	if !ok {
+		return nil, fmt.Errorf("file %s not found in bundle", id)
+	}
+	if bf.Folder {
+		return nil, fmt.Errorf("%s is a folder", id)
+	}
+	bundleID := bf.Bundle
+	if bundleID == "" {
+		bundleID = b.manifest.ID
+	}
+	source, ok := b.sources[bundleID]
+	if !ok {
+		return nil, fmt.Errorf("content of %s is held by bundle %s, which wasn't given", id, bundleID)
+	}
+	return source.Open(ctx, bf.Content)
+}
+
+// maxFallbackSnippet is the maximum length of the snippets of fallback matches.
+const maxFallbackSnippet = 300
+
+// Search matches the terms of the query against the lines of the text files within
+// the request's scope and filter, and ranks files by the share of terms they
+// contain. Each match is the best line of a file.
+func (b *BundleFallback) Search(ctx context.Context, req *operandv1.SearchRequest) (*operandv1.SearchResponse, error) {
+	terms := strings.Fields(strings.ToLower(req.Query))
+	if len(terms) == 0 {
+		return &operandv1.SearchResponse{}, nil
+	}
+	maxResults := int(req.GetMaxResults())
+	if maxResults <= 0 {
+		maxResults = 10
+	}
+
+	resp := &operandv1.SearchResponse{Files: make(map[string]*filev1.File)}
+	for i := range b.manifest.Files {
+		bf := &b.manifest.Files[i]
+		if bf.Folder || (req.ParentId != nil && !b.within(bf, *req.ParentId)) {
+			continue
+		}
+		file, err := bundleFileProto(bf, b.manifest.RootID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Filter != nil && !MatchesFilter(req.Filter, file.Properties) {
+			continue
+		}
+		snippet, score, err := b.bestLine(ctx, bf, terms)
+		if err != nil {
+			return nil, err
+		}
+		if score == 0 {
+			continue
+		}
+		resp.Matches = append(resp.Matches, &operandv1.ContentMatch{
+			MatchId: bf.ID,
+			FileId:  bf.ID,
+			Snippet: snippet,
+			Score:   score,
+		})
+		resp.Files[bf.ID] = file
+	}
+	sort.SliceStable(resp.Matches, func(i, j int) bool {
+		return resp.Matches[i].Score > resp.Matches[j].Score
+	})
+	if len(resp.Matches) > maxResults {
+		for _, m := range resp.Matches[maxResults:] {
+			delete(resp.Files, m.FileId)
+		}
+		resp.Matches = resp.Matches[:maxResults]
+	}
+	return resp, nil
+}
+
+// within reports whether a file is below the given folder.
+func (b *BundleFallback) within(bf *BundleFile, folderID string) bool {
+	for id := bf.ParentID; id != ""; {
+		if id == folderID {
+			return true
+		}
+		parent, ok := b.files[id]
+		if !ok {
+			break
+		}
+		id = parent.ParentID
+	}
+	return folderID == b.manifest.RootID
+}
+
+// bestLine returns the line of a file which contains the most terms, and the share
+// of terms it contains. Files which aren't text have no lines.
+func (b *BundleFallback) bestLine(ctx context.Context, bf *BundleFile, terms []string) (string, float32, error) {
+	r, err := b.Open(ctx, bf.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", 0, err
+	}
+	if !utf8.Valid(data) {
+		return "", 0, nil
+	}
+
+	var best string
+	bestCount := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		lower := strings.ToLower(line)
+		count := 0
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = strings.TrimSpace(line), count
+		}
+	}
+	if len(best) > maxFallbackSnippet {
+		best = best[:maxFallbackSnippet]
+		for !utf8.ValidString(best) {
+			best = best[:len(best)-1]
+		}
+	}
+	return best, float32(bestCount) / float32(len(terms)), nil
+}
+
+// bundleFileProto converts a file of a bundle of the given folder back into a file.
+func bundleFileProto(bf *BundleFile, rootID string) (*filev1.File, error) {
+	file := &filev1.File{
+		Id:        bf.ID,
+		Name:      bf.Name,
+		CreatedAt: timestamppb.New(bf.CreatedAt),
+		UpdatedAt: timestamppb.New(bf.UpdatedAt),
+	}
+	parentID := bf.ParentID
+	if parentID == "" {
+		parentID = rootID
+	}
+	if parentID != "" {
+		file.ParentId = &parentID
+	}
+	if !bf.Folder {
+		size := bf.SizeBytes
+		file.SizeBytes = &size
+	}
+	if len(bf.Properties) > 0 {
+		file.Properties = new(filev1.Properties)
+		if err := protojson.Unmarshal(bf.Properties, file.Properties); err != nil {
+			return nil, fmt.Errorf("invalid properties for %s: %w", bf.Path, err)
+		}
+	}
+	return file, nil
+}
@@ -0,0 +1,32 @@
+package operand
+
+import (
+	"context"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// DeleteFile deletes a file, treating files which no longer exist as deleted. The
+// API deletes folders along with everything within them. All deletions made by the
+// SDK go through DeleteFile, which refuses to delete files protected by a legal
+// hold (see Hold), or folders holding any, with ErrProtected.
+func (c *Client) DeleteFile(ctx context.Context, id string) error {
+	if err := c.checkDeletable(ctx, id); err != nil {
+		return err
+	}
+	_, err := c.FileService().DeleteFile(ctx, connect.NewRequest(&filev1.DeleteFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+	}))
+	if connect.CodeOf(err) == connect.CodeNotFound {
+		return nil // Already gone.
+	}
+	return err
+}
+
+// DeleteTree deletes a folder and everything within it. If anything within the
+// folder is protected by a legal hold, nothing is deleted, and ErrProtected is
+// returned. It's equivalent to DeleteFile, which checks the contents of folders.
+func (c *Client) DeleteTree(ctx context.Context, rootID string) error {
+	return c.DeleteFile(ctx, rootID)
+}
@@ -0,0 +1,27 @@
+package operand_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	operand "github.com/operandinc/go-sdk"
+	"github.com/operandinc/go-sdk/operandtest"
+)
+
+func TestDeleteFileOfFolderHoldingAHeldFileFails(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	folder := createFile(t, client, "folder", "", "", nil)
+	sub := createFile(t, client, "sub", folder.Id, "", nil)
+	held := createFile(t, client, "held.txt", sub.Id, "evidence",
+		operand.SetProperty(nil, operand.PropertyLegalHold, operand.TextProperty("litigation")))
+
+	if err := client.DeleteFile(context.Background(), folder.Id); !errors.Is(err, operand.ErrProtected) {
+		t.Fatalf("got %v, want %v", err, operand.ErrProtected)
+	}
+	if _, ok := srv.Content(held.Id); !ok {
+		t.Error("the held file was deleted")
+	}
+}
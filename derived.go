@@ -0,0 +1,95 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// Properties set on derived assets.
+const (
+	// PropertyDerivedFrom holds the ID of the file an asset was derived from.
+	PropertyDerivedFrom = "operand_derived_from"
+	// PropertyDerivedKind holds the kind of a derived asset (see AssetKind).
+	PropertyDerivedKind = "operand_derived_kind"
+)
+
+// AssetKind is a kind of asset derived from a file.
+type AssetKind string
+
+// Kinds of derived assets. Summaries are the sidecars created by CreateSummary.
+const (
+	AssetSummary   AssetKind = "summary"
+	AssetThumbnail AssetKind = "thumbnail"
+	AssetText      AssetKind = "text"
+	AssetPageImage AssetKind = "page_image"
+)
+
+// ErrNoAsset is returned when a file has no derived asset of the requested kind.
+var ErrNoAsset = errors.New("no derived asset of the requested kind")
+
+// CreateDerivedAsset uploads an asset derived from a file, e.g. a thumbnail or its
+// extracted text, as a sidecar next to it, so that it can be retrieved by kind
+// with DerivedAsset. The API doesn't generate derived assets itself.
+func (c *Client) CreateDerivedAsset(
+	ctx context.Context,
+	file *filev1.File,
+	kind AssetKind,
+	ext string,
+	data io.Reader,
+) (*filev1.File, error) {
+	properties := SetProperty(nil, PropertyDerivedFrom, TextProperty(file.Id))
+	properties = SetProperty(properties, PropertyDerivedKind, TextProperty(string(kind)))
+	name := fmt.Sprintf("%s.%s%s", strings.TrimSuffix(file.Name, path.Ext(file.Name)), kind, ext)
+	resp, err := c.CreateFile(ctx, name, file.ParentId, data, properties)
+	if err != nil {
+		return nil, err
+	}
+	return resp.File, nil
+}
+
+// DerivedAsset returns the content of the asset of the given kind derived from a
+// file, along with the asset itself. The caller must close the content. It returns
+// ErrNoAsset if there is no such asset.
+func (c *Client) DerivedAsset(ctx context.Context, fileID string, kind AssetKind) (io.ReadCloser, *filev1.File, error) {
+	resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: fileID}},
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+	siblings, err := c.ListFolder(ctx, resp.Msg.File.GetParentId())
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, f := range siblings {
+		if !isAssetOf(f, fileID, kind) {
+			continue
+		}
+		content, err := c.download(ctx, f.DownloadUrl)
+		if err != nil {
+			return nil, nil, err
+		}
+		return content.Body, f, nil
+	}
+	return nil, nil, ErrNoAsset
+}
+
+// isAssetOf reports whether a file is an asset of the given kind derived from
+// another.
+func isAssetOf(f *filev1.File, fileID string, kind AssetKind) bool {
+	if kind == AssetSummary {
+		if of, ok := IsSummary(f); ok {
+			return of == fileID
+		}
+	}
+	from, _ := PropertyText(f.GetProperties(), PropertyDerivedFrom)
+	k, _ := PropertyText(f.GetProperties(), PropertyDerivedKind)
+	return from == fileID && k == string(kind)
+}
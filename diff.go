@@ -0,0 +1,157 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+// SearchTarget is one side of a search comparison: a client (i.e. an endpoint and
+// configuration) and the options to search with (e.g. a scope).
+type SearchTarget struct {
+	Client  *Client
+	Options []SearchOption
+}
+
+// DiffOptions configures DiffSearch.
+type DiffOptions struct {
+	// Key identifies a result across both sides. Defaults to the original name of
+	// the matching file (see OriginalName), since file IDs differ between
+	// endpoints and reindexed copies.
+	Key func(match *operandv1.ContentMatch, file *filev1.File) string
+}
+
+// DiffItem is a result which was only returned by one side.
+type DiffItem struct {
+	Key   string  `json:"key"`
+	Rank  int     `json:"rank"`
+	Score float32 `json:"score"`
+}
+
+// RankChange is a result which was returned by both sides.
+type RankChange struct {
+	Key    string  `json:"key"`
+	RankA  int     `json:"rank_a"`
+	RankB  int     `json:"rank_b"`
+	ScoreA float32 `json:"score_a"`
+	ScoreB float32 `json:"score_b"`
+}
+
+// Shift is the change in rank from A to B. Positive shifts move the result down.
+func (rc RankChange) Shift() int { return rc.RankB - rc.RankA }
+
+// ScoreDelta is the change in score from A to B.
+func (rc RankChange) ScoreDelta() float32 { return rc.ScoreB - rc.ScoreA }
+
+// SearchDiff is the difference between the results of a query on two sides.
+// Results are ranked by their best match, and ranks are 1-based.
+type SearchDiff struct {
+	Query string `json:"query"`
+	// Overlap is the fraction of distinct results returned by both sides (the
+	// Jaccard index). It's 1 if neither side returned anything.
+	Overlap float64      `json:"overlap"`
+	Common  []RankChange `json:"common"`
+	OnlyA   []DiffItem   `json:"only_a"`
+	OnlyB   []DiffItem   `json:"only_b"`
+}
+
+// MeanAbsShift is the mean absolute rank shift of the common results.
+func (d *SearchDiff) MeanAbsShift() float64 {
+	if len(d.Common) == 0 {
+		return 0
+	}
+	var total int
+	for _, rc := range d.Common {
+		if s := rc.Shift(); s < 0 {
+			total -= s
+		} else {
+			total += s
+		}
+	}
+	return float64(total) / float64(len(d.Common))
+}
+
+// DiffSearch runs a query against both sides, and compares the results, e.g. to
+// evaluate a change to chunking or preprocessing before it's rolled out.
+func DiffSearch(ctx context.Context, query string, a, b SearchTarget, opts DiffOptions) (*SearchDiff, error) {
+	key := opts.Key
+	if key == nil {
+		key = func(_ *operandv1.ContentMatch, file *filev1.File) string { return OriginalName(file) }
+	}
+	ra, err := rankedResults(ctx, query, a, key)
+	if err != nil {
+		return nil, fmt.Errorf("side A: %w", err)
+	}
+	rb, err := rankedResults(ctx, query, b, key)
+	if err != nil {
+		return nil, fmt.Errorf("side B: %w", err)
+	}
+
+	d := &SearchDiff{Query: query}
+	inB := make(map[string]DiffItem, len(rb))
+	for _, item := range rb {
+		inB[item.Key] = item
+	}
+	inA := make(map[string]bool, len(ra))
+	for _, item := range ra {
+		inA[item.Key] = true
+		if other, ok := inB[item.Key]; ok {
+			d.Common = append(d.Common, RankChange{
+				Key:    item.Key,
+				RankA:  item.Rank,
+				RankB:  other.Rank,
+				ScoreA: item.Score,
+				ScoreB: other.Score,
+			})
+		} else {
+			d.OnlyA = append(d.OnlyA, item)
+		}
+	}
+	for _, item := range rb {
+		if !inA[item.Key] {
+			d.OnlyB = append(d.OnlyB, item)
+		}
+	}
+	d.Overlap = 1
+	if union := len(d.Common) + len(d.OnlyA) + len(d.OnlyB); union > 0 {
+		d.Overlap = float64(len(d.Common)) / float64(union)
+	}
+	return d, nil
+}
+
+// rankedResults runs a query, and ranks the distinct results by their best match.
+func rankedResults(
+	ctx context.Context,
+	query string,
+	target SearchTarget,
+	key func(*operandv1.ContentMatch, *filev1.File) string,
+) ([]DiffItem, error) {
+	resp, err := target.Client.Search(ctx, query, target.Options...)
+	if err != nil {
+		return nil, err
+	}
+	best := make(map[string]float32)
+	for _, m := range resp.Matches {
+		k := key(m, resp.Files[m.FileId])
+		if score, ok := best[k]; !ok || m.Score > score {
+			best[k] = m.Score
+		}
+	}
+	items := make([]DiffItem, 0, len(best))
+	for k, score := range best {
+		items = append(items, DiffItem{Key: k, Score: score})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].Key < items[j].Key
+	})
+	for i := range items {
+		items[i].Rank = i + 1
+	}
+	return items, nil
+}
@@ -0,0 +1,159 @@
+package operand
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/operandinc/go-sdk/chunk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// DirConfigName is the name of the per-directory configuration files read by
+// UploadDir and Sync with UploadOptions.DirConfig. Configuration files aren't
+// uploaded themselves.
+const DirConfigName = ".operand.yaml"
+
+// DirConfig is the configuration of a directory, which applies to everything
+// within it, including subdirectories. A subdirectory's configuration overrides
+// its parent's, except that tags are merged and ignore rules are added to.
+//
+//	chunk_profile: markdown
+//	tags:
+//	  team: docs
+//	collision: overwrite
+//	ignore:
+//	  - "*.tmp"
+//	  - drafts/
+type DirConfig struct {
+	// ChunkProfile is recorded in PropertyChunkProfile, for CreateChunkedFile.
+	ChunkProfile string `yaml:"chunk_profile"`
+	// Tags are added to files as text properties.
+	Tags map[string]string `yaml:"tags"`
+	// Collision is the name of a collision policy (see ParseCollisionPolicy). It's
+	// ignored by Sync.
+	Collision string `yaml:"collision"`
+	// Ignore lists patterns (see path.Match) of files and directories to skip.
+	// Patterns containing a slash are matched against paths relative to the
+	// directory; others against names. A trailing slash only matches directories.
+	Ignore []string `yaml:"ignore"`
+}
+
+// ReadDirConfig reads the configuration file of a directory. It returns nil if the
+// directory has none.
+func ReadDirConfig(dir string) (*DirConfig, error) {
+	b, err := os.ReadFile(filepath.Join(dir, DirConfigName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var config DirConfig
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", DirConfigName, err)
+	}
+	if config.ChunkProfile != "" {
+		if chunk.ForName(config.ChunkProfile) == nil {
+			return nil, fmt.Errorf("invalid %s: unknown chunk profile %q", DirConfigName, config.ChunkProfile)
+		}
+	}
+	if config.Collision != "" {
+		if _, err := ParseCollisionPolicy(config.Collision); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", DirConfigName, err)
+		}
+	}
+	for _, pattern := range config.Ignore {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid %s: bad ignore pattern %q", DirConfigName, pattern)
+		}
+	}
+	return &config, nil
+}
+
+// dirSettings are the settings in effect within a directory, merged from the
+// configuration of it and its parents.
+type dirSettings struct {
+	chunkProfile string
+	tags         map[string]string
+	collision    *CollisionPolicy
+	ignore       []ignoreRule
+}
+
+type ignoreRule struct {
+	dir     string // The directory of the rule's configuration, relative to the root.
+	pattern string
+	dirOnly bool
+}
+
+// with returns the settings for a subdirectory with the given configuration.
+func (s *dirSettings) with(relDir string, config *DirConfig) *dirSettings {
+	if config == nil {
+		return s
+	}
+	merged := *s
+	if config.ChunkProfile != "" {
+		merged.chunkProfile = config.ChunkProfile
+	}
+	if len(config.Tags) > 0 {
+		merged.tags = make(map[string]string, len(s.tags)+len(config.Tags))
+		for k, v := range s.tags {
+			merged.tags[k] = v
+		}
+		for k, v := range config.Tags {
+			merged.tags[k] = v
+		}
+	}
+	if config.Collision != "" {
+		policy, _ := ParseCollisionPolicy(config.Collision) // Validated on read.
+		merged.collision = &policy
+	}
+	merged.ignore = s.ignore[:len(s.ignore):len(s.ignore)] // Appends copy.
+	for _, pattern := range config.Ignore {
+		merged.ignore = append(merged.ignore, ignoreRule{
+			dir:     relDir,
+			pattern: strings.TrimSuffix(pattern, "/"),
+			dirOnly: strings.HasSuffix(pattern, "/"),
+		})
+	}
+	return &merged
+}
+
+// ignored reports whether the file or directory at the given path is ignored.
+func (s *dirSettings) ignored(rel string, isDir bool) bool {
+	for _, rule := range s.ignore {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		subject := path.Base(rel)
+		if strings.Contains(rule.pattern, "/") {
+			subject = strings.TrimPrefix(rel, rule.dir+"/")
+			if rule.dir == "." {
+				subject = rel
+			}
+		}
+		if ok, _ := path.Match(rule.pattern, subject); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// properties adds the tags and chunk profile to a copy of the properties.
+func (s *dirSettings) properties(properties *filev1.Properties) *filev1.Properties {
+	if s.chunkProfile == "" && len(s.tags) == 0 {
+		return properties
+	}
+	properties = cloneProperties(properties)
+	for k, v := range s.tags {
+		properties = SetProperty(properties, k, TextProperty(v))
+	}
+	if s.chunkProfile != "" {
+		properties = SetProperty(properties, PropertyChunkProfile, TextProperty(s.chunkProfile))
+	}
+	return properties
+}
@@ -0,0 +1,386 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// download fetches the contents at the given URL, typically a file's download URL.
+// The API key is only sent along if the URL points at the configured endpoint.
+func (c *Client) download(ctx context.Context, rawURL string) (*http.Response, error) {
+	return c.get(ctx, rawURL, nil)
+}
+
+// downloadRange fetches up to length bytes of the contents at the given URL,
+// starting at offset, or the rest of the contents if length isn't positive. Servers
+// may ignore the range, and return all of the contents with http.StatusOK instead
+// of http.StatusPartialContent.
+func (c *Client) downloadRange(ctx context.Context, rawURL string, offset, length int64) (*http.Response, error) {
+	return c.get(ctx, rawURL, http.Header{"Range": {byteRange(offset, length)}})
+}
+
+// byteRange returns the value of a Range header requesting length bytes starting at
+// offset, or the rest of the contents if length isn't positive.
+func byteRange(offset, length int64) string {
+	if length <= 0 {
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+}
+
+// get fetches the contents at the given URL, sending along the given headers (e.g.
+// Range and Accept).
+func (c *Client) get(ctx context.Context, rawURL string, header http.Header) (*http.Response, error) {
+	ctx = c.withOperation(ctx)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.isEndpointURL(req.URL) {
+		req.Header.Set("Authorization", "Key "+c.apiKey)
+		setRequestContext(ctx, req.Header)
+		setOperationID(ctx, req.Header)
+	}
+
+	c.checkDeadline(ctx, MethodDownload, false)
+	ctx, cl := c.startCallLog(ctx)
+	start := c.clock.Now()
+	resp, err := c.httpClient.Do(req)
+	if err == nil {
+		recordCallInfo(ctx, resp.Header, resp.Trailer)
+		if resp.StatusCode != http.StatusOK && (header.Get("Range") == "" || resp.StatusCode != http.StatusPartialContent) {
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+			err = c.httpError(resp, body)
+		}
+	}
+	c.stats.record(MethodDownload, c.clock.Now().Sub(start), err)
+	c.logCall(ctx, cl, MethodDownload, start, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *Client) isEndpointURL(u *url.URL) bool {
+	if c.resolver != nil && c.resolver.isResolvedURL(u) {
+		return true
+	}
+	endpoint, err := url.Parse(c.currentEndpoint().url)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == endpoint.Scheme && u.Host == endpoint.Host
+}
+
+// FileInfo describes the content of a downloaded file.
+type FileInfo struct {
+	File *filev1.File
+	// Size is the size of the content in bytes, or -1 if it isn't known.
+	Size int64
+	// ContentType is the media type of the content, which tells the representation
+	// the server chose (see WithAccept).
+	ContentType string
+	// Ranged is set if a range was requested (see WithRange) and honored, in which
+	// case the content starts at Offset. Servers may ignore ranges, and return the
+	// whole content instead.
+	Ranged bool
+	Offset int64
+	// Stale is set if the content was served by a fallback (see WithFallback), whose
+	// data is current as of StaleAsOf.
+	Stale     bool
+	StaleAsOf time.Time
+}
+
+// DownloadFileOption configures DownloadFile.
+type DownloadFileOption func(*downloadFileOptions)
+
+type downloadFileOptions struct {
+	ranged         bool
+	offset, length int64
+	accept         []string
+}
+
+// WithRange requests length bytes of the content starting at offset, or the rest of
+// the content if length isn't positive, e.g. to resume an interrupted download.
+// Check FileInfo.Ranged for whether the range was honored.
+func WithRange(offset, length int64) DownloadFileOption {
+	return func(o *downloadFileOptions) {
+		o.ranged, o.offset, o.length = true, offset, length
+	}
+}
+
+// Media types of the representations of content which may be requested with
+// WithAccept, where the server supports them, in place of the original content.
+const (
+	// MediaTypeText is the plain text extracted from the content.
+	MediaTypeText = "text/plain"
+	// MediaTypeMarkdown is the content converted to Markdown.
+	MediaTypeMarkdown = "text/markdown"
+)
+
+// WithAccept requests the content in one of the given representations (e.g.
+// MediaTypeText), in order of preference, rather than the original content. Servers
+// which don't support any of them return the original content, or fail with
+// connect.CodeUnimplemented; check FileInfo.ContentType for the representation
+// returned. Fallbacks (see WithFallback) always serve the original content.
+func WithAccept(mediaTypes ...string) DownloadFileOption {
+	return func(o *downloadFileOptions) { o.accept = mediaTypes }
+}
+
+// DownloadFile streams the content of a file. The caller must close the content.
+// The folder of a resumable upload (see ResumableUpload) is streamed as the file
+// it holds.
+func (c *Client) DownloadFile(ctx context.Context, fileID string, opts ...DownloadFileOption) (io.ReadCloser, *FileInfo, error) {
+	var o downloadFileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: fileID}},
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+	file := resp.Msg.File
+	if _, ok := PropertyText(file.Properties, PropertyResumableUpload); ok {
+		return c.openParts(ctx, file), &FileInfo{File: file, Size: -1}, nil
+	}
+	if IsFolder(file) {
+		return nil, nil, fmt.Errorf("%s is a folder", fileID)
+	}
+	return c.openContent(ctx, file, &o)
+}
+
+// openParts streams the concatenated parts of a resumable upload (see ReadParts).
+// Ranges and representations aren't supported, so the whole content is returned.
+func (c *Client) openParts(ctx context.Context, folder *filev1.File) io.ReadCloser {
+	r, w := io.Pipe()
+	go func() {
+		w.CloseWithError(c.ReadParts(ctx, folder.Id, w))
+	}()
+	return r
+}
+
+// openContent downloads the content of a file, falling back to the client's
+// fallback, if any, when the API is unavailable.
+func (c *Client) openContent(ctx context.Context, file *filev1.File, o *downloadFileOptions) (io.ReadCloser, *FileInfo, error) {
+	var err error
+	if file.DownloadUrl != "" { // Files served by a fallback have no download URL.
+		header := make(http.Header)
+		if o.ranged {
+			header.Set("Range", byteRange(o.offset, o.length))
+		}
+		if len(o.accept) > 0 {
+			header.Set("Accept", acceptHeader(o.accept))
+		}
+		var resp *http.Response
+		resp, err = c.get(ctx, file.DownloadUrl, header)
+		if err == nil {
+			return resp.Body, fileInfo(file, resp), nil
+		}
+		if c.fallback == nil || !unavailable(ctx, err) {
+			return nil, nil, err
+		}
+	} else if c.fallback == nil {
+		return nil, nil, fmt.Errorf("%s has no download URL", file.Id)
+	}
+	content, ferr := c.fallback.Open(ctx, file.Id)
+	if ferr != nil {
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ferr
+	}
+	asOf := c.fallback.AsOf()
+	recordStale(ctx, asOf)
+	info := &FileInfo{File: file, Size: file.GetSizeBytes(), Stale: true, StaleAsOf: asOf}
+	if o.ranged { // The range is applied locally.
+		if _, err := io.CopyN(io.Discard, content, o.offset); err != nil && err != io.EOF {
+			content.Close()
+			return nil, nil, err
+		}
+		info.Ranged, info.Offset = true, o.offset
+		info.Size = max(info.Size-o.offset, 0)
+		if o.length > 0 {
+			info.Size = min(info.Size, o.length)
+			content = readCloser{io.LimitReader(content, o.length), content}
+		}
+	}
+	return content, info, nil
+}
+
+// acceptHeader returns the value of an Accept header preferring the given media
+// types in order.
+func acceptHeader(mediaTypes []string) string {
+	var b strings.Builder
+	for i, t := range mediaTypes {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(t)
+		if i > 0 {
+			fmt.Fprintf(&b, ";q=0.%d", max(10-i, 1))
+		}
+	}
+	return b.String()
+}
+
+// readCloser combines a reader with the closer of the underlying content.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// fileInfo describes the content of a file from the response it was downloaded in.
+func fileInfo(file *filev1.File, resp *http.Response) *FileInfo {
+	info := &FileInfo{File: file, Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type")}
+	if info.Size < 0 && resp.StatusCode == http.StatusOK && file.SizeBytes != nil {
+		info.Size = *file.SizeBytes
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		info.Ranged = true
+		info.Offset, _ = contentRangeStart(resp.Header.Get("Content-Range"))
+	}
+	return info
+}
+
+// contentRangeStart returns the first byte position of a Content-Range header,
+// e.g. 100 for "bytes 100-199/1000".
+func contentRangeStart(h string) (int64, bool) {
+	spec, ok := strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
+
+// DownloadOptions configures DownloadDir.
+type DownloadOptions struct {
+	// Attributes restores the attributes of files captured on upload (see
+	// UploadOptions.Attributes).
+	Attributes bool
+	// Symlinks recreates symbolic links recorded by SymlinkRecord. Otherwise, they're
+	// downloaded as empty files.
+	Symlinks bool
+}
+
+// DownloadDir downloads the tree of files below a folder (empty for the root) into
+// a local directory, which is created if necessary. Files are given their original
+// names (see OriginalName), made safe for the local file system. Nothing is written
+// outside of the directory: existing symbolic links aren't followed, and links
+// whose target is absolute or outside of the directory are rejected.
+func (c *Client) DownloadDir(ctx context.Context, folderID, dir string, opts DownloadOptions) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	paths := map[string]string{folderID: dir} // Folder ID -> local path.
+	return c.Walk(ctx, folderID, func(file *filev1.File, _ string) error {
+		parent, ok := paths[file.GetParentId()]
+		if !ok {
+			return fmt.Errorf("parent %s of %s isn't within the folder", file.GetParentId(), file.Id)
+		}
+		p := filepath.Join(parent, exportName(OriginalName(file)))
+		if err := noSymlink(p); err != nil {
+			return err
+		}
+		if IsFolder(file) {
+			paths[file.Id] = p
+			return os.MkdirAll(p, 0o755)
+		}
+		if target, ok := PropertyText(file.GetProperties(), PropertySymlinkTarget); ok && opts.Symlinks {
+			target, err := symlinkTarget(dir, p, target)
+			if err != nil {
+				return fmt.Errorf("failed to link %s: %w", file.Id, err)
+			}
+			if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			return os.Symlink(target, p)
+		}
+		if err := c.downloadTo(ctx, file, p); err != nil {
+			return fmt.Errorf("failed to download %s: %w", file.Id, err)
+		}
+		if opts.Attributes {
+			if err := restoreAttributes(p, file); err != nil {
+				return fmt.Errorf("failed to restore attributes of %s: %w", file.Id, err)
+			}
+		}
+		return nil
+	})
+}
+
+// noSymlink returns an error if a path is an existing symbolic link, so that it
+// isn't followed out of the directory being downloaded into.
+func noSymlink(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return fmt.Errorf("%s is a symbolic link", path)
+	}
+	return nil
+}
+
+// symlinkTarget validates the target of a symbolic link to be created at path,
+// within root, returning it cleaned. Since the folders of a download are never
+// symbolic links, the cleaned target resolves within root if it does lexically.
+func symlinkTarget(root, path, target string) (string, error) {
+	target = filepath.Clean(filepath.FromSlash(target))
+	if filepath.IsAbs(target) || filepath.VolumeName(target) != "" || strings.HasPrefix(target, string(filepath.Separator)) {
+		return "", fmt.Errorf("absolute target %q", target)
+	}
+	rel, err := filepath.Rel(root, filepath.Join(filepath.Dir(path), target))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("target %q is outside of %s", target, root)
+	}
+	return target, nil
+}
+
+// downloadTo downloads the content of a file to a local path.
+func (c *Client) downloadTo(ctx context.Context, file *filev1.File, path string) error {
+	resp, err := c.download(ctx, file.DownloadUrl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,107 @@
+package operand_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/operandinc/go-sdk/operandtest"
+)
+
+func createFile(t *testing.T, client *operand.Client, name, parentID, content string, properties *filev1.Properties) *filev1.File {
+	t.Helper()
+	var parent *string
+	if parentID != "" {
+		parent = &parentID
+	}
+	var data io.Reader // Nil for folders.
+	if content != "" {
+		data = strings.NewReader(content)
+	}
+	resp, err := client.CreateFile(context.Background(), name, parent, data, properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.File
+}
+
+func symlink(target string) *filev1.Properties {
+	return operand.SetProperty(nil, operand.PropertySymlinkTarget, operand.TextProperty(target))
+}
+
+func TestDownloadDirRejectsEscapingSymlinks(t *testing.T) {
+	for _, target := range []string{"..", "../outside", "sub/../../outside", "/tmp"} {
+		t.Run(target, func(t *testing.T) {
+			srv := operandtest.NewServer()
+			defer srv.Close()
+			client := srv.Client()
+			folder := createFile(t, client, "folder", "", "", nil)
+			createFile(t, client, "link", folder.Id, "-", symlink(target))
+			// A file of the same name would be written through the link.
+			createFile(t, client, "link", folder.Id, "escaped", nil)
+
+			root := t.TempDir()
+			dest := filepath.Join(root, "dest")
+			err := client.DownloadDir(context.Background(), folder.Id, dest, operand.DownloadOptions{Symlinks: true})
+			if err == nil {
+				t.Fatal("DownloadDir succeeded")
+			}
+			if _, err := os.Lstat(filepath.Join(dest, "link")); !os.IsNotExist(err) {
+				t.Errorf("link was created: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(root, "outside")); !os.IsNotExist(err) {
+				t.Errorf("a file was written outside of the directory: %v", err)
+			}
+		})
+	}
+}
+
+func TestDownloadDirDoesNotWriteThroughSymlinks(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	folder := createFile(t, client, "folder", "", "", nil)
+	createFile(t, client, "target", folder.Id, "original", nil)
+	createFile(t, client, "link", folder.Id, "-", symlink("target"))
+	createFile(t, client, "link", folder.Id, "overwritten", nil)
+
+	dest := t.TempDir()
+	err := client.DownloadDir(context.Background(), folder.Id, dest, operand.DownloadOptions{Symlinks: true})
+	if err == nil {
+		t.Fatal("DownloadDir succeeded")
+	}
+	content, err := os.ReadFile(filepath.Join(dest, "target"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "original" {
+		t.Errorf("target was overwritten through the link: %q", content)
+	}
+}
+
+func TestDownloadDirCreatesSymlinksWithinTheDirectory(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	folder := createFile(t, client, "folder", "", "", nil)
+	sub := createFile(t, client, "sub", folder.Id, "", nil)
+	createFile(t, client, "target", folder.Id, "content", nil)
+	createFile(t, client, "link", sub.Id, "-", symlink("../target"))
+
+	dest := t.TempDir()
+	if err := client.DownloadDir(context.Background(), folder.Id, dest, operand.DownloadOptions{Symlinks: true}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(dest, "sub", "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "content" {
+		t.Errorf("got %q through the link, want %q", content, "content")
+	}
+}
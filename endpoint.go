@@ -0,0 +1,126 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/bufbuild/connect-go"
+)
+
+// endpoint is an endpoint of the API, along with the number of calls in flight to
+// it, so that it can be drained once the client switches to another.
+type endpoint struct {
+	url string
+
+	mu       sync.Mutex
+	inflight int
+	retired  bool
+	drained  chan struct{} // Closed once retired with no calls in flight.
+}
+
+func newEndpoint(url string) *endpoint {
+	return &endpoint{url: url, drained: make(chan struct{})}
+}
+
+// acquire records the start of a call to the endpoint.
+func (e *endpoint) acquire() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.inflight++
+}
+
+// release records the end of a call to the endpoint.
+func (e *endpoint) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.inflight--
+	if e.retired && e.inflight == 0 {
+		close(e.drained)
+	}
+}
+
+// drain retires the endpoint, and waits for the calls in flight to it to complete.
+func (e *endpoint) drain(ctx context.Context) error {
+	e.mu.Lock()
+	if !e.retired {
+		e.retired = true
+		if e.inflight == 0 {
+			close(e.drained)
+		}
+	}
+	e.mu.Unlock()
+	select {
+	case <-e.drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// currentEndpoint returns the endpoint new calls are made to.
+func (c *Client) currentEndpoint() *endpoint {
+	return c.endpoint.Load()
+}
+
+// SetEndpoint switches the endpoint of the API at runtime, e.g. to follow an update
+// from service discovery: calls started from then on, including by service clients
+// obtained from then on (see FileService), are made to the new endpoint. It then
+// waits for the RPCs and uploads in flight to the previous endpoint to complete, or
+// for the context to be done, in which case they carry on, and the context's error
+// is returned. Downloads aren't waited for, as their content is streamed to the
+// caller; neither are calls made with service clients obtained before the switch,
+// which are still made to the previous endpoint.
+func (c *Client) SetEndpoint(ctx context.Context, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+	previous := c.endpoint.Swap(newEndpoint(endpoint))
+	return previous.drain(ctx)
+}
+
+// endpointInterceptor tracks the RPCs in flight to the endpoint a service client was
+// obtained for. It's the outermost interceptor, so that all attempts of a call are
+// covered.
+type endpointInterceptor struct {
+	e *endpoint
+}
+
+var _ connect.Interceptor = endpointInterceptor{}
+
+func (ei endpointInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ei.e.acquire()
+		defer ei.e.release()
+		return next(ctx, req)
+	}
+}
+
+func (ei endpointInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		ei.e.acquire()
+		return &endpointConn{StreamingClientConn: next(ctx, spec), e: ei.e}
+	}
+}
+
+func (endpointInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
+
+// endpointConn records the end of a stream once it has been closed.
+type endpointConn struct {
+	connect.StreamingClientConn
+	e    *endpoint
+	once sync.Once
+}
+
+func (ec *endpointConn) CloseResponse() error {
+	err := ec.StreamingClientConn.CloseResponse()
+	ec.once.Do(ec.e.release)
+	return err
+}
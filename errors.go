@@ -0,0 +1,397 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Sentinel errors matching the *Error of failed calls by code, for use with
+// errors.Is, e.g. errors.Is(err, operand.ErrNotFound).
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrAlreadyExists    = errors.New("already exists")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrUnauthenticated  = errors.New("unauthenticated")
+	ErrInvalidArgument  = errors.New("invalid argument")
+	// ErrRateLimited matches calls rejected by the rate limiter (see
+	// Error.RateLimit), or for exceeding a quota.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnavailable matches calls which failed as the API couldn't be reached, or
+	// couldn't serve them, e.g. during an outage.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// sentinelCodes maps sentinel errors to the codes they match.
+var sentinelCodes = map[error]connect.Code{
+	ErrNotFound:         connect.CodeNotFound,
+	ErrAlreadyExists:    connect.CodeAlreadyExists,
+	ErrPermissionDenied: connect.CodePermissionDenied,
+	ErrUnauthenticated:  connect.CodeUnauthenticated,
+	ErrInvalidArgument:  connect.CodeInvalidArgument,
+	ErrRateLimited:      connect.CodeResourceExhausted,
+	ErrUnavailable:      connect.CodeUnavailable,
+}
+
+// Error is an error returned by the Operand API, with the details the server
+// attached to it (in the style of google.rpc error details) extracted. RPCs made
+// with the client return it in place of the underlying *connect.Error, which it
+// wraps, so that errors.As and connect.CodeOf still work. Uploads and downloads
+// which fail with an unexpected HTTP status return it too, with the status mapped
+// to a code.
+//
+// Errors match the sentinel error of their code (e.g. ErrNotFound) with errors.Is.
+type Error struct {
+	Code    connect.Code
+	Message string
+	// HTTPStatus is the status of a failed upload or download. It's zero for RPCs.
+	HTTPStatus int
+	// RequestID is the ID the API assigned to the request (see HeaderRequestID), if
+	// reported. Include it in support tickets.
+	RequestID string
+	// Reason, Domain and Metadata are taken from a google.rpc.ErrorInfo detail.
+	Reason   string
+	Domain   string
+	Metadata map[string]string
+	// FieldViolations are taken from a google.rpc.BadRequest detail, and describe
+	// which fields (e.g. properties) of the request are invalid.
+	FieldViolations []FieldViolation
+	// RetryDelay is taken from a google.rpc.RetryInfo detail.
+	RetryDelay time.Duration
+	// LocalizedMessage is taken from a google.rpc.LocalizedMessage detail.
+	LocalizedMessage string
+	// Details holds the values of details which were extracted by parsers
+	// registered with WithErrorDetailParser.
+	Details []any
+	// RateLimit is taken from the rate-limit headers of the response, if any.
+	RateLimit *RateLimit
+
+	err *connect.Error
+}
+
+// FieldViolation describes an invalid field of a request.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// Error returns the code and message, along with any field violations and the
+// request ID.
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Code.String())
+	if e.Message != "" {
+		b.WriteString(": ")
+		b.WriteString(e.Message)
+	}
+	for i, v := range e.FieldViolations {
+		if i == 0 {
+			b.WriteString(" (")
+		} else {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "%s: %s", v.Field, v.Description)
+		if i == len(e.FieldViolations)-1 {
+			b.WriteString(")")
+		}
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " [request ID %s]", e.RequestID)
+	}
+	return b.String()
+}
+
+// Is reports whether the error matches a sentinel error.
+func (e *Error) Is(target error) bool {
+	code, ok := sentinelCodes[target]
+	if !ok {
+		return false
+	}
+	if target == ErrRateLimited && e.HTTPStatus == http.StatusTooManyRequests {
+		return true
+	}
+	return e.Code == code
+}
+
+// Unwrap returns the underlying *connect.Error, if the error was returned by an RPC.
+func (e *Error) Unwrap() error {
+	if e.err == nil {
+		return nil
+	}
+	return e.err
+}
+
+// ErrorDetailParser parses the serialized value of an error detail of a given type
+// into a value, which is added to Error.Details.
+type ErrorDetailParser func(value []byte) (any, error)
+
+// WithErrorDetailParser registers a parser for error details of the given type
+// (its fully-qualified protobuf name, e.g. "acme.v1.QuotaDetail"), e.g. for
+// details specific to a deployment. Details which can't be parsed are ignored.
+func WithErrorDetailParser(typeName string, parse ErrorDetailParser) Option {
+	return func(c *Client) {
+		if c.errorDetailParsers == nil {
+			c.errorDetailParsers = make(map[string]ErrorDetailParser)
+		}
+		c.errorDetailParsers[typeName] = parse
+	}
+}
+
+// apiError converts a connect error into an *Error.
+func (c *Client) apiError(err error) error {
+	var ce *connect.Error
+	if err == nil || !errors.As(err, &ce) {
+		return err
+	}
+	var existing *Error
+	if errors.As(err, &existing) {
+		return err
+	}
+	e := &Error{Code: ce.Code(), Message: ce.Message(), err: ce}
+	e.RateLimit = parseRateLimit(ce.Meta(), c.clock.Now())
+	e.RequestID = ce.Meta().Get(HeaderRequestID)
+	for _, d := range ce.Details() {
+		switch typeName := d.Type(); typeName {
+		case "google.rpc.ErrorInfo":
+			parseErrorInfo(e, d.Bytes())
+		case "google.rpc.BadRequest":
+			parseBadRequest(e, d.Bytes())
+		case "google.rpc.RetryInfo":
+			parseRetryInfo(e, d.Bytes())
+		case "google.rpc.LocalizedMessage":
+			eachField(d.Bytes(), func(num protowire.Number, v []byte) {
+				if num == 2 {
+					e.LocalizedMessage = string(v)
+				}
+			})
+		default:
+			if parse, ok := c.errorDetailParsers[typeName]; ok {
+				if v, err := parse(d.Bytes()); err == nil {
+					e.Details = append(e.Details, v)
+				}
+			}
+		}
+	}
+	return e
+}
+
+// eachField calls fn with the number and value of each length-delimited field of
+// a serialized message. Other fields are skipped, as are malformed messages.
+func eachField(b []byte, fn func(num protowire.Number, v []byte)) {
+	eachValue(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) {
+		if typ == protowire.BytesType {
+			fn(num, v)
+		}
+	})
+}
+
+// eachValue calls fn with each field of a serialized message: with its bytes if
+// it's length-delimited, or its value if it's a varint.
+func eachValue(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64)) {
+	for len(b) > 0 {
+		num, typ, tagLen := protowire.ConsumeTag(b)
+		if tagLen < 0 {
+			return
+		}
+		b = b[tagLen:]
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return
+			}
+			fn(num, typ, v, 0)
+			b = b[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return
+			}
+			fn(num, typ, nil, v)
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return
+			}
+			b = b[n:]
+		}
+	}
+}
+
+// parseErrorInfo parses a google.rpc.ErrorInfo: reason (1), domain (2) and
+// metadata (3, a map<string, string>).
+func parseErrorInfo(e *Error, b []byte) {
+	eachField(b, func(num protowire.Number, v []byte) {
+		switch num {
+		case 1:
+			e.Reason = string(v)
+		case 2:
+			e.Domain = string(v)
+		case 3:
+			var key, value string
+			eachField(v, func(num protowire.Number, v []byte) {
+				switch num {
+				case 1:
+					key = string(v)
+				case 2:
+					value = string(v)
+				}
+			})
+			if e.Metadata == nil {
+				e.Metadata = make(map[string]string)
+			}
+			e.Metadata[key] = value
+		}
+	})
+}
+
+// parseBadRequest parses a google.rpc.BadRequest: field_violations (1), each with
+// a field (1) and description (2).
+func parseBadRequest(e *Error, b []byte) {
+	eachField(b, func(num protowire.Number, v []byte) {
+		if num != 1 {
+			return
+		}
+		var fv FieldViolation
+		eachField(v, func(num protowire.Number, v []byte) {
+			switch num {
+			case 1:
+				fv.Field = string(v)
+			case 2:
+				fv.Description = string(v)
+			}
+		})
+		e.FieldViolations = append(e.FieldViolations, fv)
+	})
+}
+
+// parseRetryInfo parses a google.rpc.RetryInfo: retry_delay (1), a
+// google.protobuf.Duration of seconds (1) and nanos (2).
+func parseRetryInfo(e *Error, b []byte) {
+	eachField(b, func(num protowire.Number, v []byte) {
+		if num != 1 {
+			return
+		}
+		eachValue(v, func(num protowire.Number, typ protowire.Type, _ []byte, n uint64) {
+			if typ != protowire.VarintType {
+				return
+			}
+			switch num {
+			case 1:
+				e.RetryDelay += time.Duration(int64(n)) * time.Second
+			case 2:
+				e.RetryDelay += time.Duration(int32(n))
+			}
+		})
+	})
+}
+
+// httpError returns the error of an upload or download which failed with an
+// unexpected status code.
+func (c *Client) httpError(resp *http.Response, body []byte) *Error {
+	return &Error{
+		Code:       httpStatusCode(resp.StatusCode),
+		Message:    fmt.Sprintf("unexpected status code %d: %s", resp.StatusCode, body),
+		HTTPStatus: resp.StatusCode,
+		RequestID:  resp.Header.Get(HeaderRequestID),
+		RateLimit:  parseRateLimit(resp.Header, c.clock.Now()),
+	}
+}
+
+// errorCode returns the code of an error from an RPC, upload or download. Failures
+// to reach the API are considered unavailable.
+func errorCode(err error) connect.Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	if errors.Is(err, context.Canceled) {
+		return connect.CodeCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return connect.CodeDeadlineExceeded
+	}
+	var ne net.Error
+	if errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return connect.CodeUnavailable
+	}
+	return connect.CodeOf(err)
+}
+
+// httpStatusCode maps the status code of an HTTP response to the code of an RPC
+// which failed with it.
+func httpStatusCode(status int) connect.Code {
+	switch status {
+	case http.StatusBadRequest:
+		return connect.CodeInvalidArgument
+	case http.StatusUnauthorized:
+		return connect.CodeUnauthenticated
+	case http.StatusForbidden:
+		return connect.CodePermissionDenied
+	case http.StatusNotFound:
+		return connect.CodeNotFound
+	case http.StatusConflict:
+		return connect.CodeAlreadyExists
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return connect.CodeResourceExhausted
+	case http.StatusNotImplemented, http.StatusNotAcceptable:
+		return connect.CodeUnimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return connect.CodeUnavailable
+	case http.StatusInternalServerError:
+		return connect.CodeInternal
+	}
+	return connect.CodeUnknown
+}
+
+// errorInterceptor converts the errors of RPCs into *Error.
+type errorInterceptor struct {
+	c *Client
+}
+
+var _ connect.Interceptor = errorInterceptor{}
+
+func (ei errorInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		resp, err := next(ctx, req)
+		return resp, ei.c.apiError(err)
+	}
+}
+
+func (ei errorInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		return &errorConn{StreamingClientConn: next(ctx, spec), c: ei.c}
+	}
+}
+
+func (errorInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
+
+// errorConn converts the errors of a stream into *Error.
+type errorConn struct {
+	connect.StreamingClientConn
+	c *Client
+}
+
+func (ec *errorConn) Send(msg any) error {
+	return ec.c.apiError(ec.StreamingClientConn.Send(msg))
+}
+
+func (ec *errorConn) Receive(msg any) error {
+	return ec.c.apiError(ec.StreamingClientConn.Receive(msg))
+}
+
+func (ec *errorConn) CloseResponse() error {
+	return ec.c.apiError(ec.StreamingClientConn.CloseResponse())
+}
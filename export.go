@@ -0,0 +1,188 @@
+package operand
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ExportManifestName is the name of the manifest written by ExportSearch.
+const ExportManifestName = "manifest.json"
+
+// ExportTarget is a destination for exported files, e.g. a local directory or an archive.
+type ExportTarget interface {
+	// Create creates a file with the given slash-separated name within the target.
+	Create(ctx context.Context, name string) (io.WriteCloser, error)
+}
+
+// DirTarget is an ExportTarget that writes files to a local directory.
+type DirTarget string
+
+// Create creates the file in the directory, along with any missing parent directories.
+func (d DirTarget) Create(_ context.Context, name string) (io.WriteCloser, error) {
+	p := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(p)
+}
+
+// ZipTarget is an ExportTarget that writes files to a zip archive.
+// Close must be called once the export is complete.
+type ZipTarget struct {
+	zw *zip.Writer
+}
+
+// NewZipTarget returns a new ZipTarget that writes the archive to w.
+func NewZipTarget(w io.Writer) *ZipTarget {
+	return &ZipTarget{zw: zip.NewWriter(w)}
+}
+
+// Create adds a file to the archive. Only one file may be written at a time.
+func (z *ZipTarget) Create(_ context.Context, name string) (io.WriteCloser, error) {
+	w, err := z.zw.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return nopWriteCloser{w}, nil
+}
+
+// Close finishes writing the archive. It does not close the underlying writer.
+func (z *ZipTarget) Close() error {
+	return z.zw.Close()
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// ExportManifest describes the results of an export, in relevance order.
+type ExportManifest struct {
+	Query      string         `json:"query"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Files      []ExportedFile `json:"files"`
+}
+
+// ExportedFile is a single file within an export.
+type ExportedFile struct {
+	Rank     int             `json:"rank"`
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Path     string          `json:"path"` // Path of the content within the export.
+	Score    float32         `json:"score"`
+	Snippets []string        `json:"snippets"`
+	Metadata json.RawMessage `json:"metadata"` // The file, encoded with protojson.
+}
+
+// ExportSearch runs a query, and exports the content and metadata of each matching
+// file to the target, along with a manifest (see ExportManifestName) listing the
+// files in relevance order. Files with several matches are exported once, at the
+// rank of their best match.
+func (c *Client) ExportSearch(
+	ctx context.Context,
+	query string,
+	target ExportTarget,
+	opts ...SearchOption,
+) (*ExportManifest, error) {
+	resp, err := c.Search(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &ExportManifest{
+		Query:      query,
+		ExportedAt: c.clock.Now().UTC(),
+	}
+	index := make(map[string]int) // File ID -> index into manifest.Files.
+	for _, m := range resp.Matches {
+		if i, ok := index[m.FileId]; ok {
+			manifest.Files[i].Snippets = append(manifest.Files[i].Snippets, m.Snippet)
+			continue
+		}
+		file, ok := resp.Files[m.FileId]
+		if !ok {
+			continue
+		}
+		metadata, err := protojson.Marshal(file)
+		if err != nil {
+			return nil, err
+		}
+		rank := len(manifest.Files) + 1
+		index[m.FileId] = len(manifest.Files)
+		manifest.Files = append(manifest.Files, ExportedFile{
+			Rank:     rank,
+			ID:       file.Id,
+			Name:     OriginalName(file),
+			Path:     path.Join("files", fmt.Sprintf("%04d-%s", rank, exportName(OriginalName(file)))),
+			Score:    m.Score,
+			Snippets: []string{m.Snippet},
+			Metadata: metadata,
+		})
+	}
+
+	for _, ef := range manifest.Files {
+		if err := c.exportFile(ctx, target, ef.Path, resp.Files[ef.ID].DownloadUrl); err != nil {
+			return nil, fmt.Errorf("failed to export file %s: %w", ef.ID, err)
+		}
+	}
+
+	w, err := target.Create(ctx, ExportManifestName)
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func (c *Client) exportFile(ctx context.Context, target ExportTarget, name, downloadURL string) error {
+	resp, err := c.download(ctx, downloadURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	w, err := target.Create(ctx, name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// exportName makes a file name safe to use as a single path element.
+func exportName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', 0:
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+	return name
+}
@@ -0,0 +1,135 @@
+package operand
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+// SearchSource is a single scope queried by FederatedSearch.
+type SearchSource struct {
+	// Name identifies the source in the merged results.
+	Name string
+	// Client is used to query the source. If nil, the client on which FederatedSearch
+	// is called is used. Passing clients with different API keys allows a query to be
+	// federated across multiple tenants.
+	Client *Client
+	// ParentID restricts the source to a directory. If nil, all files are searched.
+	ParentID *string
+}
+
+// FederatedMatch is a match returned by FederatedSearch, attributed to its source.
+type FederatedMatch struct {
+	Source string
+	Match  *operandv1.ContentMatch
+	File   *filev1.File
+	// Score is the score of the match, normalized to [0, 1] within its source so
+	// that matches from different sources are comparable.
+	Score float32
+}
+
+// FederatedSearch runs a query against multiple sources concurrently, and merges the
+// results into a single ranked list. The search options are applied to every source,
+// and the maximum number of results (if set) applies to both the individual sources
+// and the merged list. If any source fails, the first error is returned.
+func (c *Client) FederatedSearch(
+	ctx context.Context,
+	query string,
+	sources []SearchSource,
+	opts ...SearchOption,
+) ([]*FederatedMatch, error) {
+	o := new(searchOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		merged   []*FederatedMatch
+		firstErr error
+	)
+	for _, source := range sources {
+		wg.Add(1)
+		go func(source SearchSource) {
+			defer wg.Done()
+
+			client := source.Client
+			if client == nil {
+				client = c
+			}
+			sourceOpts := opts
+			if source.ParentID != nil {
+				sourceOpts = append(sourceOpts[:len(sourceOpts):len(sourceOpts)], WithParent(*source.ParentID))
+			}
+
+			resp, err := client.Search(ctx, query, sourceOpts...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			merged = append(merged, normalizeMatches(source.Name, resp)...)
+		}(source)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Score != merged[j].Score {
+			return merged[i].Score > merged[j].Score
+		}
+		return merged[i].Match.Score > merged[j].Match.Score
+	})
+	if o.maxResults > 0 && len(merged) > int(o.maxResults) {
+		merged = merged[:o.maxResults]
+	}
+
+	return merged, nil
+}
+
+// normalizeMatches min-max normalizes the scores of a single source's matches.
+func normalizeMatches(source string, resp *operandv1.SearchResponse) []*FederatedMatch {
+	if len(resp.Matches) == 0 {
+		return nil
+	}
+
+	lo, hi := resp.Matches[0].Score, resp.Matches[0].Score
+	for _, m := range resp.Matches[1:] {
+		if m.Score < lo {
+			lo = m.Score
+		}
+		if m.Score > hi {
+			hi = m.Score
+		}
+	}
+
+	matches := make([]*FederatedMatch, 0, len(resp.Matches))
+	for _, m := range resp.Matches {
+		score := float32(1)
+		if hi > lo {
+			score = (m.Score - lo) / (hi - lo)
+		}
+		matches = append(matches, &FederatedMatch{
+			Source: source,
+			Match:  m,
+			File:   resp.Files[m.FileId],
+			Score:  score,
+		})
+	}
+	return matches
+}
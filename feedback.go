@@ -0,0 +1,108 @@
+package operand
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// FeedbackSignal is a signal of the relevance of a search result to its query.
+type FeedbackSignal string
+
+// The signals of relevance.
+const (
+	FeedbackClick  FeedbackSignal = "click"  // The result was opened.
+	FeedbackAccept FeedbackSignal = "accept" // The result answered the query.
+	FeedbackReject FeedbackSignal = "reject" // The result was marked irrelevant.
+)
+
+// ErrNoFeedbackSink is returned by SubmitFeedback when the client has no feedback
+// sink.
+var ErrNoFeedbackSink = errors.New("no feedback sink")
+
+// Feedback is a signal of the relevance of a search result, as submitted with
+// SubmitFeedback.
+type Feedback struct {
+	Time time.Time `json:"time"`
+	// QueryID is the operation ID of the search (see SearchEvent.OperationID and
+	// QueryLogEntry.OperationID), so that feedback can be joined with queries.
+	QueryID string         `json:"query_id"`
+	FileID  string         `json:"file_id"`
+	Signal  FeedbackSignal `json:"signal"`
+	// OperationID is the operation ID of the submission.
+	OperationID string `json:"operation_id"`
+}
+
+// FeedbackSink records feedback on search results. The API has no endpoint for
+// feedback, so it's up to the sink to deliver it, e.g. to an analytics pipeline.
+type FeedbackSink interface {
+	RecordFeedback(ctx context.Context, f *Feedback) error
+}
+
+// FeedbackSinkFunc is an adapter to allow the use of ordinary functions as
+// feedback sinks.
+type FeedbackSinkFunc func(ctx context.Context, f *Feedback) error
+
+// RecordFeedback calls f(ctx, fb).
+func (f FeedbackSinkFunc) RecordFeedback(ctx context.Context, fb *Feedback) error {
+	return f(ctx, fb)
+}
+
+// WithFeedbackSink sets the sink which SubmitFeedback records feedback to.
+func WithFeedbackSink(s FeedbackSink) Option {
+	return func(c *Client) { c.feedback = s }
+}
+
+// SubmitFeedback reports a signal of the relevance of a search result to its
+// query, identified by the operation ID of the search (e.g. set with
+// WithOperationID before searching), to close the loop on search quality. It's recorded to the client's feedback sink (see
+// WithFeedbackSink), as the API has no endpoint for feedback.
+func (c *Client) SubmitFeedback(ctx context.Context, queryID, fileID string, signal FeedbackSignal) error {
+	if c.feedback == nil {
+		return ErrNoFeedbackSink
+	}
+	switch {
+	case queryID == "":
+		return errors.New("query ID is required")
+	case fileID == "":
+		return errors.New("file ID is required")
+	}
+	switch signal {
+	case FeedbackClick, FeedbackAccept, FeedbackReject:
+	default:
+		return fmt.Errorf("unknown feedback signal %q", signal)
+	}
+	ctx = c.withOperation(ctx)
+	return c.feedback.RecordFeedback(ctx, &Feedback{
+		Time:        c.clock.Now().UTC(),
+		QueryID:     queryID,
+		FileID:      fileID,
+		Signal:      signal,
+		OperationID: OperationID(ctx),
+	})
+}
+
+// FeedbackLog is a feedback sink which writes feedback as JSON lines, e.g. to be
+// joined with a QueryLog for relevance analysis.
+type FeedbackLog struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+var _ FeedbackSink = (*FeedbackLog)(nil)
+
+// NewFeedbackLog returns a FeedbackLog which writes to w.
+func NewFeedbackLog(w io.Writer) *FeedbackLog {
+	return &FeedbackLog{enc: json.NewEncoder(w)}
+}
+
+// RecordFeedback writes the feedback to the log.
+func (l *FeedbackLog) RecordFeedback(_ context.Context, f *Feedback) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc.Encode(f)
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package operand
+
+import "io/fs"
+
+// fileKey identifies a file on disk, regardless of its path.
+type fileKey struct{}
+
+// keyOf returns false, as files can't be identified on this platform.
+func keyOf(info fs.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
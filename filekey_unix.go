@@ -0,0 +1,22 @@
+//go:build unix
+
+package operand
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileKey identifies a file on disk, regardless of its path.
+type fileKey struct {
+	dev, ino uint64
+}
+
+// keyOf returns the key of a file.
+func keyOf(info fs.FileInfo) (fileKey, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
@@ -0,0 +1,127 @@
+package operand
+
+import (
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+// MatchesFilter reports whether a set of properties satisfies a search filter,
+// evaluated client-side. All top-level conditions must hold. Property conditions
+// match if the values are equal, or if either side is an array and the two share
+// at least one value. Range conditions match if any numeric value is in range.
+func MatchesFilter(filter *operandv1.Filter, properties *filev1.Properties) bool {
+	for _, cond := range filter.GetConditions() {
+		if !matchesCondition(cond, properties) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesCondition(cond *operandv1.Condition, properties *filev1.Properties) bool {
+	switch c := cond.GetCondition().(type) {
+	case *operandv1.Condition_Property:
+		have := properties.GetProperties()[c.Property.GetKey()]
+		return propertyMatches(have, c.Property.GetProperty())
+	case *operandv1.Condition_Range:
+		have := properties.GetProperties()[c.Range.GetKey()]
+		for _, v := range numberValues(have) {
+			if inRange(v, c.Range) {
+				return true
+			}
+		}
+		return false
+	case *operandv1.Condition_And:
+		return MatchesFilter(c.And, properties)
+	case *operandv1.Condition_Or:
+		for _, sub := range c.Or.GetConditions() {
+			if matchesCondition(sub, properties) {
+				return true
+			}
+		}
+		return false
+	case *operandv1.Condition_Not:
+		return !matchesCondition(c.Not.GetCondition(), properties)
+	default:
+		return false
+	}
+}
+
+func propertyMatches(have, want *filev1.Property) bool {
+	if have == nil || want == nil {
+		return false
+	}
+	switch want.GetValue().(type) {
+	case *filev1.Property_Text, *filev1.Property_TextArray:
+		return overlaps(textValues(have), textValues(want))
+	case *filev1.Property_Number, *filev1.Property_NumberArray:
+		return overlaps(numberValues(have), numberValues(want))
+	default:
+		return false
+	}
+}
+
+func textValues(p *filev1.Property) []string {
+	switch v := p.GetValue().(type) {
+	case *filev1.Property_Text:
+		return []string{v.Text}
+	case *filev1.Property_TextArray:
+		return v.TextArray.GetValues()
+	default:
+		return nil
+	}
+}
+
+func numberValues(p *filev1.Property) []float64 {
+	switch v := p.GetValue().(type) {
+	case *filev1.Property_Number:
+		return []float64{v.Number}
+	case *filev1.Property_NumberArray:
+		return v.NumberArray.GetValues()
+	default:
+		return nil
+	}
+}
+
+func overlaps[T comparable](a, b []T) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func inRange(v float64, r *operandv1.Range) bool {
+	if r.Lt != nil && !(v < *r.Lt) {
+		return false
+	}
+	if r.Lte != nil && !(v <= *r.Lte) {
+		return false
+	}
+	if r.Gt != nil && !(v > *r.Gt) {
+		return false
+	}
+	if r.Gte != nil && !(v >= *r.Gte) {
+		return false
+	}
+	return true
+}
+
+// andFilters combines two filters such that both must hold. Either may be nil.
+func andFilters(a, b *operandv1.Filter) *operandv1.Filter {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return &operandv1.Filter{
+		Conditions: []*operandv1.Condition{
+			{Condition: &operandv1.Condition_And{And: a}},
+			{Condition: &operandv1.Condition_And{And: b}},
+		},
+	}
+}
@@ -0,0 +1,201 @@
+package operand
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// StaleReason is the reason a file is considered stale.
+type StaleReason string
+
+const (
+	// StaleOutdated files haven't been updated since FreshnessOptions.UpdatedBefore.
+	StaleOutdated StaleReason = "outdated"
+	// StaleBrokenSource files were fetched from a URL which no longer exists.
+	StaleBrokenSource StaleReason = "broken_source"
+	// StaleNotIndexed files haven't been successfully indexed.
+	StaleNotIndexed StaleReason = "not_indexed"
+)
+
+// FreshnessOptions configures a freshness report.
+type FreshnessOptions struct {
+	// UpdatedBefore, if set, reports files which haven't been updated since.
+	UpdatedBefore time.Time
+	// CheckSources reports files whose source URL now returns 404 (or 410). Source
+	// URLs are taken from the PropertySourceURL property, and from sitemap and RSS
+	// syncs attached to folders.
+	CheckSources bool
+	// Concurrency is the number of source URLs checked concurrently. Defaults to 4.
+	Concurrency int
+}
+
+// StaleFile is a single finding of a freshness report. A file may be reported
+// several times, for different reasons.
+type StaleFile struct {
+	ID        string      `json:"id"`
+	Path      string      `json:"path"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	Reason    StaleReason `json:"reason"`
+	// Detail describes the finding, e.g. the indexing status, or the source URL.
+	Detail string `json:"detail,omitempty"`
+}
+
+// FreshnessReport is a report of the stale files within a tree.
+type FreshnessReport struct {
+	RootID      string      `json:"root_id"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	Scanned     int         `json:"scanned"`
+	Stale       []StaleFile `json:"stale"`
+}
+
+// FreshnessReport scans the tree below the given folder (empty for the root), and
+// reports stale files.
+func (c *Client) FreshnessReport(
+	ctx context.Context,
+	rootID string,
+	opts FreshnessOptions,
+) (*FreshnessReport, error) {
+	report := &FreshnessReport{
+		RootID:      rootID,
+		GeneratedAt: c.clock.Now().UTC(),
+	}
+
+	type source struct {
+		file *filev1.File
+		path string
+		url  string
+	}
+	var sources []source
+	err := c.Walk(ctx, rootID, func(file *filev1.File, path string) error {
+		report.Scanned++
+		updatedAt := file.GetUpdatedAt().AsTime()
+		stale := func(reason StaleReason, detail string) {
+			report.Stale = append(report.Stale, StaleFile{
+				ID:        file.Id,
+				Path:      path,
+				UpdatedAt: updatedAt,
+				Reason:    reason,
+				Detail:    detail,
+			})
+		}
+
+		if !opts.UpdatedBefore.IsZero() && !IsFolder(file) && updatedAt.Before(opts.UpdatedBefore) {
+			stale(StaleOutdated, "")
+		}
+		if !IsFolder(file) && file.IndexingStatus != filev1.IndexingStatus_INDEXING_STATUS_READY {
+			stale(StaleNotIndexed, file.IndexingStatus.String())
+		}
+		if opts.CheckSources {
+			if u := sourceURL(file); u != "" {
+				sources = append(sources, source{file: file, path: path, url: u})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, concurrency)
+	)
+	for _, s := range sources {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(s source) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if !c.sourceGone(ctx, s.url) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Stale = append(report.Stale, StaleFile{
+				ID:        s.file.Id,
+				Path:      s.path,
+				UpdatedAt: s.file.GetUpdatedAt().AsTime(),
+				Reason:    StaleBrokenSource,
+				Detail:    s.url,
+			})
+		}(s)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// sourceURL returns the URL the file was fetched from, if known.
+func sourceURL(file *filev1.File) string {
+	if u, ok := PropertyText(file.GetProperties(), PropertySourceURL); ok {
+		return u
+	}
+	params := file.GetSync().GetParams()
+	if u := params.GetSitemap().GetUrl(); u != "" {
+		return u
+	}
+	return params.GetRss().GetUrl()
+}
+
+// sourceGone reports whether the URL definitively no longer exists. Other failures
+// (e.g. timeouts) aren't reported, since they may be transient.
+func (c *Client) sourceGone(ctx context.Context, rawURL string) bool {
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+		if err != nil {
+			return false
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusNotFound, http.StatusGone:
+			return true
+		case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			continue // Retry with GET.
+		}
+		return false
+	}
+	return false
+}
+
+// WriteJSON writes the report as JSON.
+func (r *FreshnessReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes the stale files of the report as CSV, with a header row.
+func (r *FreshnessReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "path", "updated_at", "reason", "detail"})
+	for _, f := range r.Stale {
+		cw.Write([]string{
+			f.ID,
+			f.Path,
+			f.UpdatedAt.Format(time.RFC3339),
+			string(f.Reason),
+			f.Detail,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
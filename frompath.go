@@ -0,0 +1,70 @@
+package operand
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// sniffLen is the number of bytes of content inspected to detect its type.
+const sniffLen = 512
+
+type contentTypeKey struct{}
+
+// withContentType returns a context with which uploads are sent with the given
+// content type.
+func withContentType(ctx context.Context, contentType string) context.Context {
+	return context.WithValue(ctx, contentTypeKey{}, contentType)
+}
+
+func uploadContentType(ctx context.Context) string {
+	contentType, _ := ctx.Value(contentTypeKey{}).(string)
+	return contentType
+}
+
+// CreateFileFromPath uploads a local file, named after its base name, streaming its
+// content. Its content type is detected from its extension, falling back to
+// sniffing its content (see http.DetectContentType).
+func (c *Client) CreateFileFromPath(
+	ctx context.Context,
+	path string,
+	parent *string,
+	properties *filev1.Properties,
+) (*filev1.CreateFileResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	contentType, err := DetectContentType(path, f)
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateFile(withContentType(ctx, contentType), filepath.Base(path), parent, f, properties)
+}
+
+// DetectContentType returns the MIME type of a file, from the extension of its name,
+// or by sniffing the start of its content, after which it's rewound.
+func DetectContentType(name string, content io.ReadSeeker) (string, error) {
+	if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+		return contentType, nil
+	}
+	start, err := content.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(content, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := content.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
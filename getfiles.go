@@ -0,0 +1,61 @@
+package operand
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// DefaultGetFilesConcurrency is the number of files fetched concurrently by GetFiles.
+const DefaultGetFilesConcurrency = 8
+
+// FileResult is the outcome of fetching a single file with GetFiles.
+type FileResult struct {
+	File *filev1.File
+	Err  error
+}
+
+// GetFiles fetches the files with the given IDs, e.g. to hydrate the results of a
+// search, returning their results keyed by ID. The API has no batch endpoint, so
+// files are fetched concurrently, DefaultGetFilesConcurrency at a time. Failures
+// are reported per ID, rather than failing the whole batch.
+func (c *Client) GetFiles(ctx context.Context, ids []string) map[string]FileResult {
+	return c.getFiles(ctx, ids, nil)
+}
+
+func (c *Client) getFiles(ctx context.Context, ids []string, opts *filev1.ReturnedFileOptions) map[string]FileResult {
+	results := make(map[string]FileResult, len(ids))
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		sem  = make(chan struct{}, DefaultGetFilesConcurrency)
+		seen = make(map[string]bool, len(ids))
+	)
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+				Selector:      &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+				ReturnOptions: opts,
+			}))
+			result := FileResult{Err: err}
+			if err == nil {
+				result.File = resp.Msg.File
+			}
+			mu.Lock()
+			results[id] = result
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+	return results
+}
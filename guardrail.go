@@ -0,0 +1,140 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrQuestionRejected is returned when a guardrail rejects a question, wrapping
+	// the error of the guardrail.
+	ErrQuestionRejected = errors.New("question rejected")
+	// ErrAnswerRejected is returned when a guardrail rejects an answer, wrapping the
+	// error of the guardrail.
+	ErrAnswerRejected = errors.New("answer rejected")
+	// ErrUnverifiedQuote is returned by VerifyQuotes when an answer quotes text which
+	// isn't found in the files relevant to it.
+	ErrUnverifiedQuote = errors.New("quote not found in sources")
+)
+
+// Guardrail checks the questions asked with Ask, AskStream and conversations, and
+// their answers, e.g. to enforce a policy. Either check may be nil.
+type Guardrail struct {
+	// CheckQuestion is called before a question is asked. Returning an error rejects
+	// it, without asking it.
+	CheckQuestion func(ctx context.Context, question string) error
+	// CheckAnswer is called with the complete answer to a question, and the client
+	// which asked it, e.g. to download the files relevant to it. Returning an error
+	// rejects the answer. Streamed answers are checked once complete, after their
+	// parts have been yielded, so the stream's Err reports the rejection.
+	CheckAnswer func(ctx context.Context, c *Client, question string, answer *Answer) error
+}
+
+// WithGuardrails adds guardrails to the questions asked by the client, and their
+// answers. They're checked in order, until one rejects.
+func WithGuardrails(guardrails ...Guardrail) Option {
+	return func(c *Client) { c.guardrails = append(c.guardrails, guardrails...) }
+}
+
+// checkQuestion runs the question checks of the guardrails.
+func (c *Client) checkQuestion(ctx context.Context, question string) error {
+	for _, g := range c.guardrails {
+		if g.CheckQuestion == nil {
+			continue
+		}
+		if err := g.CheckQuestion(ctx, question); err != nil {
+			return fmt.Errorf("%w: %w", ErrQuestionRejected, err)
+		}
+	}
+	return nil
+}
+
+// checkAnswer runs the answer checks of the guardrails.
+func (c *Client) checkAnswer(ctx context.Context, question string, answer *Answer) error {
+	for _, g := range c.guardrails {
+		if g.CheckAnswer == nil {
+			continue
+		}
+		if err := g.CheckAnswer(ctx, c, question, answer); err != nil {
+			return fmt.Errorf("%w: %w", ErrAnswerRejected, err)
+		}
+	}
+	return nil
+}
+
+// MaxQuestionLength returns a guardrail which rejects questions longer than n
+// characters.
+func MaxQuestionLength(n int) Guardrail {
+	return Guardrail{CheckQuestion: func(_ context.Context, question string) error {
+		if l := utf8.RuneCountInString(question); l > n {
+			return fmt.Errorf("%d characters long, over the limit of %d", l, n)
+		}
+		return nil
+	}}
+}
+
+// MinQuoteWords is the number of words from which quoted text of an answer is
+// checked by VerifyQuotes. Shorter quotes are usually terms, rather than quotes of
+// the sources.
+const MinQuoteWords = 4
+
+// quotePattern matches text within straight or curly double quotes.
+var quotePattern = regexp.MustCompile(`"([^"\n]+)"|“([^”\n]+)”`)
+
+// VerifyQuotes is an answer check (see Guardrail) which rejects answers quoting
+// text that isn't found in the files relevant to them, e.g. hallucinated quotes.
+// Quotes of at least MinQuoteWords words are checked, ignoring case and
+// differences of whitespace; the relevant files are downloaded to check them.
+func VerifyQuotes(ctx context.Context, c *Client, _ string, answer *Answer) error {
+	var quotes []string
+	for _, m := range quotePattern.FindAllStringSubmatch(answer.Text, -1) {
+		quote := normalizeQuote(m[1] + m[2])
+		if len(strings.Fields(quote)) >= MinQuoteWords {
+			quotes = append(quotes, quote)
+		}
+	}
+	if len(quotes) == 0 {
+		return nil
+	}
+
+	var sources []string
+	for _, file := range answer.Files {
+		if file.GetDownloadUrl() == "" {
+			continue // A folder.
+		}
+		resp, err := c.download(ctx, file.DownloadUrl)
+		if err != nil {
+			return fmt.Errorf("downloading %s: %w", file.Id, err)
+		}
+		content, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("downloading %s: %w", file.Id, err)
+		}
+		sources = append(sources, normalizeQuote(string(content)))
+	}
+	for _, quote := range quotes {
+		found := false
+		for _, source := range sources {
+			if strings.Contains(source, quote) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %q", ErrUnverifiedQuote, quote)
+		}
+	}
+	return nil
+}
+
+// normalizeQuote lowercases text, and collapses its whitespace, so that quotes
+// match regardless of line wrapping.
+func normalizeQuote(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,197 @@
+package operand
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// OperationStatus is the status of an operation tracked by an OperationHandle.
+type OperationStatus string
+
+const (
+	OperationPending   OperationStatus = "pending"
+	OperationRunning   OperationStatus = "running"
+	OperationSucceeded OperationStatus = "succeeded"
+	OperationFailed    OperationStatus = "failed"
+	OperationCancelled OperationStatus = "cancelled"
+)
+
+// Done reports whether the status is final.
+func (s OperationStatus) Done() bool {
+	return s == OperationSucceeded || s == OperationFailed || s == OperationCancelled
+}
+
+// OperationHandle tracks an operation which runs in the background, e.g. an upload
+// queued with WriteBehind, a ResumableUpload started with Start, or the indexing of
+// a file (see TrackIndexing), and eventually yields a result of type T.
+type OperationHandle[T any] struct {
+	id string
+
+	mu        sync.Mutex
+	status    OperationStatus
+	cancel    func()
+	cancelled bool
+	done      chan struct{}
+	result    T
+	err       error
+}
+
+func newOperationHandle[T any](id string) *OperationHandle[T] {
+	return &OperationHandle[T]{id: id, status: OperationPending, done: make(chan struct{})}
+}
+
+// startOperation runs fn in the background, and returns a handle to it.
+func startOperation[T any](ctx context.Context, id string, fn func(ctx context.Context) (T, error)) *OperationHandle[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	h := newOperationHandle[T](id)
+	h.cancel = cancel
+	h.setStatus(OperationRunning)
+	go func() {
+		defer cancel()
+		h.finish(fn(ctx))
+	}()
+	return h
+}
+
+// ID returns the ID of the operation.
+func (h *OperationHandle[T]) ID() string {
+	return h.id
+}
+
+// Status returns the status of the operation.
+func (h *OperationHandle[T]) Status() OperationStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.status
+}
+
+// Done returns a channel which is closed once the operation has finished.
+func (h *OperationHandle[T]) Done() <-chan struct{} {
+	return h.done
+}
+
+// Result returns the result of the operation, or the error it failed with. It must
+// only be called once Done is closed.
+func (h *OperationHandle[T]) Result() (T, error) {
+	return h.result, h.err
+}
+
+// Await waits for the operation to finish, and returns its result. If the context
+// is done first, the operation carries on; use Cancel to stop it.
+func (h *OperationHandle[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-h.done:
+		return h.result, h.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Cancel asks the operation to stop. It's a no-op if the operation has finished.
+// Await reports context.Canceled for operations which stopped as a result.
+func (h *OperationHandle[T]) Cancel() {
+	h.mu.Lock()
+	if h.status.Done() || h.cancelled {
+		h.mu.Unlock()
+		return
+	}
+	h.cancelled = true
+	cancel := h.cancel
+	h.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (h *OperationHandle[T]) setStatus(status OperationStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.status.Done() {
+		h.status = status
+	}
+}
+
+// setCancel sets the function which stops the operation.
+func (h *OperationHandle[T]) setCancel(cancel func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cancel = cancel
+}
+
+// finish records the result of the operation.
+func (h *OperationHandle[T]) finish(result T, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.status.Done() {
+		return
+	}
+	switch {
+	case h.cancelled && err != nil:
+		h.status, err = OperationCancelled, context.Canceled
+	case err != nil:
+		h.status = OperationFailed
+	default:
+		h.status = OperationSucceeded
+	}
+	h.result, h.err = result, err
+	close(h.done)
+}
+
+// newOperationID returns a new random ID for an operation handle.
+func (c *Client) newOperationID() string {
+	var b [8]byte
+	c.rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// DefaultIndexingPollInterval is the interval at which TrackIndexing polls the
+// status of a file, by default.
+const DefaultIndexingPollInterval = 2 * time.Second
+
+// TrackIndexing tracks the indexing of a file by the API, polling its status at the
+// given interval (DefaultIndexingPollInterval if zero). The operation succeeds with
+// the file once it's ready, and fails if it can't be indexed.
+func (c *Client) TrackIndexing(ctx context.Context, fileID string, interval time.Duration) *OperationHandle[*filev1.File] {
+	if interval <= 0 {
+		interval = DefaultIndexingPollInterval
+	}
+	return startOperation(ctx, fileID, func(ctx context.Context) (*filev1.File, error) {
+		for {
+			resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+				Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: fileID}},
+			}))
+			if err != nil {
+				return nil, err
+			}
+			file := resp.Msg.File
+			switch file.IndexingStatus {
+			case filev1.IndexingStatus_INDEXING_STATUS_READY:
+				return file, nil
+			case filev1.IndexingStatus_INDEXING_STATUS_FAILED, filev1.IndexingStatus_INDEXING_STATUS_UNSUPPORTED:
+				return file, &IndexingError{FileID: fileID, Status: file.IndexingStatus}
+			}
+			select {
+			case <-c.clock.After(interval):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	})
+}
+
+// IndexingError is returned when a file can't be indexed.
+type IndexingError struct {
+	FileID string
+	Status filev1.IndexingStatus
+}
+
+func (e *IndexingError) Error() string {
+	return fmt.Sprintf("file %s couldn't be indexed: %s", e.FileID, e.Status)
+}
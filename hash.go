@@ -0,0 +1,75 @@
+package operand
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// HashAlgorithm is a hash function used to detect changes to content, e.g. by Sync
+// (see PropertyContentHash). Hashes are prefixed by the name of their algorithm,
+// as in "sha256:<hex digest>", so that changing algorithms is detected as a change
+// of content rather than a collision. SHA256 is the default; large syncs may trade
+// cryptographic strength for throughput by plugging in a faster hash, e.g.:
+//
+//	operand.HashAlgorithm{Name: "xxh64", New: func() hash.Hash { return xxhash.New() }}
+type HashAlgorithm struct {
+	// Name prefixes hashes. It must not contain a colon.
+	Name string
+	New  func() hash.Hash
+}
+
+// SHA256 is the default HashAlgorithm.
+var SHA256 = HashAlgorithm{Name: "sha256", New: sha256.New}
+
+// orDefault returns the algorithm, or SHA256 if it's unset.
+func (a HashAlgorithm) orDefault() HashAlgorithm {
+	if a.New == nil {
+		return SHA256
+	}
+	return a
+}
+
+// Sum returns the prefixed hash of the content read from r.
+func (a HashAlgorithm) Sum(r io.Reader) (string, error) {
+	a = a.orDefault()
+	h := a.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return a.Name + ":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SumBytes returns the prefixed hash of content.
+func (a HashAlgorithm) SumBytes(content []byte) string {
+	a = a.orDefault()
+	h := a.New()
+	h.Write(content)
+	return a.Name + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// sumFile returns the prefixed hash of a local file.
+func (a HashAlgorithm) sumFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return a.Sum(f)
+}
+
+// SameContentHash reports whether two content hashes are equal. Hashes without a
+// prefix, as recorded by earlier versions of the SDK, are SHA-256 hashes.
+func SameContentHash(a, b string) bool {
+	return normalizeHash(a) == normalizeHash(b)
+}
+
+func normalizeHash(h string) string {
+	if !strings.Contains(h, ":") {
+		return SHA256.Name + ":" + h
+	}
+	return h
+}
@@ -0,0 +1,165 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// PropertyLegalHold marks a file (or, for a folder, everything within it) as
+// protected from deletion by the SDK. It may hold any value, e.g. the reason for
+// the hold. Since properties can only be set when a file is created, existing files
+// are protected with Client.Hold instead.
+const PropertyLegalHold = "operand_legal_hold"
+
+// ErrProtected is returned when deleting a file which is protected by a legal hold.
+var ErrProtected = errors.New("file is protected by a legal hold")
+
+// HoldStore records the files which are protected from deletion. Holds on folders
+// protect everything within them.
+type HoldStore interface {
+	Held(ctx context.Context, ids []string) (bool, error)
+	Hold(ctx context.Context, id string) error
+	Release(ctx context.Context, id string) error
+}
+
+// MemoryHoldStore is an in-memory HoldStore.
+type MemoryHoldStore struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+var _ HoldStore = (*MemoryHoldStore)(nil)
+
+// NewMemoryHoldStore returns a new MemoryHoldStore holding the given files.
+func NewMemoryHoldStore(ids ...string) *MemoryHoldStore {
+	s := &MemoryHoldStore{ids: make(map[string]bool)}
+	for _, id := range ids {
+		s.ids[id] = true
+	}
+	return s
+}
+
+// Held reports whether any of the files are held.
+func (s *MemoryHoldStore) Held(_ context.Context, ids []string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		if s.ids[id] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Hold places a hold on the file.
+func (s *MemoryHoldStore) Hold(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids[id] = true
+	return nil
+}
+
+// Release releases the hold on the file.
+func (s *MemoryHoldStore) Release(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.ids, id)
+	return nil
+}
+
+// WithHoldStore sets the store used to record legal holds. By default, holds are
+// kept in memory, and are lost when the process exits.
+func WithHoldStore(s HoldStore) Option {
+	return func(c *Client) { c.holds = s }
+}
+
+// Hold protects a file, or a folder and everything within it, from deletion by the SDK.
+func (c *Client) Hold(ctx context.Context, id string) error {
+	return c.holds.Hold(ctx, id)
+}
+
+// Release releases a hold placed with Hold. Holds placed with PropertyLegalHold
+// can't be released.
+func (c *Client) Release(ctx context.Context, id string) error {
+	return c.holds.Release(ctx, id)
+}
+
+type overrideHoldsKey struct{}
+
+// OverrideHolds returns a context with which the SDK deletes files regardless of
+// any legal holds. It should only be used once a hold has been lifted, but can't be
+// released, e.g. because it's set via PropertyLegalHold.
+func OverrideHolds(ctx context.Context) context.Context {
+	return context.WithValue(ctx, overrideHoldsKey{}, true)
+}
+
+// Protected reports whether a file is protected from deletion, either directly or
+// via one of its parents.
+func (c *Client) Protected(ctx context.Context, id string) (bool, error) {
+	resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+		Selector:      &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+		ReturnOptions: &filev1.ReturnedFileOptions{IncludeParents: true},
+	}))
+	if connect.CodeOf(err) == connect.CodeNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return c.protected(ctx, append([]*filev1.File{resp.Msg.File}, resp.Msg.File.Parents...))
+}
+
+// protected reports whether any of the files is protected.
+func (c *Client) protected(ctx context.Context, files []*filev1.File) (bool, error) {
+	ids := make([]string, 0, len(files))
+	for _, f := range files {
+		if _, ok := f.GetProperties().GetProperties()[PropertyLegalHold]; ok {
+			return true, nil
+		}
+		ids = append(ids, f.Id)
+	}
+	return c.holds.Held(ctx, ids)
+}
+
+// checkDeletable returns ErrProtected if the file is protected, unless holds are
+// overridden by the context. The API deletes folders along with everything within
+// them, so a folder is only deletable if nothing within it is protected either.
+func (c *Client) checkDeletable(ctx context.Context, id string) error {
+	if override, _ := ctx.Value(overrideHoldsKey{}).(bool); override {
+		return nil
+	}
+	resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+		Selector:      &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+		ReturnOptions: &filev1.ReturnedFileOptions{IncludeParents: true},
+	}))
+	if connect.CodeOf(err) == connect.CodeNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	file := resp.Msg.File
+	protected, err := c.protected(ctx, append([]*filev1.File{file}, file.Parents...))
+	if err != nil {
+		return err
+	}
+	if protected {
+		return fmt.Errorf("%w: %s", ErrProtected, id)
+	}
+	if !IsFolder(file) {
+		return nil
+	}
+	return c.Walk(ctx, id, func(file *filev1.File, _ string) error {
+		protected, err := c.protected(ctx, []*filev1.File{file})
+		if err != nil {
+			return err
+		}
+		if protected {
+			return fmt.Errorf("%w: %s", ErrProtected, file.Id)
+		}
+		return nil
+	})
+}
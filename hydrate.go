@@ -0,0 +1,144 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+// DefaultMaxHitContent is the maximum number of bytes of content fetched for each
+// hit by SearchHits, by default.
+const DefaultMaxHitContent = 1 << 20
+
+// HydrateOptions configures the hydration of search results (see WithHydration).
+type HydrateOptions struct {
+	// Parents includes the parents of each file.
+	Parents bool
+	// Content fetches the content of each file. It's only used by SearchHits.
+	Content bool
+	// MaxContentSize truncates content to the given number of bytes. If zero,
+	// DefaultMaxHitContent is used.
+	MaxContentSize int64
+}
+
+// WithHydration replaces the files returned by Search with their full records, as
+// returned by GetFile, fetched concurrently. A file which can't be fetched fails
+// the search.
+func WithHydration(opts HydrateOptions) SearchOption {
+	return func(o *searchOptions) { o.hydrate = &opts }
+}
+
+// hydrate replaces the files of a search response with their full records.
+func (c *Client) hydrate(ctx context.Context, resp *operandv1.SearchResponse, opts *HydrateOptions) error {
+	ids := make([]string, 0, len(resp.Files))
+	for id := range resp.Files {
+		ids = append(ids, id)
+	}
+	var returnOptions *filev1.ReturnedFileOptions
+	if opts.Parents {
+		returnOptions = &filev1.ReturnedFileOptions{IncludeParents: true}
+	}
+	for id, result := range c.getFiles(ctx, ids, returnOptions) {
+		if result.Err != nil {
+			return fmt.Errorf("failed to hydrate %s: %w", id, result.Err)
+		}
+		resp.Files[id] = result.File
+	}
+	return nil
+}
+
+// Hit is a search match, along with the file it's within.
+type Hit struct {
+	Match *operandv1.ContentMatch
+	File  *filev1.File
+	// Content is the content of the file, if requested with WithHydration.
+	Content []byte
+	// Truncated reports whether Content was truncated to HydrateOptions.MaxContentSize.
+	Truncated bool
+}
+
+// SearchHits is like Search, but returns the matches along with their files, in
+// order, rather than leaving callers to cross-reference them by file ID. Files are
+// hydrated, and their content fetched, as configured by WithHydration.
+func (c *Client) SearchHits(ctx context.Context, query string, opts ...SearchOption) ([]*Hit, error) {
+	resp, err := c.Search(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+	hits := make([]*Hit, len(resp.Matches))
+	for i, m := range resp.Matches {
+		hits[i] = &Hit{Match: m, File: resp.Files[m.FileId]}
+	}
+
+	o := new(searchOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.hydrate == nil || !o.hydrate.Content {
+		return hits, nil
+	}
+	max := o.hydrate.MaxContentSize
+	if max <= 0 {
+		max = DefaultMaxHitContent
+	}
+	type content struct {
+		data      []byte
+		truncated bool
+		err       error
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		sem      = make(chan struct{}, DefaultGetFilesConcurrency)
+		contents = make(map[string]*content)
+	)
+	for id, file := range resp.Files {
+		if IsFolder(file) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string, file *filev1.File) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			cn := new(content)
+			cn.data, cn.truncated, cn.err = c.readContent(ctx, file, max)
+			mu.Lock()
+			contents[id] = cn
+			mu.Unlock()
+		}(id, file)
+	}
+	wg.Wait()
+	for _, hit := range hits {
+		cn, ok := contents[hit.Match.FileId]
+		if !ok {
+			continue
+		}
+		if cn.err != nil {
+			return nil, fmt.Errorf("failed to fetch content of %s: %w", hit.Match.FileId, cn.err)
+		}
+		hit.Content, hit.Truncated = cn.data, cn.truncated
+	}
+	return hits, nil
+}
+
+// readContent reads up to max bytes of the content of a file.
+func (c *Client) readContent(ctx context.Context, file *filev1.File, max int64) ([]byte, bool, error) {
+	content, _, err := c.openContent(ctx, file, &downloadFileOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	defer content.Close()
+	data, err := io.ReadAll(io.LimitReader(content, max+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(data)) > max {
+		return data[:max], true, nil
+	}
+	return data, false, nil
+}
@@ -0,0 +1,132 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	operand "github.com/operandinc/go-sdk"
+)
+
+// sniffLen is the number of bytes of content inspected to detect its type.
+const sniffLen = 8 << 10
+
+// Admission decides which documents a run accepts. Rejected documents are skipped
+// and reported in the result, rather than failing the run. Tombstones are always
+// admitted.
+type Admission struct {
+	// MaxSize is the maximum size of a document, in bytes. Zero means no limit.
+	MaxSize int
+	// AllowBinary admits documents whose content looks binary (e.g. images or
+	// archives mislabelled as text). They're rejected by default.
+	AllowBinary bool
+	// DenyExtensions are file extensions, such as ".exe", which are rejected.
+	DenyExtensions []string
+	// DenyContentTypes are prefixes of content types, such as "application/zip" or
+	// "image/", which are rejected. The content type is detected from the content
+	// with http.DetectContentType.
+	DenyContentTypes []string
+	// MaxBytesPerSecond, if set, defers documents so that the run uploads no more
+	// than this many bytes of content per second, on average.
+	MaxBytesPerSecond int
+
+	mu   sync.Mutex
+	next time.Time // When the next document may be uploaded.
+}
+
+// SkippedDocument is a document which was rejected by admission control.
+type SkippedDocument struct {
+	ExternalID string
+	Name       string
+	Size       int
+	Reason     string
+}
+
+// rejection is the error returned when a document isn't admitted.
+type rejection struct {
+	reason string
+}
+
+func (e *rejection) Error() string {
+	return "rejected: " + e.reason
+}
+
+// admit returns an error if the document isn't admitted. It's safe to call on a
+// nil Admission, which admits everything.
+func (a *Admission) admit(doc *Document) error {
+	if a == nil || doc.Delete {
+		return nil
+	}
+	if a.MaxSize > 0 && len(doc.Content) > a.MaxSize {
+		return &rejection{fmt.Sprintf("%d bytes exceeds the maximum size of %d bytes", len(doc.Content), a.MaxSize)}
+	}
+	ext := strings.ToLower(path.Ext(doc.Name))
+	for _, deny := range a.DenyExtensions {
+		if ext != "" && strings.EqualFold(ext, deny) {
+			return &rejection{fmt.Sprintf("extension %s is denied", ext)}
+		}
+	}
+	contentType := http.DetectContentType(doc.Content)
+	for _, deny := range a.DenyContentTypes {
+		if strings.HasPrefix(contentType, deny) {
+			return &rejection{fmt.Sprintf("content type %s is denied", contentType)}
+		}
+	}
+	if !a.AllowBinary && looksBinary(doc.Content) {
+		return &rejection{"content looks binary"}
+	}
+	return nil
+}
+
+// wait defers the upload of size bytes of content until it's within the rate limit.
+func (a *Admission) wait(ctx context.Context, clock operand.Clock, size int) error {
+	if a == nil || a.MaxBytesPerSecond <= 0 {
+		return nil
+	}
+	a.mu.Lock()
+	now := clock.Now()
+	if a.next.Before(now) {
+		a.next = now
+	}
+	start := a.next
+	a.next = a.next.Add(time.Duration(size) * time.Second / time.Duration(a.MaxBytesPerSecond))
+	a.mu.Unlock()
+
+	if d := start.Sub(now); d > 0 {
+		select {
+		case <-clock.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// looksBinary reports whether content looks binary: it contains NUL bytes, isn't valid
+// UTF-8, or is more than 10% control characters.
+func looksBinary(content []byte) bool {
+	sniff := content
+	if len(sniff) > sniffLen {
+		sniff = sniff[:sniffLen]
+		// Don't count a rune cut in half as invalid.
+		for i := 0; i < utf8.UTFMax && len(sniff) > 0 && !utf8.Valid(sniff); i++ {
+			sniff = sniff[:len(sniff)-1]
+		}
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(sniff) {
+		return true
+	}
+	control := 0
+	for _, b := range sniff {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' {
+			control++
+		}
+	}
+	return control*10 > len(sniff)
+}
@@ -0,0 +1,188 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	operand "github.com/operandinc/go-sdk"
+	"google.golang.org/protobuf/proto"
+)
+
+// DedupEntry is the state recorded for an ingested document.
+type DedupEntry struct {
+	FileID string `json:"file_id"`
+	Hash   string `json:"hash"`
+}
+
+// DedupStore records the documents which have been ingested, keyed by external ID,
+// so that replayed or redelivered documents with unchanged content can be skipped
+// rather than re-uploaded and re-indexed. Implementations must be safe for
+// concurrent use.
+type DedupStore interface {
+	// Get returns the entry for an external ID, if any.
+	Get(ctx context.Context, externalID string) (*DedupEntry, error)
+	// Put records the entry for an external ID.
+	Put(ctx context.Context, externalID string, entry *DedupEntry) error
+	// Delete removes the entry for an external ID.
+	Delete(ctx context.Context, externalID string) error
+}
+
+// documentHash returns the hash of everything about a document that ends up in
+// Operand, i.e. its name, content and properties.
+func documentHash(doc *Document, alg operand.HashAlgorithm) (string, error) {
+	properties, err := proto.MarshalOptions{Deterministic: true}.Marshal(doc.Properties)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	for _, part := range [][]byte{[]byte(doc.Name), doc.Content, properties} {
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], uint64(len(part)))
+		b.Write(n[:]) // Length-prefix each part so they can't be confused.
+		b.Write(part)
+	}
+	return alg.SumBytes(b.Bytes()), nil
+}
+
+// MemoryDedupStore is an in-memory DedupStore.
+type MemoryDedupStore struct {
+	mu      sync.Mutex
+	entries map[string]DedupEntry
+}
+
+var (
+	_ DedupStore      = (*MemoryDedupStore)(nil)
+	_ operand.Catalog = (*MemoryDedupStore)(nil)
+)
+
+// NewMemoryDedupStore returns a new, empty MemoryDedupStore.
+func NewMemoryDedupStore() *MemoryDedupStore {
+	return &MemoryDedupStore{entries: make(map[string]DedupEntry)}
+}
+
+// Get returns the entry for an external ID, if any.
+func (s *MemoryDedupStore) Get(_ context.Context, externalID string) (*DedupEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[externalID]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Put records the entry for an external ID.
+func (s *MemoryDedupStore) Put(_ context.Context, externalID string, entry *DedupEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[externalID] = *entry
+	return nil
+}
+
+// Delete removes the entry for an external ID.
+func (s *MemoryDedupStore) Delete(_ context.Context, externalID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, externalID)
+	return nil
+}
+
+// Entries returns the file ID recorded for each external ID, so that the store can
+// be checked with operand.Client.CheckConsistency.
+func (s *MemoryDedupStore) Entries(context.Context) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make(map[string]string, len(s.entries))
+	for externalID, entry := range s.entries {
+		entries[externalID] = entry.FileID
+	}
+	return entries, nil
+}
+
+// FileDedupStore is a DedupStore persisted to an append-only log file, so that it
+// survives restarts of the ingesting process.
+type FileDedupStore struct {
+	*MemoryDedupStore
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+var (
+	_ DedupStore      = (*FileDedupStore)(nil)
+	_ operand.Catalog = (*FileDedupStore)(nil)
+)
+
+type dedupLogRecord struct {
+	ExternalID string      `json:"external_id"`
+	Entry      *DedupEntry `json:"entry,omitempty"` // Nil if deleted.
+}
+
+// OpenFileDedupStore opens (or creates) the store at the given path, and loads its
+// contents into memory. Close must be called once the store is no longer needed.
+func OpenFileDedupStore(path string) (*FileDedupStore, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &FileDedupStore{MemoryDedupStore: NewMemoryDedupStore(), f: f}
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var rec dedupLogRecord
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if rec.Entry == nil {
+			delete(s.entries, rec.ExternalID)
+		} else {
+			s.entries[rec.ExternalID] = *rec.Entry
+		}
+	}
+
+	return s, nil
+}
+
+// Put records the entry for an external ID.
+func (s *FileDedupStore) Put(ctx context.Context, externalID string, entry *DedupEntry) error {
+	if err := s.append(&dedupLogRecord{ExternalID: externalID, Entry: entry}); err != nil {
+		return err
+	}
+	return s.MemoryDedupStore.Put(ctx, externalID, entry)
+}
+
+// Delete removes the entry for an external ID.
+func (s *FileDedupStore) Delete(ctx context.Context, externalID string) error {
+	if err := s.append(&dedupLogRecord{ExternalID: externalID}); err != nil {
+		return err
+	}
+	return s.MemoryDedupStore.Delete(ctx, externalID)
+}
+
+// Close closes the underlying file.
+func (s *FileDedupStore) Close() error {
+	return s.f.Close()
+}
+
+func (s *FileDedupStore) append(rec *dedupLogRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.f.Write(append(line, '\n'))
+	return err
+}
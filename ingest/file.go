@@ -0,0 +1,86 @@
+package ingest
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// CSVSource is a source which reads documents from CSV, one per row. The first row
+// must be a header naming the columns, which are referenced by the mapping.
+type CSVSource struct {
+	r       *csv.Reader
+	mapping Mapping
+	columns map[string]int
+	n       int
+}
+
+var _ Source = (*CSVSource)(nil)
+
+// NewCSVSource returns a new CSVSource, reading the header from r.
+func NewCSVSource(r io.Reader, mapping Mapping) (*CSVSource, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, field := range []string{mapping.ContentField, mapping.IDField, mapping.NameField} {
+		if _, ok := columns[field]; field != "" && !ok {
+			return nil, fmt.Errorf("unknown column %q", field)
+		}
+	}
+
+	return &CSVSource{r: cr, mapping: mapping, columns: columns}, nil
+}
+
+// Next reads the next row.
+func (s *CSVSource) Next(context.Context) (*Document, error) {
+	record, err := s.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	s.n++
+	return s.mapping.document(s.n, func(field string) (any, bool) {
+		i, ok := s.columns[field]
+		if !ok || i >= len(record) {
+			return nil, false
+		}
+		return record[i], true
+	})
+}
+
+// JSONLSource is a source which reads documents from newline-delimited JSON, one per
+// object. Fields are referenced by the mapping using their top-level keys.
+type JSONLSource struct {
+	dec     *json.Decoder
+	mapping Mapping
+	n       int
+}
+
+var _ Source = (*JSONLSource)(nil)
+
+// NewJSONLSource returns a new JSONLSource reading from r.
+func NewJSONLSource(r io.Reader, mapping Mapping) *JSONLSource {
+	return &JSONLSource{dec: json.NewDecoder(r), mapping: mapping}
+}
+
+// Next reads the next object.
+func (s *JSONLSource) Next(context.Context) (*Document, error) {
+	var record map[string]any
+	if err := s.dec.Decode(&record); err != nil {
+		return nil, err
+	}
+	s.n++
+	return s.mapping.document(s.n, func(field string) (any, bool) {
+		v, ok := record[field]
+		return v, ok && v != nil
+	})
+}
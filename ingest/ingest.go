@@ -0,0 +1,430 @@
+// Package ingest contains helpers for bulk-loading documents into Operand from
+// external sources, such as CSV files or databases.
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"unicode/utf8"
+
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Document is a single document to be ingested as a file.
+type Document struct {
+	// ExternalID identifies the document within its source system. If set, the
+	// document is upserted, i.e. it replaces any file previously ingested with the
+	// same external ID into the same directory.
+	ExternalID string
+	Name       string
+	Content    []byte
+	Properties *filev1.Properties
+	// Delete marks the document as a tombstone: rather than being uploaded, any
+	// file previously ingested with the same external ID is deleted.
+	Delete bool
+}
+
+// Source produces documents to be ingested. Next returns io.EOF once the source
+// has been exhausted.
+type Source interface {
+	Next(ctx context.Context) (*Document, error)
+}
+
+// Progress is a snapshot of the progress of a run.
+type Progress struct {
+	Processed int // The number of documents processed, successfully or not.
+	Created   int // The number of documents which didn't previously exist.
+	Updated   int // The number of documents which replaced an existing file.
+	Deleted   int // The number of tombstones which deleted an existing file.
+	Unchanged int // The number of documents skipped as they were already ingested.
+	Skipped   int // The number of documents rejected by admission control.
+	Failed    int // The number of documents which couldn't be ingested.
+}
+
+// Result is the result of a run.
+type Result struct {
+	Progress
+	Errors           []*DocumentError
+	SkippedDocuments []*SkippedDocument
+}
+
+// DocumentError is the error returned when a single document couldn't be ingested.
+type DocumentError struct {
+	ExternalID string
+	Name       string
+	Err        error
+}
+
+func (e *DocumentError) Error() string {
+	return fmt.Sprintf("failed to ingest document %q: %v", e.Name, e.Err)
+}
+
+func (e *DocumentError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultConcurrency is the number of documents uploaded concurrently by default.
+const DefaultConcurrency = 4
+
+// Runner ingests documents from a source into a directory.
+type Runner struct {
+	Client *operand.Client
+	// ParentID is the directory documents are ingested into. Empty for the root.
+	ParentID string
+	// Concurrency is the number of documents uploaded concurrently.
+	// If zero, DefaultConcurrency is used.
+	Concurrency int
+	// OnProgress, if set, is called after each document is processed.
+	// Calls are serialized.
+	OnProgress func(Progress)
+	// Dedup, if set, is used to skip documents which have already been ingested
+	// with the same content, e.g. when a source is replayed.
+	Dedup DedupStore
+	// Hash is the algorithm of the hashes recorded in Dedup. Defaults to
+	// operand.SHA256.
+	Hash operand.HashAlgorithm
+	// Summarizer, if set, is used to create a summary sidecar for each document (see
+	// operand.Client.CreateSummary). If summarization fails, the document is still
+	// ingested, but counted as failed.
+	Summarizer operand.Summarizer
+	// Chunked uploads documents split into chunks, with
+	// operand.Client.CreateChunkedFile.
+	Chunked bool
+	// Admission, if set, rejects or defers documents before they're uploaded.
+	Admission *Admission
+	// Templates, if set, generate properties for each document. The path of a
+	// document is its name, and its source URL is taken from
+	// operand.PropertySourceURL.
+	Templates *operand.PropertyTemplates
+	// DetectLanguage sets operand.PropertyLanguage on documents which don't have it,
+	// from the language of their content (see operand.DetectLanguage), so that
+	// searches can be restricted to, or analyzed for, a language.
+	DetectLanguage bool
+
+	mu       sync.Mutex
+	existing map[string]string // External ID -> file ID.
+	result   Result
+}
+
+// Run ingests all of the documents produced by the source. Failures to ingest
+// individual documents don't stop the run, and are reported in the result instead.
+// An error is only returned if the source fails or the context is cancelled.
+func (r *Runner) Run(ctx context.Context, src Source) (*Result, error) {
+	if err := r.index(ctx); err != nil {
+		return nil, err
+	}
+
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	docs := make(chan *Document)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for doc := range docs {
+				outcome, err := r.safeIngest(ctx, doc)
+				r.record(doc, outcome, err)
+			}
+		}()
+	}
+
+	err := feed(ctx, src, docs)
+	close(docs)
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := r.result
+	return &result, err
+}
+
+// feed sends documents from the source to the channel until the source is exhausted.
+func feed(ctx context.Context, src Source, docs chan<- *Document) error {
+	for {
+		doc, err := src.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		select {
+		case docs <- doc:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// index builds the index of previously ingested documents within the directory.
+func (r *Runner) index(ctx context.Context) error {
+	files, err := r.Client.ListFolder(ctx, r.ParentID)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result = Result{}
+	r.existing = make(map[string]string)
+	for _, f := range files {
+		if id, ok := operand.PropertyText(f.Properties, operand.PropertyExternalID); ok {
+			r.existing[id] = f.Id
+		}
+	}
+	return nil
+}
+
+// outcome is the outcome of successfully ingesting a single document.
+type outcome int
+
+const (
+	outcomeCreated outcome = iota
+	outcomeUpdated
+	outcomeDeleted
+	outcomeUnchanged
+)
+
+// safeIngest calls ingest, recovering from any panic so that a single bad document
+// can't take down the whole run.
+func (r *Runner) safeIngest(ctx context.Context, doc *Document) (result outcome, err error) {
+	err = operand.Protect(func() error {
+		result, err = r.ingest(ctx, doc)
+		return err
+	})
+	return result, err
+}
+
+// ingest uploads a single document, and replaces any existing file with the same
+// external ID. Tombstones delete the existing file instead.
+func (r *Runner) ingest(ctx context.Context, doc *Document) (outcome, error) {
+	if doc.Delete {
+		return r.tombstone(ctx, doc)
+	}
+	if err := r.Admission.admit(doc); err != nil {
+		return 0, err
+	}
+
+	var hash string
+	if r.Dedup != nil && doc.ExternalID != "" {
+		var err error
+		if hash, err = documentHash(doc, r.Hash); err != nil {
+			return 0, err
+		}
+		entry, err := r.Dedup.Get(ctx, doc.ExternalID)
+		if err != nil {
+			return 0, err
+		}
+		if entry != nil && entry.Hash == hash {
+			return outcomeUnchanged, nil
+		}
+	}
+
+	if err := r.Admission.wait(ctx, r.Client.Clock(), len(doc.Content)); err != nil {
+		return 0, err
+	}
+	file, err := r.create(ctx, doc)
+	if err != nil {
+		return 0, err
+	}
+	result, err := r.replace(ctx, doc, file, hash)
+	if err != nil {
+		return 0, err
+	}
+	if r.Summarizer != nil {
+		if _, err := r.Client.CreateSummary(ctx, file, doc.Content, r.Summarizer); err != nil {
+			return 0, fmt.Errorf("failed to summarize: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// create uploads the document as a new file (or, if chunked, a folder of chunks).
+func (r *Runner) create(ctx context.Context, doc *Document) (*filev1.File, error) {
+	properties, err := r.properties(doc)
+	if err != nil {
+		return nil, err
+	}
+	parent := r.parent()
+	if r.Chunked {
+		chunked, err := r.Client.CreateChunkedFile(ctx, doc.Name, parent, bytes.NewReader(doc.Content), properties)
+		if err != nil {
+			return nil, err
+		}
+		return chunked.Folder, nil
+	}
+	resp, err := r.Client.CreateFile(ctx, doc.Name, parent, bytes.NewReader(doc.Content), properties)
+	if err != nil {
+		return nil, err
+	}
+	return resp.File, nil
+}
+
+// properties returns the properties of the file created for the document.
+func (r *Runner) properties(doc *Document) (*filev1.Properties, error) {
+	properties := doc.Properties
+	if properties != nil {
+		properties = proto.Clone(properties).(*filev1.Properties)
+	}
+	if doc.ExternalID != "" {
+		properties = operand.SetProperty(
+			properties,
+			operand.PropertyExternalID,
+			operand.TextProperty(doc.ExternalID),
+		)
+	}
+	if _, ok := operand.PropertyText(properties, operand.PropertyLanguage); r.DetectLanguage && !ok {
+		if tag := operand.DetectLanguage(string(languageSample(doc.Content))); tag != "" {
+			properties = operand.SetProperty(properties, operand.PropertyLanguage, operand.TextProperty(tag))
+		}
+	}
+	sourceURL, _ := operand.PropertyText(doc.Properties, operand.PropertySourceURL)
+	return r.Templates.Apply(&operand.TemplateData{
+		Path:       doc.Name,
+		Name:       path.Base(doc.Name),
+		Ext:        path.Ext(doc.Name),
+		Size:       int64(len(doc.Content)),
+		SourceURL:  sourceURL,
+		ExternalID: doc.ExternalID,
+	}, properties)
+}
+
+// languageSample returns the prefix of content inspected to detect its language,
+// cut at a rune boundary.
+func languageSample(content []byte) []byte {
+	const n = 4 << 10
+	if len(content) <= n {
+		return content
+	}
+	content = content[:n]
+	for i := 0; i < utf8.UTFMax && len(content) > 0 && !utf8.Valid(content); i++ {
+		content = content[:len(content)-1]
+	}
+	return content
+}
+
+func (r *Runner) parent() *string {
+	if r.ParentID == "" {
+		return nil
+	}
+	return &r.ParentID
+}
+
+// replace records a newly created file as the current version of the document,
+// deleting the previous version (if any).
+func (r *Runner) replace(ctx context.Context, doc *Document, file *filev1.File, hash string) (outcome, error) {
+	if doc.ExternalID == "" {
+		return outcomeCreated, nil
+	}
+	if r.Dedup != nil {
+		if err := r.Dedup.Put(ctx, doc.ExternalID, &DedupEntry{
+			FileID: file.Id,
+			Hash:   hash,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	// The new file is created before the old one is removed, so that the document
+	// never disappears from search results.
+	r.mu.Lock()
+	previous, ok := r.existing[doc.ExternalID]
+	r.existing[doc.ExternalID] = file.Id
+	r.mu.Unlock()
+	if !ok {
+		return outcomeCreated, nil
+	}
+
+	if err := r.Client.DeleteFile(ctx, previous); err != nil {
+		return 0, fmt.Errorf("failed to delete previous version %s: %w", previous, err)
+	}
+	return outcomeUpdated, nil
+}
+
+// tombstone deletes the file previously ingested with the document's external ID.
+// Tombstones for unknown documents are ignored, so that they can be redelivered.
+func (r *Runner) tombstone(ctx context.Context, doc *Document) (outcome, error) {
+	if doc.ExternalID == "" {
+		return 0, errors.New("tombstone has no external ID")
+	}
+
+	r.mu.Lock()
+	previous, ok := r.existing[doc.ExternalID]
+	delete(r.existing, doc.ExternalID)
+	r.mu.Unlock()
+	if !ok && r.Dedup != nil {
+		entry, err := r.Dedup.Get(ctx, doc.ExternalID)
+		if err != nil {
+			return 0, err
+		}
+		if entry != nil {
+			previous, ok = entry.FileID, true
+		}
+	}
+	if !ok {
+		return outcomeUnchanged, nil
+	}
+
+	if err := r.Client.DeleteFile(ctx, previous); err != nil {
+		// Restore the index entry unless the document has been re-created meanwhile.
+		r.mu.Lock()
+		if _, ok := r.existing[doc.ExternalID]; !ok {
+			r.existing[doc.ExternalID] = previous
+		}
+		r.mu.Unlock()
+		return 0, err
+	}
+	if r.Dedup != nil {
+		if err := r.Dedup.Delete(ctx, doc.ExternalID); err != nil {
+			return 0, err
+		}
+	}
+	return outcomeDeleted, nil
+}
+
+func (r *Runner) record(doc *Document, outcome outcome, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.result.Processed++
+	var rejected *rejection
+	switch {
+	case errors.As(err, &rejected):
+		r.result.Skipped++
+		r.result.SkippedDocuments = append(r.result.SkippedDocuments, &SkippedDocument{
+			ExternalID: doc.ExternalID,
+			Name:       doc.Name,
+			Size:       len(doc.Content),
+			Reason:     rejected.reason,
+		})
+	case err != nil:
+		r.result.Failed++
+		r.result.Errors = append(r.result.Errors, &DocumentError{
+			ExternalID: doc.ExternalID,
+			Name:       doc.Name,
+			Err:        err,
+		})
+	case outcome == outcomeCreated:
+		r.result.Created++
+	case outcome == outcomeUpdated:
+		r.result.Updated++
+	case outcome == outcomeDeleted:
+		r.result.Deleted++
+	case outcome == outcomeUnchanged:
+		r.result.Unchanged++
+	}
+
+	if r.OnProgress != nil {
+		r.OnProgress(r.result.Progress)
+	}
+}
@@ -0,0 +1,43 @@
+// Package kafkastream is a reference implementation of ingest.Stream for Kafka.
+package kafkastream
+
+import (
+	"context"
+
+	"github.com/operandinc/go-sdk/ingest"
+	"github.com/segmentio/kafka-go"
+)
+
+// Stream reads messages from a Kafka consumer group. Offsets are committed through
+// the reader once the consumer has ingested the messages, so the reader must be
+// configured with a GroupID and without automatic commits.
+type Stream struct {
+	reader *kafka.Reader
+	decode ingest.Decoder
+}
+
+var _ ingest.Stream = (*Stream)(nil)
+
+// New returns a new Stream which reads from the given reader.
+func New(reader *kafka.Reader, decode ingest.Decoder) *Stream {
+	return &Stream{reader: reader, decode: decode}
+}
+
+// Fetch fetches the next message, without committing it.
+func (s *Stream) Fetch(ctx context.Context) (*ingest.Message, error) {
+	m, err := s.reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.decode(m.Key, m.Value)
+	return &ingest.Message{Document: doc, Err: err, Ack: m}, nil
+}
+
+// Commit commits the offsets of the messages.
+func (s *Stream) Commit(ctx context.Context, msgs []*ingest.Message) error {
+	kms := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		kms = append(kms, msg.Ack.(kafka.Message))
+	}
+	return s.reader.CommitMessages(ctx, kms...)
+}
@@ -0,0 +1,139 @@
+package ingest
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// Mapping describes how the fields of a record (e.g. the columns of a CSV row) are
+// mapped onto a document.
+type Mapping struct {
+	// ContentField is the field holding the content of the document. Required.
+	ContentField string
+	// IDField is the field holding the external ID of the document, used to upsert.
+	// If empty, documents are always created.
+	IDField string
+	// NameField is the field holding the name of the document. If empty, the
+	// external ID (or failing that, the record number) is used.
+	NameField string
+	// Properties maps fields to the keys of the properties they're stored in.
+	Properties map[string]string
+	// DeleteField is the field marking a record as a tombstone, i.e. a deletion of the
+	// document with its external ID. It's truthy if it's true, "true", or 1.
+	DeleteField string
+}
+
+// DefaultExtension is appended to document names which don't have an extension,
+// so that they're treated as plain text.
+const DefaultExtension = ".txt"
+
+// document builds a document from a record. The record number is 1-based.
+func (m *Mapping) document(n int, get func(field string) (any, bool)) (*Document, error) {
+	if v, ok := get(m.DeleteField); m.DeleteField != "" && ok && truthy(v) {
+		if m.IDField == "" {
+			return nil, fmt.Errorf("record %d: tombstones require an ID field", n)
+		}
+		id, ok := get(m.IDField)
+		if !ok {
+			return nil, fmt.Errorf("record %d: missing ID field %q", n, m.IDField)
+		}
+		return &Document{ExternalID: fmt.Sprint(id), Delete: true}, nil
+	}
+
+	content, ok := get(m.ContentField)
+	if !ok {
+		return nil, fmt.Errorf("record %d: missing content field %q", n, m.ContentField)
+	}
+	doc := &Document{Content: []byte(fmt.Sprint(content))}
+
+	if m.IDField != "" {
+		id, ok := get(m.IDField)
+		if !ok {
+			return nil, fmt.Errorf("record %d: missing ID field %q", n, m.IDField)
+		}
+		doc.ExternalID = fmt.Sprint(id)
+	}
+
+	switch name, ok := get(m.NameField); {
+	case m.NameField != "" && ok:
+		doc.Name = fmt.Sprint(name)
+	case doc.ExternalID != "":
+		doc.Name = doc.ExternalID
+	default:
+		doc.Name = fmt.Sprintf("record-%d", n)
+	}
+	if path.Ext(doc.Name) == "" {
+		doc.Name += DefaultExtension
+	}
+
+	for field, key := range m.Properties {
+		v, ok := get(field)
+		if !ok {
+			continue
+		}
+		if p := toProperty(v); p != nil {
+			doc.Properties = operand.SetProperty(doc.Properties, key, p)
+		}
+	}
+
+	return doc, nil
+}
+
+// toProperty converts a decoded value to a property, returning nil if the value
+// has no property representation.
+func toProperty(v any) *filev1.Property {
+	switch v := v.(type) {
+	case string:
+		return operand.TextProperty(v)
+	case float64:
+		return operand.NumberProperty(v)
+	case int64:
+		return operand.NumberProperty(float64(v))
+	case bool:
+		return operand.TextProperty(fmt.Sprint(v))
+	case []any:
+		var (
+			texts   []string
+			numbers []float64
+		)
+		for _, e := range v {
+			switch e := e.(type) {
+			case string:
+				texts = append(texts, e)
+			case float64:
+				numbers = append(numbers, e)
+			default:
+				return nil
+			}
+		}
+		switch {
+		case len(texts) > 0 && len(numbers) > 0:
+			return nil
+		case len(numbers) > 0:
+			return operand.NumberArrayProperty(numbers...)
+		default:
+			return operand.TextArrayProperty(texts...)
+		}
+	default:
+		return nil
+	}
+}
+
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true") || v == "1"
+	case float64:
+		return v == 1
+	case int64:
+		return v == 1
+	default:
+		return false
+	}
+}
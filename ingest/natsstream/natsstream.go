@@ -0,0 +1,56 @@
+// Package natsstream is a reference implementation of ingest.Stream for NATS JetStream.
+package natsstream
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"github.com/operandinc/go-sdk/ingest"
+)
+
+// DefaultFetchSize is the number of messages requested from the server at a time.
+const DefaultFetchSize = 32
+
+// Stream reads messages from a JetStream pull subscription. Messages are
+// acknowledged once the consumer has ingested them.
+type Stream struct {
+	sub     *nats.Subscription
+	decode  ingest.Decoder
+	pending []*nats.Msg
+}
+
+var _ ingest.Stream = (*Stream)(nil)
+
+// New returns a new Stream which reads from the given pull subscription, e.g. one
+// created with nats.JetStreamContext.PullSubscribe.
+func New(sub *nats.Subscription, decode ingest.Decoder) *Stream {
+	return &Stream{sub: sub, decode: decode}
+}
+
+// Fetch returns the next message, pulling more from the server as required.
+func (s *Stream) Fetch(ctx context.Context) (*ingest.Message, error) {
+	for len(s.pending) == 0 {
+		msgs, err := s.sub.Fetch(DefaultFetchSize, nats.Context(ctx))
+		if err == context.DeadlineExceeded && ctx.Err() == nil {
+			continue // The server had nothing for us, try again.
+		} else if err != nil {
+			return nil, err
+		}
+		s.pending = msgs
+	}
+
+	m := s.pending[0]
+	s.pending = s.pending[1:]
+	doc, err := s.decode([]byte(m.Header.Get(nats.MsgIdHdr)), m.Data)
+	return &ingest.Message{Document: doc, Err: err, Ack: m}, nil
+}
+
+// Commit acknowledges the messages.
+func (s *Stream) Commit(ctx context.Context, msgs []*ingest.Message) error {
+	for _, msg := range msgs {
+		if err := msg.Ack.(*nats.Msg).AckSync(nats.Context(ctx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
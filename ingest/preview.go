@@ -0,0 +1,138 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// Action is what a run would do with a document.
+type Action string
+
+const (
+	ActionCreate    Action = "create"    // The document would be uploaded as a new file.
+	ActionUpdate    Action = "update"    // The document would replace an existing file.
+	ActionDelete    Action = "delete"    // The tombstone would delete an existing file.
+	ActionUnchanged Action = "unchanged" // The document has already been ingested.
+	ActionSkip      Action = "skip"      // The tombstone is for an unknown document.
+	ActionReject    Action = "reject"    // The document would be rejected by admission control.
+)
+
+// PreviewDocument describes how a run would ingest a single document.
+type PreviewDocument struct {
+	ExternalID string
+	Name       string
+	Size       int
+	// Properties are the properties the file would be created with.
+	Properties *filev1.Properties
+	Action     Action
+	// Reason is why the document would be rejected, if it would be.
+	Reason string
+	// ChunkProfile and Chunks describe how the document would be chunked, if the
+	// runner is chunked.
+	ChunkProfile string
+	Chunks       int
+}
+
+// Preview reads up to limit documents from the source (all of them, if limit is
+// zero), and reports how each would be named, chunked and tagged by Run, without
+// modifying the tenant. It's intended for iterating on mappings before running an
+// import for real. Documents which fail to preview are reported as errors, as with
+// Run.
+func (r *Runner) Preview(ctx context.Context, src Source, limit int) ([]*PreviewDocument, error) {
+	files, err := r.Client.ListFolder(ctx, r.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool)
+	for _, f := range files {
+		if id, ok := operand.PropertyText(f.Properties, operand.PropertyExternalID); ok {
+			existing[id] = true
+		}
+	}
+
+	var (
+		previews []*PreviewDocument
+		errs     []error
+	)
+	for limit <= 0 || len(previews)+len(errs) < limit {
+		doc, err := src.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return previews, err
+		}
+		p, err := r.preview(ctx, doc, existing)
+		if err != nil {
+			errs = append(errs, &DocumentError{
+				ExternalID: doc.ExternalID,
+				Name:       doc.Name,
+				Err:        err,
+			})
+			continue
+		}
+		previews = append(previews, p)
+	}
+	return previews, errors.Join(errs...)
+}
+
+// preview describes how a single document would be ingested.
+func (r *Runner) preview(ctx context.Context, doc *Document, existing map[string]bool) (*PreviewDocument, error) {
+	p := &PreviewDocument{
+		ExternalID: doc.ExternalID,
+		Name:       doc.Name,
+		Size:       len(doc.Content),
+	}
+	if doc.Delete {
+		if doc.ExternalID == "" {
+			return nil, errors.New("tombstone has no external ID")
+		}
+		p.Action = ActionSkip
+		if existing[doc.ExternalID] {
+			p.Action = ActionDelete
+		}
+		return p, nil
+	}
+
+	var rejected *rejection
+	if err := r.Admission.admit(doc); errors.As(err, &rejected) {
+		p.Action, p.Reason = ActionReject, rejected.reason
+		return p, nil
+	}
+
+	properties, err := r.properties(doc)
+	if err != nil {
+		return nil, err
+	}
+	p.Properties = properties
+	p.Action = ActionCreate
+	if existing[doc.ExternalID] {
+		p.Action = ActionUpdate
+	}
+	if r.Dedup != nil && doc.ExternalID != "" {
+		hash, err := documentHash(doc, r.Hash)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := r.Dedup.Get(ctx, doc.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil && entry.Hash == hash {
+			p.Action = ActionUnchanged
+		}
+	}
+
+	if r.Chunked {
+		profile, err := r.Client.ChunkProfile(ctx, doc.Name, r.parent(), p.Properties)
+		if err != nil {
+			return nil, err
+		}
+		p.ChunkProfile = profile.Name
+		p.Chunks = len(profile.Split(string(doc.Content)))
+	}
+	return p, nil
+}
@@ -0,0 +1,175 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"text/template"
+	"time"
+
+	operand "github.com/operandinc/go-sdk"
+)
+
+// SQLSource is a source which reads documents from the rows returned by a SQL query.
+// Rows are mapped to documents with templates, which are executed with a map of
+// column names to values.
+type SQLSource struct {
+	DB    *sql.DB
+	Query string
+	Args  []any
+
+	// Content renders the content of the document. Required.
+	Content *template.Template
+	// Name renders the name of the document. If nil, the external ID is used.
+	Name *template.Template
+	// IDColumn is the column holding the external ID of the document. Required.
+	IDColumn string
+	// Properties maps columns to the keys of the properties they're stored in.
+	Properties map[string]string
+
+	// UpdatedAtColumn enables incremental mode. If set, the query is expected to
+	// select only rows updated after its first placeholder argument, which is set to
+	// Since (followed by Args), e.g. "... WHERE updated_at > $1 ORDER BY updated_at".
+	UpdatedAtColumn string
+	// Since is the watermark of the previous run in incremental mode.
+	Since time.Time
+
+	rows      *sql.Rows
+	columns   []string
+	n         int
+	watermark time.Time
+}
+
+var _ Source = (*SQLSource)(nil)
+
+// Next reads the next row, running the query if required.
+func (s *SQLSource) Next(ctx context.Context) (*Document, error) {
+	if s.rows == nil {
+		if err := s.query(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if !s.rows.Next() {
+		err := s.rows.Err()
+		s.Close()
+		if err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	s.n++
+
+	values := make([]any, len(s.columns))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := s.rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	row := make(map[string]any, len(s.columns))
+	for i, column := range s.columns {
+		if b, ok := values[i].([]byte); ok {
+			values[i] = string(b)
+		}
+		row[column] = values[i]
+	}
+
+	return s.document(row)
+}
+
+// Watermark returns the most recent updated_at value seen so far in incremental
+// mode, which should be persisted and passed as Since to the next run. Note that the
+// watermark also advances past rows which failed to ingest, so check the result of
+// the run before persisting it.
+func (s *SQLSource) Watermark() time.Time {
+	if s.watermark.IsZero() {
+		return s.Since
+	}
+	return s.watermark
+}
+
+// Close closes the underlying rows, if the query has been run. It's called
+// automatically once all rows have been read.
+func (s *SQLSource) Close() error {
+	if s.rows == nil {
+		return nil
+	}
+	return s.rows.Close()
+}
+
+func (s *SQLSource) query(ctx context.Context) error {
+	if s.Content == nil || s.IDColumn == "" {
+		return errors.New("content template and ID column are required")
+	}
+
+	args := s.Args
+	if s.UpdatedAtColumn != "" {
+		args = append([]any{s.Since}, args...)
+	}
+	rows, err := s.DB.QueryContext(ctx, s.Query, args...)
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return err
+	}
+
+	s.rows, s.columns = rows, columns
+	return nil
+}
+
+func (s *SQLSource) document(row map[string]any) (*Document, error) {
+	id, ok := row[s.IDColumn]
+	if !ok || id == nil {
+		return nil, fmt.Errorf("row %d: missing ID column %q", s.n, s.IDColumn)
+	}
+	doc := &Document{ExternalID: fmt.Sprint(id)}
+
+	var buf bytes.Buffer
+	if err := s.Content.Execute(&buf, row); err != nil {
+		return nil, fmt.Errorf("row %d: %w", s.n, err)
+	}
+	doc.Content = buf.Bytes()
+
+	doc.Name = doc.ExternalID
+	if s.Name != nil {
+		var name bytes.Buffer
+		if err := s.Name.Execute(&name, row); err != nil {
+			return nil, fmt.Errorf("row %d: %w", s.n, err)
+		}
+		doc.Name = name.String()
+	}
+	if path.Ext(doc.Name) == "" {
+		doc.Name += DefaultExtension
+	}
+
+	for column, key := range s.Properties {
+		v := row[column]
+		if t, ok := v.(time.Time); ok {
+			v = float64(t.Unix())
+		}
+		if p := toProperty(v); p != nil {
+			doc.Properties = operand.SetProperty(doc.Properties, key, p)
+		}
+	}
+
+	if s.UpdatedAtColumn != "" {
+		updatedAt, ok := row[s.UpdatedAtColumn].(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("row %d: column %q is not a timestamp", s.n, s.UpdatedAtColumn)
+		}
+		if updatedAt.After(s.watermark) {
+			s.watermark = updatedAt
+		}
+	}
+
+	return doc, nil
+}
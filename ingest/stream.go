@@ -0,0 +1,255 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	operand "github.com/operandinc/go-sdk"
+)
+
+// Message is a document read from a message stream, along with the state required
+// to acknowledge it.
+type Message struct {
+	Document *Document
+	// Err is set if the message couldn't be decoded into a document.
+	Err error
+	// Ack is opaque state used by the stream to commit the message, e.g. an offset.
+	Ack any
+}
+
+// Stream is a stream of messages, e.g. a Kafka topic or a NATS subject.
+type Stream interface {
+	// Fetch blocks until the next message is available.
+	Fetch(ctx context.Context) (*Message, error)
+	// Commit acknowledges messages once they've been ingested. Messages are passed
+	// in the order they were fetched.
+	Commit(ctx context.Context, msgs []*Message) error
+}
+
+// Decoder decodes the key and value of a message into a document.
+type Decoder func(key, value []byte) (*Document, error)
+
+// JSONDecoder returns a decoder for messages with JSON object values. Fields are
+// referenced by the mapping using their top-level keys. If the mapping has no ID
+// field, the message key is used as the external ID. Messages with a key and an
+// empty value (e.g. Kafka tombstones) delete the document with that key.
+func JSONDecoder(mapping Mapping) Decoder {
+	return func(key, value []byte) (*Document, error) {
+		if len(value) == 0 && len(key) > 0 {
+			return &Document{ExternalID: string(key), Delete: true}, nil
+		}
+		var record map[string]any
+		if err := json.Unmarshal(value, &record); err != nil {
+			return nil, err
+		}
+		doc, err := mapping.document(0, func(field string) (any, bool) {
+			v, ok := record[field]
+			return v, ok && v != nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if mapping.IDField == "" && len(key) > 0 {
+			doc.ExternalID = string(key)
+			if mapping.NameField == "" && !doc.Delete {
+				doc.Name = doc.ExternalID
+				if path.Ext(doc.Name) == "" {
+					doc.Name += DefaultExtension
+				}
+			}
+		}
+		return doc, nil
+	}
+}
+
+// Default values for Consumer.
+const (
+	DefaultBatchSize     = 32
+	DefaultFlushInterval = 5 * time.Second
+)
+
+// Consumer ingests documents from a message stream. Messages are uploaded in
+// batches, and only committed once every message in the batch has been ingested
+// (or handed off to OnFailure). While a batch is being uploaded, at most one more
+// batch is fetched ahead of time, so a slow upload applies backpressure to the stream.
+type Consumer struct {
+	Client *operand.Client
+	// ParentID is the directory documents are ingested into. Empty for the root.
+	ParentID string
+	// BatchSize is the maximum number of messages per batch. If zero,
+	// DefaultBatchSize is used.
+	BatchSize int
+	// FlushInterval is the maximum amount of time a partial batch is held before
+	// being uploaded. If zero, DefaultFlushInterval is used.
+	FlushInterval time.Duration
+	// Concurrency is the number of documents uploaded concurrently within a batch.
+	// If zero, DefaultConcurrency is used.
+	Concurrency int
+	// OnFailure, if set, is called for each message which couldn't be ingested, e.g.
+	// to send it to a dead-letter queue. If it returns nil, the message is committed
+	// along with the rest of its batch. Otherwise, or if OnFailure isn't set, Run
+	// returns without committing the batch, so that it's redelivered.
+	OnFailure func(ctx context.Context, msg *Message, err error) error
+	// Dedup, if set, is used to skip redelivered messages whose documents have
+	// already been ingested with the same content.
+	Dedup DedupStore
+}
+
+// Run consumes the stream until the context is cancelled or an error occurs.
+func (c *Consumer) Run(ctx context.Context, stream Stream) error {
+	runner := &Runner{
+		Client:      c.Client,
+		ParentID:    c.ParentID,
+		Concurrency: c.Concurrency,
+		Dedup:       c.Dedup,
+	}
+	if err := runner.index(ctx); err != nil {
+		return err
+	}
+
+	batchSize := c.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	interval := c.FlushInterval
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs := make(chan *Message, batchSize)
+	fetchErr := make(chan error, 1)
+	go func() {
+		defer close(msgs)
+		for {
+			var msg *Message
+			err := operand.Protect(func() (err error) {
+				msg, err = stream.Fetch(ctx)
+				return err
+			})
+			if err != nil {
+				fetchErr <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-ctx.Done():
+				fetchErr <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	ticker := c.Client.Clock().NewTicker(interval)
+	defer ticker.Stop()
+
+	var batch []*Message
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				err := <-fetchErr
+				if ctx.Err() != nil {
+					// Anything uncommitted will be redelivered.
+					return err
+				}
+				if flushErr := c.flush(ctx, runner, stream, batch); flushErr != nil {
+					return flushErr
+				}
+				return err
+			}
+			batch = append(batch, msg)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C():
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if err := c.flush(ctx, runner, stream, batch); err != nil {
+			return err
+		}
+		batch = nil
+	}
+}
+
+// flush ingests a batch of messages, and commits them if successful.
+func (c *Consumer) flush(ctx context.Context, runner *Runner, stream Stream, batch []*Message) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	// Messages are grouped by document, and the messages of each document are
+	// ingested in the order they were fetched, so that e.g. an upsert followed by a
+	// tombstone leaves the document deleted.
+	var (
+		errs   = make([]error, len(batch))
+		groups [][]int // Indices of the messages of each document.
+		byID   = make(map[string]int)
+	)
+	for i, msg := range batch {
+		if msg.Err != nil {
+			errs[i] = msg.Err
+			continue
+		}
+		id := msg.Document.ExternalID
+		if id == "" {
+			groups = append(groups, []int{i})
+			continue
+		}
+		g, ok := byID[id]
+		if !ok {
+			g = len(groups)
+			byID[id] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for _, group := range groups {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(group []int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			for _, i := range group {
+				_, errs[i] = runner.safeIngest(ctx, batch[i].Document)
+			}
+		}(group)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if c.OnFailure == nil {
+			return err
+		}
+		if err := c.OnFailure(ctx, batch[i], err); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Commit(ctx, batch); err != nil {
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package ingest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	operand "github.com/operandinc/go-sdk"
+	"github.com/operandinc/go-sdk/ingest"
+	"github.com/operandinc/go-sdk/operandtest"
+)
+
+// sliceStream is a stream of a fixed list of messages.
+type sliceStream struct {
+	mu        sync.Mutex
+	msgs      []*ingest.Message
+	committed []*ingest.Message
+}
+
+func (s *sliceStream) Fetch(context.Context) (*ingest.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.msgs) == 0 {
+		return nil, io.EOF
+	}
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+func (s *sliceStream) Commit(_ context.Context, msgs []*ingest.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.committed = append(s.committed, msgs...)
+	return nil
+}
+
+func TestConsumerAppliesMessagesOfADocumentInOrder(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+
+	// Each document is upserted, then deleted, then upserted again if it's even,
+	// all within a single batch.
+	const n = 16
+	stream := &sliceStream{}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprint("doc-", i)
+		upsert := &ingest.Document{ExternalID: id, Name: id + ".txt", Content: []byte(id)}
+		stream.msgs = append(stream.msgs,
+			&ingest.Message{Document: upsert},
+			&ingest.Message{Document: &ingest.Document{ExternalID: id, Delete: true}},
+		)
+		if i%2 == 0 {
+			stream.msgs = append(stream.msgs, &ingest.Message{Document: upsert})
+		}
+	}
+	total := len(stream.msgs)
+
+	consumer := &ingest.Consumer{Client: srv.Client(), BatchSize: total, Concurrency: n}
+	if err := consumer.Run(context.Background(), stream); !errors.Is(err, io.EOF) {
+		t.Fatalf("Run: got %v, want io.EOF", err)
+	}
+	if len(stream.committed) != total {
+		t.Fatalf("committed %d messages, want %d", len(stream.committed), total)
+	}
+
+	remaining := make(map[string]int)
+	for _, file := range srv.Files() {
+		id, _ := operand.PropertyText(file.Properties, operand.PropertyExternalID)
+		remaining[id]++
+	}
+	for i := 0; i < n; i++ {
+		id := fmt.Sprint("doc-", i)
+		want := 0
+		if i%2 == 0 {
+			want = 1
+		}
+		if got := remaining[id]; got != want {
+			t.Errorf("%s: %d files remain, want %d", id, got, want)
+		}
+	}
+}
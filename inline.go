@@ -0,0 +1,81 @@
+package operand
+
+import (
+	"context"
+	"io"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// DefaultInlineThreshold is the size up to which content is created inline by
+// WithInlineUploads, by default.
+const DefaultInlineThreshold = 16 << 10
+
+// WithInlineUploads creates files whose content is at most threshold bytes (or
+// DefaultInlineThreshold if zero) with the CreateFile RPC, in a single message,
+// rather than with a multipart upload, which saves a lot of overhead when creating
+// many small files, e.g. short snippets. Only content of a known size (e.g. a
+// *bytes.Reader, *strings.Reader or regular *os.File) is created inline, and not
+// by CreateFileFromPath, which sends the content type of files, as the RPC can't
+// carry it.
+func WithInlineUploads(threshold int64) Option {
+	return func(c *Client) {
+		if threshold <= 0 {
+			threshold = DefaultInlineThreshold
+		}
+		c.inlineThreshold = threshold
+	}
+}
+
+// inline reports whether a file is created inline.
+func (c *Client) inline(ctx context.Context, data io.Reader) bool {
+	if c.inlineThreshold <= 0 || data == nil || uploadContentType(ctx) != "" {
+		return false
+	}
+	size := readerSize(data)
+	// Empty content isn't created inline, as a request without data creates a folder.
+	return size > 0 && size <= c.inlineThreshold
+}
+
+// createInline creates a file with the CreateFile RPC, sending its content in the
+// same message as its metadata.
+func (c *Client) createInline(
+	ctx context.Context,
+	name string,
+	parent *string,
+	data io.Reader,
+	properties *filev1.Properties,
+) (*filev1.CreateFileResponse, error) {
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return nil, err
+	}
+	meta := &filev1.CreateFileMeta{Name: name, Properties: properties}
+	if parent != nil {
+		meta.ParentId = *parent
+	}
+	req := &filev1.CreateFileRequest{Meta: meta, DataChunk: content}
+	var resp *filev1.CreateFileResponse
+	// Streams aren't retried by the retry interceptor, but the content is in memory.
+	err = c.withRetries(ctx, func() error {
+		stream := c.FileService().CreateFile(ctx)
+		if err := stream.Send(req); err != nil {
+			stream.CloseAndReceive()
+			return err
+		}
+		c.observeUploadBytes(int64(len(content)))
+		r, err := stream.CloseAndReceive()
+		if err != nil {
+			return err
+		}
+		resp = r.Msg
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if fn := uploadProgress(ctx); fn != nil {
+		fn(int64(len(content)), int64(len(content)))
+	}
+	return resp, nil
+}
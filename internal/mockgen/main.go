@@ -0,0 +1,262 @@
+// Command mockgen generates operandmock.API, a mock of operand.API, from the
+// declaration of the interface. It's run by go generate, from the root of the
+// module.
+//
+//	mockgen [-in api.go] [-o operandmock/api.go]
+//
+// Each method of the mock calls the field of the same name with a Func suffix, or
+// panics if it's nil, so that unexpected calls fail tests loudly.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// operandPath is the import path of the operand package.
+	operandPath = "github.com/operandinc/go-sdk"
+	// interfaceName is the name of the mocked interface, and of the mock.
+	interfaceName = "API"
+)
+
+func main() {
+	log.SetFlags(0)
+	in := flag.String("in", "api.go", "file declaring the interface")
+	out := flag.String("o", "operandmock/api.go", "file to write the mock to")
+	flag.Parse()
+
+	src, err := generate(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the source of the mock of the interface declared in a file.
+func generate(path string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	iface, err := findInterface(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	imports := make(map[string]string) // Name -> path.
+	for _, spec := range file.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := p[strings.LastIndex(p, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = p
+	}
+	g := &generator{fset: fset, imports: imports, used: map[string]bool{"operand": true}}
+
+	var fields, methods bytes.Buffer
+	for _, m := range iface.Methods.List {
+		if len(m.Names) == 0 {
+			return nil, fmt.Errorf("%s: embedded interfaces aren't supported", path)
+		}
+		name := m.Names[0].Name
+		typ := g.qualify(m.Type).(*ast.FuncType)
+		args := g.params(typ.Params)
+		fmt.Fprintf(&fields, "\t%sFunc %s\n", name, g.print(typ))
+
+		sig := g.print(typ)
+		call := fmt.Sprintf("m.%sFunc(%s)", name, strings.Join(args, ", "))
+		if typ.Results != nil && len(typ.Results.List) > 0 {
+			call = "return " + call
+		}
+		fmt.Fprintf(&methods, "\n// %s calls %sFunc.\n", name, name)
+		fmt.Fprintf(&methods, "func (m *%s) %s%s {\n", interfaceName, name, strings.TrimPrefix(sig, "func"))
+		fmt.Fprintf(&methods, "\tif m.%sFunc == nil {\n", name)
+		fmt.Fprintf(&methods, "\t\tpanic(\"operandmock: unexpected call to %s\")\n", name)
+		fmt.Fprintf(&methods, "\t}\n")
+		fmt.Fprintf(&methods, "\t%s\n", call)
+		fmt.Fprintf(&methods, "}\n")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by internal/mockgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "// Package operandmock provides a mock of operand.API, for unit tests of code\n")
+	fmt.Fprintf(&buf, "// using the SDK. See operandtest for a fake of the API with real semantics.\n")
+	fmt.Fprintf(&buf, "package operandmock\n\n")
+	fmt.Fprintf(&buf, "import (\n")
+	var used []string
+	for name := range g.used {
+		used = append(used, name)
+	}
+	// Imports of the standard library come first, as goimports groups them.
+	sort.Slice(used, func(i, j int) bool {
+		a, b := importPath(g.imports, used[i]), importPath(g.imports, used[j])
+		if isStd(a) != isStd(b) {
+			return isStd(a)
+		}
+		return a < b
+	})
+	for i, name := range used {
+		p := importPath(g.imports, name)
+		if i > 0 && isStd(importPath(g.imports, used[i-1])) && !isStd(p) {
+			fmt.Fprintf(&buf, "\n")
+		}
+		if name == p[strings.LastIndex(p, "/")+1:] {
+			fmt.Fprintf(&buf, "\t%q\n", p)
+		} else {
+			fmt.Fprintf(&buf, "\t%s %q\n", name, p)
+		}
+	}
+	fmt.Fprintf(&buf, ")\n\n")
+	fmt.Fprintf(&buf, "// %s is a mock of operand.%s. Each method calls the field of the same name with a\n", interfaceName, interfaceName)
+	fmt.Fprintf(&buf, "// Func suffix, or panics if it's nil.\n")
+	fmt.Fprintf(&buf, "type %s struct {\n%s}\n\n", interfaceName, fields.String())
+	fmt.Fprintf(&buf, "var _ operand.%s = (*%s)(nil)\n", interfaceName, interfaceName)
+	buf.Write(methods.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+// findInterface returns the declaration of the mocked interface.
+func findInterface(file *ast.File) (*ast.InterfaceType, error) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if ts.Name.Name != interfaceName {
+				continue
+			}
+			iface, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				return nil, fmt.Errorf("%s isn't an interface", interfaceName)
+			}
+			return iface, nil
+		}
+	}
+	return nil, fmt.Errorf("%s not found", interfaceName)
+}
+
+func importPath(imports map[string]string, name string) string {
+	if name == "operand" {
+		return operandPath
+	}
+	return imports[name]
+}
+
+// isStd reports whether an import path is of the standard library.
+func isStd(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// generator holds the state of the generation of a mock.
+type generator struct {
+	fset    *token.FileSet
+	imports map[string]string // Name -> path, of the file declaring the interface.
+	used    map[string]bool   // Names of the imports used by the mock.
+}
+
+// qualify returns a copy of a type expression of the operand package, with its
+// exported identifiers qualified by the package name, so that it can be used from
+// another package. The packages it refers to are recorded as used.
+func (g *generator) qualify(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case nil:
+		return nil
+	case *ast.Ident:
+		if !ast.IsExported(e.Name) {
+			return ast.NewIdent(e.Name) // Builtin.
+		}
+		return &ast.SelectorExpr{X: ast.NewIdent("operand"), Sel: ast.NewIdent(e.Name)}
+	case *ast.SelectorExpr:
+		pkg := e.X.(*ast.Ident).Name
+		g.used[pkg] = true
+		return &ast.SelectorExpr{X: ast.NewIdent(pkg), Sel: ast.NewIdent(e.Sel.Name)}
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: g.qualify(e.X)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: g.qualify(e.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: g.qualify(e.Key), Value: g.qualify(e.Value)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: e.Dir, Value: g.qualify(e.Value)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: g.qualify(e.Elt)}
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: g.qualify(e.X), Index: g.qualify(e.Index)}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, len(e.Indices))
+		for i, index := range e.Indices {
+			indices[i] = g.qualify(index)
+		}
+		return &ast.IndexListExpr{X: g.qualify(e.X), Indices: indices}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: g.qualifyFields(e.Params), Results: g.qualifyFields(e.Results)}
+	case *ast.InterfaceType:
+		if len(e.Methods.List) > 0 {
+			panic(fmt.Sprintf("mockgen: unsupported interface literal at %s", g.fset.Position(e.Pos())))
+		}
+		return &ast.InterfaceType{Methods: &ast.FieldList{}}
+	default:
+		panic(fmt.Sprintf("mockgen: unsupported type %T at %s", expr, g.fset.Position(expr.Pos())))
+	}
+}
+
+func (g *generator) qualifyFields(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	qualified := &ast.FieldList{}
+	for _, f := range fields.List {
+		var names []*ast.Ident
+		for _, name := range f.Names {
+			names = append(names, ast.NewIdent(name.Name))
+		}
+		qualified.List = append(qualified.List, &ast.Field{Names: names, Type: g.qualify(f.Type)})
+	}
+	return qualified
+}
+
+// params names the unnamed parameters of a method, and returns the arguments
+// passing them on.
+func (g *generator) params(params *ast.FieldList) []string {
+	var args []string
+	for i, f := range params.List {
+		if len(f.Names) == 0 {
+			f.Names = []*ast.Ident{ast.NewIdent("p" + strconv.Itoa(i))}
+		}
+		for _, name := range f.Names {
+			arg := name.Name
+			if _, ok := f.Type.(*ast.Ellipsis); ok {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// print returns the source of a node.
+func (g *generator) print(node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, token.NewFileSet(), node); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,127 @@
+package operand
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// PropertyLanguage holds the language of a file's content, as a BCP 47 language tag
+// (e.g. "de"), e.g. as detected on ingest.
+const PropertyLanguage = "operand_language"
+
+// WithLanguage hints the language of the query to Search, so that the API analyzes
+// it (e.g. stems it) appropriately. It's sent as the locale of the call, overriding
+// any set on the context with WithLocale.
+func WithLanguage(tag string) SearchOption {
+	return func(o *searchOptions) { o.language = tag }
+}
+
+// WithAutoLanguage detects the language of the query with DetectLanguage, unless
+// it's set with WithLanguage or WithLocale.
+func WithAutoLanguage() SearchOption {
+	return func(o *searchOptions) { o.detectLanguage = true }
+}
+
+// searchLanguage returns the context to search with, carrying the query language.
+func (o *searchOptions) searchLanguage(ctx context.Context, query string) context.Context {
+	switch {
+	case o.language != "":
+		return WithLocale(ctx, o.language)
+	case o.detectLanguage && requestContextOf(ctx).locale == "":
+		if tag := DetectLanguage(query); tag != "" {
+			return WithLocale(ctx, tag)
+		}
+	}
+	return ctx
+}
+
+// scriptLanguages are the languages identified by their script alone.
+var scriptLanguages = []struct {
+	script *unicode.RangeTable
+	tag    string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// stopwords are common words of languages written in the Latin script.
+var stopwords = map[string][]string{
+	"en": strings.Fields("the and of to in is for with on that this what how are was"),
+	"de": strings.Fields("der die das und ist nicht mit für auf den von zu ein eine wie was"),
+	"fr": strings.Fields("le la les et est des une pour dans que qui sur pas avec du comment"),
+	"es": strings.Fields("el la los las y es de que en un una para por con del cómo qué"),
+	"it": strings.Fields("il lo la gli le e è di che per con non una del della come"),
+	"pt": strings.Fields("o a os as e é de que em um uma para com não do da como"),
+	"nl": strings.Fields("de het een en is van dat niet op te voor met zijn hoe wat"),
+}
+
+// DetectLanguage guesses the language of a short text, such as a query, returning
+// its BCP 47 language tag, or "" if it can't tell. Languages with their own script
+// are detected by script, and some common languages written in the Latin script by
+// their most frequent words.
+func DetectLanguage(text string) string {
+	counts := make(map[string]int)
+	var letters int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, sl := range scriptLanguages {
+			if unicode.Is(sl.script, r) {
+				counts[sl.tag]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	// Japanese is written with kanji as well as kana.
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+	}
+	if tag, n := most(counts); 2*n > letters {
+		return tag
+	}
+
+	clear(counts)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		for tag, words := range stopwords {
+			for _, w := range words {
+				if w == word {
+					counts[tag]++
+				}
+			}
+		}
+	}
+	if tag, n := most(counts); n > 0 {
+		return tag
+	}
+	return ""
+}
+
+// most returns the key with the highest count, breaking ties by key.
+func most(counts map[string]int) (string, int) {
+	var (
+		best string
+		max  int
+	)
+	for tag, n := range counts {
+		if n > max || (n == max && n > 0 && tag < best) {
+			best, max = tag, n
+		}
+	}
+	return best, max
+}
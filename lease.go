@@ -0,0 +1,237 @@
+package operand
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// DefaultLeaseFolderName is the name of the root-level folder holding lease files,
+// unless another folder is set with WithLeaseFolder.
+const DefaultLeaseFolderName = ".operand-leases"
+
+// Properties of lease files.
+const (
+	PropertyLeaseFile    = "operand_lease_file"
+	PropertyLeaseHolder  = "operand_lease_holder"
+	PropertyLeaseToken   = "operand_lease_token"
+	PropertyLeaseExpires = "operand_lease_expires"
+)
+
+var (
+	// ErrLeaseHeld is returned by AcquireLease if another holder has the lease.
+	ErrLeaseHeld = errors.New("lease is held by another holder")
+	// ErrLeaseLost is returned when a lease has expired, or been taken over.
+	ErrLeaseLost = errors.New("lease has been lost")
+)
+
+// Lease is an advisory lock on a file, which lets concurrent writers in different
+// services coordinate their updates. Leases are only advisory: they don't stop
+// anyone from modifying the file.
+//
+// The API has no support for leases, so they're implemented with lease files (see
+// WithLeaseFolder). As there's no atomic compare-and-swap, contenders each create
+// a lease file and then list them all; the lease with the lowest fencing token
+// wins, and the others back off. Expiry is judged by the client's clock, so the
+// clocks of contenders must be roughly in sync.
+type Lease struct {
+	FileID string
+	Holder string
+	// Token is the fencing token of the lease. Tokens increase with each new
+	// lease on a file, so that writers can reject writes from stale holders.
+	Token   int64
+	Expires time.Time
+
+	c       *Client
+	leaseID string // The ID of the current lease file.
+}
+
+// WithLeaseFolder sets the folder holding lease files. It should be used by all of
+// the clients contending for leases.
+func WithLeaseFolder(folderID string) Option {
+	return func(c *Client) { c.leaseFolderID = folderID }
+}
+
+// AcquireLease acquires the lease on a file for the given duration. It returns
+// ErrLeaseHeld if someone else holds it.
+func (c *Client) AcquireLease(ctx context.Context, fileID string, ttl time.Duration) (*Lease, error) {
+	var b [8]byte
+	c.rand.Read(b[:])
+	l := &Lease{FileID: fileID, Holder: hex.EncodeToString(b[:]), c: c}
+	if err := l.create(ctx, ttl, 0); err != nil {
+		return nil, err
+	}
+
+	leases, err := c.leases(ctx, fileID)
+	if err != nil {
+		c.DeleteFile(ctx, l.leaseID)
+		return nil, err
+	}
+	if len(leases) == 0 {
+		c.DeleteFile(ctx, l.leaseID)
+		return nil, fmt.Errorf("%w: lease file %s not found", ErrLeaseLost, l.leaseID)
+	}
+	if winner := leases[0]; winner.leaseID != l.leaseID {
+		c.DeleteFile(ctx, l.leaseID)
+		return nil, fmt.Errorf("%w: held by %s until %s", ErrLeaseHeld, winner.Holder, winner.Expires.Format(time.RFC3339))
+	}
+	l.Token = leases[0].Token
+	return l, nil
+}
+
+// Renew extends the lease by the given duration from now. It returns ErrLeaseLost
+// if the lease has already expired, or been taken over.
+func (l *Lease) Renew(ctx context.Context, ttl time.Duration) error {
+	if err := l.Check(ctx); err != nil {
+		return err
+	}
+	// The new lease file carries over the token, so it keeps winning.
+	previous := l.leaseID
+	if err := l.create(ctx, ttl, l.Token); err != nil {
+		return err
+	}
+	return l.c.DeleteFile(ctx, previous)
+}
+
+// Release releases the lease.
+func (l *Lease) Release(ctx context.Context) error {
+	return l.c.DeleteFile(ctx, l.leaseID)
+}
+
+// Check returns ErrLeaseLost if the lease is no longer held, e.g. before a write.
+func (l *Lease) Check(ctx context.Context) error {
+	if !l.c.clock.Now().Before(l.Expires) {
+		return fmt.Errorf("%w: expired at %s", ErrLeaseLost, l.Expires.Format(time.RFC3339))
+	}
+	leases, err := l.c.leases(ctx, l.FileID)
+	if err != nil {
+		return err
+	}
+	if len(leases) == 0 || leases[0].Token != l.Token || leases[0].Holder != l.Holder {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// create creates a lease file. If token is zero, the lease's token is taken from
+// the creation time of the file.
+func (l *Lease) create(ctx context.Context, ttl time.Duration, token int64) error {
+	folderID, err := l.c.leaseFolder(ctx)
+	if err != nil {
+		return err
+	}
+	expires := l.c.clock.Now().Add(ttl)
+	properties := SetProperty(nil, PropertyLeaseFile, TextProperty(l.FileID))
+	properties = SetProperty(properties, PropertyLeaseHolder, TextProperty(l.Holder))
+	properties = SetProperty(properties, PropertyLeaseExpires, TimeProperty(expires))
+	if token != 0 {
+		properties = SetProperty(properties, PropertyLeaseToken, TextProperty(strconv.FormatInt(token, 10)))
+	}
+	resp, err := l.c.createFile(ctx, l.FileID+".lease", &folderID, strings.NewReader(""), properties)
+	if err != nil {
+		return fmt.Errorf("failed to create lease file: %w", err)
+	}
+	l.leaseID = resp.File.Id
+	l.Expires = expires
+	return nil
+}
+
+// leases returns the unexpired leases on a file, lowest token (i.e. the holder)
+// first. Expired lease files are cleaned up.
+func (c *Client) leases(ctx context.Context, fileID string) ([]*Lease, error) {
+	folderID, err := c.leaseFolder(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files, err := c.ListFolder(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	now := c.clock.Now()
+	var leases []*Lease
+	for _, f := range files {
+		if id, _ := PropertyText(f.Properties, PropertyLeaseFile); id != fileID {
+			continue
+		}
+		l, err := leaseOf(c, f)
+		if err != nil || !now.Before(l.Expires) {
+			c.DeleteFile(ctx, f.Id) // Best effort.
+			continue
+		}
+		leases = append(leases, l)
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		if leases[i].Token != leases[j].Token {
+			return leases[i].Token < leases[j].Token
+		}
+		return leases[i].leaseID < leases[j].leaseID
+	})
+	return leases, nil
+}
+
+// leaseOf parses a lease file.
+func leaseOf(c *Client, f *filev1.File) (*Lease, error) {
+	l := &Lease{c: c, leaseID: f.Id}
+	l.FileID, _ = PropertyText(f.Properties, PropertyLeaseFile)
+	l.Holder, _ = PropertyText(f.Properties, PropertyLeaseHolder)
+	expires, _ := PropertyText(f.Properties, PropertyLeaseExpires)
+	var err error
+	if l.Expires, err = time.Parse(time.RFC3339Nano, expires); err != nil {
+		return nil, err
+	}
+	l.Token = f.GetCreatedAt().AsTime().UnixMicro()
+	if token, ok := PropertyText(f.Properties, PropertyLeaseToken); ok {
+		if l.Token, err = strconv.ParseInt(token, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// leaseFolder returns the ID of the folder holding lease files, creating the
+// default folder if necessary.
+func (c *Client) leaseFolder(ctx context.Context) (string, error) {
+	if c.leaseFolderID != "" {
+		return c.leaseFolderID, nil
+	}
+	folder, err := c.oldestLeaseFolder(ctx)
+	if err != nil || folder != nil {
+		return folder.GetId(), err
+	}
+	if _, err := c.createFile(ctx, DefaultLeaseFolderName, nil, nil, nil); err != nil {
+		return "", err
+	}
+	// List again, in case another client created the folder concurrently: they all
+	// settle on the oldest one.
+	folder, err = c.oldestLeaseFolder(ctx)
+	if err != nil {
+		return "", err
+	} else if folder == nil {
+		return "", errors.New("lease folder not found after creating it")
+	}
+	return folder.GetId(), nil
+}
+
+func (c *Client) oldestLeaseFolder(ctx context.Context) (*filev1.File, error) {
+	files, err := c.ListFolder(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	var folder *filev1.File
+	for _, f := range files {
+		if f.Name != DefaultLeaseFolderName || !IsFolder(f) {
+			continue
+		}
+		if folder == nil || f.GetCreatedAt().AsTime().Before(folder.GetCreatedAt().AsTime()) {
+			folder = f
+		}
+	}
+	return folder, nil
+}
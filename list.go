@@ -0,0 +1,76 @@
+package operand
+
+import (
+	"context"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// ListFolder returns all of the files directly within a directory, fetching as many
+// pages as required. An empty parent ID lists the root.
+func (c *Client) ListFolder(ctx context.Context, parentID string) ([]*filev1.File, error) {
+	return c.listFiles(ctx, &filev1.FileFilter{ParentId: &parentID})
+}
+
+// ListAll returns all of the files accessible to the client, from all directories.
+func (c *Client) ListAll(ctx context.Context) ([]*filev1.File, error) {
+	return c.listFiles(ctx, &filev1.FileFilter{})
+}
+
+func (c *Client) listFiles(ctx context.Context, filter *filev1.FileFilter) ([]*filev1.File, error) {
+	return c.IterFiles(ctx, &filev1.ListFilesRequest{Filter: filter}).All()
+}
+
+// FilesIterator iterates over the files of a listing, fetching pages from the File
+// Service as required:
+//
+//	it := client.IterFiles(ctx, &filev1.ListFilesRequest{Filter: filter})
+//	for it.Next() {
+//		file := it.File()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type FilesIterator struct {
+	*Pager[*filev1.File]
+}
+
+// IterFiles returns an iterator over the files listed by the request, starting
+// from its cursor, if any, and fetching pages of its page size, if any. The
+// request isn't modified.
+func (c *Client) IterFiles(ctx context.Context, req *filev1.ListFilesRequest) *FilesIterator {
+	req = proto.Clone(req).(*filev1.ListFilesRequest)
+	if req.Pagination == nil {
+		req.Pagination = &filev1.PaginationRequest{}
+	}
+	fetch := func(ctx context.Context, cursor *string) ([]*filev1.File, *string, error) {
+		req.Pagination.Cursor = cursor
+		resp, err := c.FileService().ListFiles(ctx, connect.NewRequest(req))
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Msg.Files, resp.Msg.GetPagination().NextCursor, nil
+	}
+	return &FilesIterator{NewPager(ctx, req.Pagination.Cursor, fetch)}
+}
+
+// File returns the current file.
+func (it *FilesIterator) File() *filev1.File {
+	return it.Item()
+}
+
+// IterAPIKeys returns an iterator over the API keys of the authorized user. The
+// API lists them in a single page.
+func (c *Client) IterAPIKeys(ctx context.Context) *Pager[*tenantv1.APIKey] {
+	return NewPager(ctx, nil, func(ctx context.Context, _ *string) ([]*tenantv1.APIKey, *string, error) {
+		resp, err := c.TenantService().ListAPIKeys(ctx, connect.NewRequest(&tenantv1.ListAPIKeysRequest{}))
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Msg.Keys, nil, nil
+	})
+}
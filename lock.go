@@ -0,0 +1,111 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Locker is a distributed lock, e.g. for electing the replica of a daemon which
+// performs mutations. LeaseLocker implements it with leases; implementations
+// backed by external stores such as Redis, etcd or a database can be plugged in
+// instead.
+type Locker interface {
+	// TryLock acquires the lock for the given duration, or extends it if it's
+	// already held by this Locker. It returns ErrLeaseHeld if the lock is held
+	// elsewhere, and ErrLeaseLost if it was held by this Locker, but has been lost.
+	TryLock(ctx context.Context, ttl time.Duration) error
+	// Unlock releases the lock, if it's held.
+	Unlock(ctx context.Context) error
+}
+
+// LeaseLocker is a Locker backed by a lease (see Client.AcquireLease) on a key,
+// which is usually the ID of the file or folder being protected.
+type LeaseLocker struct {
+	Client *Client
+	Key    string
+
+	mu    sync.Mutex
+	lease *Lease
+}
+
+var _ Locker = (*LeaseLocker)(nil)
+
+// TryLock acquires or renews the lease.
+func (l *LeaseLocker) TryLock(ctx context.Context, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lease != nil {
+		if err := l.lease.Renew(ctx, ttl); err != nil {
+			l.lease = nil
+			return err
+		}
+		return nil
+	}
+	lease, err := l.Client.AcquireLease(ctx, l.Key, ttl)
+	if err != nil {
+		return err
+	}
+	l.lease = lease
+	return nil
+}
+
+// Unlock releases the lease.
+func (l *LeaseLocker) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lease == nil {
+		return nil
+	}
+	err := l.lease.Release(ctx)
+	l.lease = nil
+	return err
+}
+
+// withLock runs fn while holding the lock, renewing it every third of its ttl. If
+// the lock is lost, the context passed to fn is cancelled.
+func withLock(
+	ctx context.Context,
+	clock Clock,
+	locker Locker,
+	ttl time.Duration,
+	fn func(ctx context.Context) error,
+) error {
+	if err := locker.TryLock(ctx, ttl); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := clock.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+				if err := locker.TryLock(ctx, ttl); err != nil {
+					cancel(fmt.Errorf("lost lock: %w", err))
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := fn(ctx)
+	close(done)
+	wg.Wait()
+	if cause := context.Cause(ctx); cause != nil && ctx.Err() != nil && err != nil {
+		err = cause
+	}
+	if unlockErr := locker.Unlock(context.WithoutCancel(ctx)); err == nil {
+		err = unlockErr
+	}
+	return err
+}
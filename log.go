@@ -0,0 +1,126 @@
+package operand
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+)
+
+// WithLogger logs each RPC, upload and download made by the client to the logger,
+// once it completes, with its method (as in MethodStats), operation ID, duration,
+// code, number of retries (see WithRetry and WithThrottle), and error, if any.
+// Successful calls are logged at slog.LevelDebug, and failed ones at
+// slog.LevelWarn, unless set otherwise with WithLogLevels.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+		if c.logLevels == nil {
+			c.logLevels = &logLevels{success: slog.LevelDebug, failure: slog.LevelWarn}
+		}
+	}
+}
+
+// WithLogLevels sets the levels at which WithLogger logs successful and failed calls.
+func WithLogLevels(success, failure slog.Level) Option {
+	return func(c *Client) { c.logLevels = &logLevels{success: success, failure: failure} }
+}
+
+type logLevels struct {
+	success, failure slog.Level
+}
+
+type callLogKey struct{}
+
+// callLog accumulates the details of a call which are logged once it completes.
+type callLog struct {
+	retries int
+}
+
+// startCallLog returns a context which tracks the retries of a call, if the client
+// logs calls.
+func (c *Client) startCallLog(ctx context.Context) (context.Context, *callLog) {
+	if c.logger == nil {
+		return ctx, nil
+	}
+	cl := new(callLog)
+	return context.WithValue(ctx, callLogKey{}, cl), cl
+}
+
+// recordRetry counts a retry of the context's call, if it's logged.
+func recordRetry(ctx context.Context) {
+	if cl, ok := ctx.Value(callLogKey{}).(*callLog); ok {
+		cl.retries++
+	}
+}
+
+// logCall logs a completed call.
+func (c *Client) logCall(ctx context.Context, cl *callLog, method string, start time.Time, err error) {
+	if cl == nil {
+		return
+	}
+	level, code := c.logLevels.success, "ok"
+	if err != nil {
+		level, code = c.logLevels.failure, errorCode(err).String()
+	}
+	if !c.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("operation_id", OperationID(ctx)),
+		slog.Duration("duration", c.clock.Now().Sub(start)),
+		slog.String("code", code),
+		slog.Int("retries", cl.retries),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	c.logger.LogAttrs(ctx, level, "operand call", attrs...)
+}
+
+// logInterceptor logs RPCs. It sits within the operation interceptor, so that the
+// operation ID is logged, and outside the retry interceptor, so that each call is
+// logged once.
+type logInterceptor struct {
+	c *Client
+}
+
+var _ connect.Interceptor = logInterceptor{}
+
+func (li logInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, cl := li.c.startCallLog(ctx)
+		start := li.c.clock.Now()
+		resp, err := next(ctx, req)
+		li.c.logCall(ctx, cl, req.Spec().Procedure, start, err)
+		return resp, err
+	}
+}
+
+func (li logInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		ctx, cl := li.c.startCallLog(ctx)
+		return &logConn{StreamingClientConn: next(ctx, spec), c: li.c, ctx: ctx, cl: cl, start: li.c.clock.Now()}
+	}
+}
+
+func (logInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
+
+// logConn logs a stream once it's closed.
+type logConn struct {
+	connect.StreamingClientConn
+	c     *Client
+	ctx   context.Context
+	cl    *callLog
+	start time.Time
+}
+
+func (lc *logConn) CloseResponse() error {
+	err := lc.StreamingClientConn.CloseResponse()
+	lc.c.logCall(lc.ctx, lc.cl, lc.Spec().Procedure, lc.start, err)
+	return err
+}
@@ -0,0 +1,44 @@
+package operand
+
+import (
+	"io"
+	"time"
+)
+
+// MetricsCollector receives metrics of the calls made by a client, e.g. to export
+// them to Prometheus. Methods are called from the goroutines making calls, so they
+// must be safe for concurrent use, and return quickly.
+type MetricsCollector interface {
+	// ObserveCall is called once each call completes, with its method (as in
+	// MethodStats), its code ("ok" if it succeeded, or a connect.Code, e.g.
+	// "unavailable"), and its latency. Each attempt of a retried call is observed.
+	ObserveCall(method, code string, latency time.Duration)
+	// ObserveUploadBytes is called with the number of bytes of content sent by each
+	// upload attempt, including ones which failed.
+	ObserveUploadBytes(n int64)
+}
+
+// WithMetrics reports metrics of RPCs, uploads and downloads to the collector, in
+// addition to the statistics kept by Client.Stats.
+func WithMetrics(m MetricsCollector) Option {
+	return func(c *Client) { c.stats.metrics = m }
+}
+
+// observeUploadBytes reports the bytes of content sent by an upload attempt.
+func (c *Client) observeUploadBytes(n int64) {
+	if c.stats.metrics != nil {
+		c.stats.metrics.ObserveUploadBytes(n)
+	}
+}
+
+// byteCounter counts the bytes read through it.
+type byteCounter struct {
+	r io.Reader
+	n int64
+}
+
+func (b *byteCounter) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	return n, err
+}
@@ -2,12 +2,16 @@
 package operand
 
 import (
-	"bytes"
 	"context"
-	"fmt"
+	"errors"
 	"io"
+	"log/slog"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"sync/atomic"
+	"time"
 
 	"github.com/bufbuild/connect-go"
 	filev1 "github.com/operandinc/go-sdk/file/v1"
@@ -19,27 +23,76 @@ import (
 
 // Client is the client for the Operand API.
 type Client struct {
-	httpClient *http.Client
-	endpoint   string
-	apiKey     string
+	httpClient         *http.Client
+	endpoint           atomic.Pointer[endpoint]
+	apiKey             string
+	timeout            time.Duration
+	interceptors       []connect.Interceptor
+	userAgent          string
+	errorDetailParsers map[string]ErrorDetailParser
+	clock              Clock
+	rand               *lockedRand
+	redactor           *Redactor
+	holds              HoldStore
+	auditHook          AuditHook
+	stats              *latencyStats
+
+	leaseFolderID string
+	shadow        *Shadow
+	shadowSem     chan struct{}
+	queryLog      *QueryLog
+	fallback      Fallback
+	retry         *RetryPolicy
+	throttle      time.Duration
+
+	inlineThreshold int64
+	logger          *slog.Logger
+	logLevels       *logLevels
+	debug           *debugTransport
+	resolver        *resolvingTransport
+	strictDecoding  bool
+	deadlines       *DeadlinePolicy
+	feedback        FeedbackSink
+	guardrails      []Guardrail
 }
 
-// NewClient creates a new client for the Operand API.
-func NewClient(apiKey string) *Client {
-	return &Client{
+// NewClient creates a new client for the Operand API, configured by the given
+// options.
+func NewClient(apiKey string, opts ...Option) *Client {
+	c := &Client{
 		httpClient: http.DefaultClient,
-		endpoint:   "https://mcp.operand.ai",
 		apiKey:     apiKey,
+		clock:      SystemClock{},
+		rand:       newLockedRand(),
+		redactor:   DefaultRedactor(),
+		holds:      NewMemoryHoldStore(),
+		stats:      newLatencyStats(),
+	}
+	c.endpoint.Store(newEndpoint(DefaultEndpoint))
+	for _, opt := range opts {
+		opt(c)
 	}
+	if c.debug != nil {
+		c.httpClient = c.debug.wrap(c.httpClient)
+	}
+	if c.resolver != nil {
+		c.httpClient = c.resolver.wrap(c.httpClient)
+	}
+	return c
 }
 
 // WithEndpoint sets the endpoint for the client.
+//
+// Deprecated: Use the WithEndpoint option, which sets it on construction, or
+// SetEndpoint, which drains calls in flight.
 func (c *Client) WithEndpoint(endpoint string) *Client {
-	c.endpoint = endpoint
+	c.endpoint.Store(newEndpoint(endpoint))
 	return c
 }
 
 // WithHTTPClient sets the HTTP client for the client.
+//
+// Deprecated: Use the WithHTTPClient option, which sets it on construction.
 func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
 	c.httpClient = httpClient
 	return c
@@ -47,21 +100,26 @@ func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
 
 // FileService returns a client for the Operand File Service.
 func (c *Client) FileService() filev1connect.FileServiceClient {
-	return filev1connect.NewFileServiceClient(c.httpClient, c.endpoint, c.clientOpts()...)
+	ep := c.currentEndpoint()
+	return filev1connect.NewFileServiceClient(c.httpClient, ep.url, c.clientOpts(ep)...)
 }
 
 // TenantService returns a client for the Operand Tenant Service.
 func (c *Client) TenantService() tenantv1connect.TenantServiceClient {
-	return tenantv1connect.NewTenantServiceClient(c.httpClient, c.endpoint, c.clientOpts()...)
+	ep := c.currentEndpoint()
+	return tenantv1connect.NewTenantServiceClient(c.httpClient, ep.url, c.clientOpts(ep)...)
 }
 
 // OperandService returns a client for the Operand Operand Service.
 func (c *Client) OperandService() operandv1connect.OperandServiceClient {
-	return operandv1connect.NewOperandServiceClient(c.httpClient, c.endpoint, c.clientOpts()...)
+	ep := c.currentEndpoint()
+	return operandv1connect.NewOperandServiceClient(c.httpClient, ep.url, c.clientOpts(ep)...)
 }
 
 // CreateFile is a utility method for creating files. Since this is a common operation
-// and is a little more involved, we provide a helper method for it.
+// and is a little more involved, we provide a helper method for it. If a collision
+// policy is set on the context (see WithCollisionPolicy), it's applied to any file
+// with the same name in the parent folder.
 func (c *Client) CreateFile(
 	ctx context.Context,
 	name string,
@@ -69,46 +127,197 @@ func (c *Client) CreateFile(
 	data io.Reader, // Nullable, if nil, we'll create a folder (i.e. a file with no data).
 	properties *filev1.Properties,
 ) (*filev1.CreateFileResponse, error) {
-	var buf bytes.Buffer
-
-	mw := multipart.NewWriter(&buf)
-	mw.WriteField("name", name)
+	ctx = c.withOperation(ctx)
+	policy := collisionPolicy(ctx)
+	if policy == CollisionAllow {
+		return c.createFile(ctx, name, parent, data, properties)
+	}
+	var parentID string
 	if parent != nil {
-		mw.WriteField("parent_id", *parent)
+		parentID = *parent
+	}
+	siblings, err := c.ListFolder(ctx, parentID)
+	if err != nil {
+		return nil, err
 	}
+	return c.createWithPolicy(ctx, policy, name, parent, data, properties, siblings)
+}
+
+// createFile uploads a file, regardless of any existing files with the same name.
+func (c *Client) createFile(
+	ctx context.Context,
+	name string,
+	parent *string,
+	data io.Reader,
+	properties *filev1.Properties,
+) (*filev1.CreateFileResponse, error) {
+	if c.inline(ctx, data) {
+		return c.createInline(ctx, name, parent, data, properties)
+	}
+	c.checkDeadline(ctx, MethodUpload, true)
+	ctx, cl := c.startCallLog(ctx)
+	start := c.clock.Now()
+	resp, err := c.uploadFile(ctx, name, parent, data, properties)
+	c.logCall(ctx, cl, MethodUpload, start, err)
+	return resp, err
+}
+
+// uploadFile uploads a file, retrying according to the client's retry policy if
+// the data can be read again.
+func (c *Client) uploadFile(
+	ctx context.Context,
+	name string,
+	parent *string,
+	data io.Reader,
+	properties *filev1.Properties,
+) (*filev1.CreateFileResponse, error) {
+	var marshaled []byte
 	if properties != nil {
-		marshaled, err := protojson.Marshal(properties)
-		if err != nil {
+		var err error
+		if marshaled, err = protojson.Marshal(properties); err != nil {
 			return nil, err
 		}
-		mw.WriteField("properties", string(marshaled))
 	}
-	if data != nil {
-		part, err := mw.CreateFormFile("file", name)
-		if err != nil {
-			return nil, err
-		}
-		_, err = io.Copy(part, data)
-		if err != nil {
-			return nil, err
-		}
+	rewind := rewindable(data)
+	if rewind == nil {
+		return c.uploadOnce(ctx, name, parent, data, marshaled)
 	}
-	if err := mw.Close(); err != nil {
-		return nil, err
+	var resp *filev1.CreateFileResponse
+	attempt := 0
+	err := c.withRetries(ctx, func() error {
+		if attempt++; attempt > 1 {
+			if err := rewind(); err != nil {
+				return err
+			}
+		}
+		var err error
+		resp, err = c.uploadOnce(ctx, name, parent, data, marshaled)
+		return err
+	})
+	return resp, err
+}
+
+// uploadOnce makes a single attempt at uploading a file.
+func (c *Client) uploadOnce(
+	ctx context.Context,
+	name string,
+	parent *string,
+	data io.Reader,
+	marshaled []byte,
+) (*filev1.CreateFileResponse, error) {
+	// The body is streamed, so that memory usage doesn't grow with the size of the
+	// file.
+	data = progressReader(ctx, data)
+	var sent *byteCounter
+	if data != nil {
+		sent = &byteCounter{r: data}
+		data = sent
 	}
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	written := make(chan error, 1)
+	go func() {
+		err := writeUploadBody(mw, name, parent, marshaled, data, uploadContentType(ctx))
+		pw.CloseWithError(err)
+		written <- err
+	}()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/upload", &buf)
+	ep := c.currentEndpoint()
+	ep.acquire()
+	defer ep.release()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.url+"/upload", pr)
 	if err != nil {
+		pr.Close()
+		<-written
 		return nil, err
 	}
 	req.Header.Set("Authorization", "Key "+c.apiKey)
 	req.Header.Set("Content-Type", mw.FormDataContentType())
 
+	start := c.clock.Now()
+	body, err := c.upload(ctx, req)
+	// Closing the body stops the writer if the upload ended early. Wait for it, so
+	// that data isn't read after we return, and report its failure (e.g. to read
+	// data) rather than the resulting failure of the request.
+	pr.Close()
+	if writeErr := <-written; err != nil && writeErr != nil && !errors.Is(writeErr, io.ErrClosedPipe) {
+		err = writeErr
+	}
+	c.stats.record(MethodUpload, c.clock.Now().Sub(start), err)
+	if sent != nil {
+		c.observeUploadBytes(sent.n)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	createFileResponse := &filev1.CreateFileResponse{}
+	if err := c.unmarshalJSON(body, createFileResponse); err != nil {
+		return nil, err
+	}
+
+	return createFileResponse, nil
+}
+
+// writeUploadBody writes the multipart body of an upload.
+func writeUploadBody(
+	mw *multipart.Writer,
+	name string,
+	parent *string,
+	properties []byte,
+	data io.Reader,
+	contentType string,
+) error {
+	if err := mw.WriteField("name", name); err != nil {
+		return err
+	}
+	if parent != nil {
+		if err := mw.WriteField("parent_id", *parent); err != nil {
+			return err
+		}
+	}
+	if properties != nil {
+		if err := mw.WriteField("properties", string(properties)); err != nil {
+			return err
+		}
+	}
+	if data != nil {
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+			"name":     "file",
+			"filename": name,
+		}))
+		header.Set("Content-Type", contentType)
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, data); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// upload sends an upload request, and returns the body of the response.
+func (c *Client) upload(ctx context.Context, req *http.Request) ([]byte, error) {
+	ctx, cancel := c.callTimeout(c.withOperation(ctx))
+	defer cancel()
+	req = req.WithContext(ctx)
+	setRequestContext(ctx, req.Header)
+	setOperationID(ctx, req.Header)
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	recordCallInfo(ctx, resp.Header, resp.Trailer)
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -116,25 +325,47 @@ func (c *Client) CreateFile(
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+		return nil, c.httpError(resp, body)
 	}
-
-	createFileResponse := &filev1.CreateFileResponse{}
-	if err := protojson.Unmarshal(body, createFileResponse); err != nil {
-		return nil, err
-	}
-
-	return createFileResponse, nil
+	return body, nil
 }
 
-func (c *Client) clientOpts() []connect.ClientOption {
-	return []connect.ClientOption{
-		connect.WithInterceptors(&headerInterceptor{apiKey: c.apiKey}),
+func (c *Client) clientOpts(ep *endpoint) []connect.ClientOption {
+	interceptors := []connect.Interceptor{
+		endpointInterceptor{e: ep},
+		operationInterceptor{c: c},
+	}
+	if c.deadlines != nil {
+		interceptors = append(interceptors, deadlineInterceptor{c: c})
+	}
+	if c.logger != nil {
+		interceptors = append(interceptors, logInterceptor{c: c})
+	}
+	interceptors = append(interceptors, errorInterceptor{c: c})
+	if c.fallback != nil {
+		interceptors = append(interceptors, fallbackInterceptor{c: c})
+	}
+	if c.retry != nil || c.throttle > 0 {
+		interceptors = append(interceptors, retryInterceptor{c: c})
+	}
+	interceptors = append(interceptors,
+		&headerInterceptor{apiKey: c.apiKey, userAgent: c.userAgent},
+		callInfoInterceptor{},
+		statsInterceptor{stats: c.stats, clock: c.clock},
+	)
+	if c.timeout > 0 {
+		interceptors = append(interceptors, timeoutInterceptor{c: c})
+	}
+	interceptors = append(interceptors, c.interceptors...)
+	if c.shadow != nil {
+		interceptors = append(interceptors, shadowInterceptor{c: c})
 	}
+	return []connect.ClientOption{connect.WithInterceptors(interceptors...)}
 }
 
 type headerInterceptor struct {
-	apiKey string
+	apiKey    string
+	userAgent string
 }
 
 var _ connect.Interceptor = (*headerInterceptor)(nil)
@@ -143,6 +374,11 @@ func (hi *headerInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc
 	return func(ctx context.Context, ar connect.AnyRequest) (connect.AnyResponse, error) {
 		if ar.Spec().IsClient {
 			ar.Header().Set("Authorization", "Key "+hi.apiKey)
+			setRequestContext(ctx, ar.Header())
+			setOperationID(ctx, ar.Header())
+			if hi.userAgent != "" {
+				ar.Header().Set("User-Agent", hi.userAgent)
+			}
 		}
 		return next(ctx, ar)
 	}
@@ -155,6 +391,11 @@ func (hi *headerInterceptor) WrapStreamingClient(
 		conn := next(ctx, s)
 		if s.IsClient {
 			conn.RequestHeader().Set("Authorization", "Key "+hi.apiKey)
+			setRequestContext(ctx, conn.RequestHeader())
+			setOperationID(ctx, conn.RequestHeader())
+			if hi.userAgent != "" {
+				conn.RequestHeader().Set("User-Agent", hi.userAgent)
+			}
 		}
 		return conn
 	}
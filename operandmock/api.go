@@ -0,0 +1,278 @@
+// Code generated by internal/mockgen; DO NOT EDIT.
+
+// Package operandmock provides a mock of operand.API, for unit tests of code
+// using the SDK. See operandtest for a fake of the API with real semantics.
+package operandmock
+
+import (
+	"context"
+	"io"
+	"time"
+
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/operandinc/go-sdk/file/v1/filev1connect"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"github.com/operandinc/go-sdk/operand/v1/operandv1connect"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+	"github.com/operandinc/go-sdk/tenant/v1/tenantv1connect"
+)
+
+// API is a mock of operand.API. Each method calls the field of the same name with a
+// Func suffix, or panics if it's nil.
+type API struct {
+	FileServiceFunc        func() filev1connect.FileServiceClient
+	TenantServiceFunc      func() tenantv1connect.TenantServiceClient
+	OperandServiceFunc     func() operandv1connect.OperandServiceClient
+	CreateFileFunc         func(ctx context.Context, name string, parent *string, data io.Reader, properties *filev1.Properties) (*filev1.CreateFileResponse, error)
+	CreateFileFromPathFunc func(ctx context.Context, path string, parent *string, properties *filev1.Properties) (*filev1.CreateFileResponse, error)
+	UploadDirFunc          func(ctx context.Context, dir string, parentID string, opts operand.UploadOptions) (map[string]string, error)
+	DownloadFileFunc       func(ctx context.Context, fileID string, opts ...operand.DownloadFileOption) (io.ReadCloser, *operand.FileInfo, error)
+	DownloadDirFunc        func(ctx context.Context, folderID string, dir string, opts operand.DownloadOptions) error
+	PreviewFunc            func(ctx context.Context, fileID string, maxBytes int64) (*operand.FilePreview, error)
+	GetFilesFunc           func(ctx context.Context, ids []string) map[string]operand.FileResult
+	ListFolderFunc         func(ctx context.Context, parentID string) ([]*filev1.File, error)
+	ListAllFunc            func(ctx context.Context) ([]*filev1.File, error)
+	IterFilesFunc          func(ctx context.Context, req *filev1.ListFilesRequest) *operand.FilesIterator
+	IterAPIKeysFunc        func(ctx context.Context) *operand.Pager[*tenantv1.APIKey]
+	ListChangedSinceFunc   func(ctx context.Context, scope string, since time.Time) ([]*filev1.File, error)
+	WalkFunc               func(ctx context.Context, rootID string, fn operand.WalkFunc) error
+	DeleteFileFunc         func(ctx context.Context, id string) error
+	DeleteTreeFunc         func(ctx context.Context, rootID string) error
+	TrackIndexingFunc      func(ctx context.Context, fileID string, interval time.Duration) *operand.OperationHandle[*filev1.File]
+	WaitForFileIndexedFunc func(ctx context.Context, fileID string, opts *operand.WaitOptions) (filev1.IndexingStatus, error)
+	SearchFunc             func(ctx context.Context, query string, opts ...operand.SearchOption) (*operandv1.SearchResponse, error)
+	SearchHitsFunc         func(ctx context.Context, query string, opts ...operand.SearchOption) ([]*operand.Hit, error)
+	SearchDocumentsFunc    func(ctx context.Context, query string, chunks int, opts ...operand.SearchOption) ([]*operand.DocumentMatch, error)
+	CiteFunc               func(ctx context.Context, match *operandv1.ContentMatch, file *filev1.File) (*operand.Citation, error)
+	AskFunc                func(ctx context.Context, question string, opts *operandv1.ConversationOptions) (*operand.Answer, error)
+	AskStreamFunc          func(ctx context.Context, question string, opts *operandv1.ConversationOptions) (*operand.AnswerStream, error)
+	NewConversationFunc    func(opts *operandv1.ConversationOptions) *operand.Conversation
+	SubmitFeedbackFunc     func(ctx context.Context, queryID string, fileID string, signal operand.FeedbackSignal) error
+}
+
+var _ operand.API = (*API)(nil)
+
+// FileService calls FileServiceFunc.
+func (m *API) FileService() filev1connect.FileServiceClient {
+	if m.FileServiceFunc == nil {
+		panic("operandmock: unexpected call to FileService")
+	}
+	return m.FileServiceFunc()
+}
+
+// TenantService calls TenantServiceFunc.
+func (m *API) TenantService() tenantv1connect.TenantServiceClient {
+	if m.TenantServiceFunc == nil {
+		panic("operandmock: unexpected call to TenantService")
+	}
+	return m.TenantServiceFunc()
+}
+
+// OperandService calls OperandServiceFunc.
+func (m *API) OperandService() operandv1connect.OperandServiceClient {
+	if m.OperandServiceFunc == nil {
+		panic("operandmock: unexpected call to OperandService")
+	}
+	return m.OperandServiceFunc()
+}
+
+// CreateFile calls CreateFileFunc.
+func (m *API) CreateFile(ctx context.Context, name string, parent *string, data io.Reader, properties *filev1.Properties) (*filev1.CreateFileResponse, error) {
+	if m.CreateFileFunc == nil {
+		panic("operandmock: unexpected call to CreateFile")
+	}
+	return m.CreateFileFunc(ctx, name, parent, data, properties)
+}
+
+// CreateFileFromPath calls CreateFileFromPathFunc.
+func (m *API) CreateFileFromPath(ctx context.Context, path string, parent *string, properties *filev1.Properties) (*filev1.CreateFileResponse, error) {
+	if m.CreateFileFromPathFunc == nil {
+		panic("operandmock: unexpected call to CreateFileFromPath")
+	}
+	return m.CreateFileFromPathFunc(ctx, path, parent, properties)
+}
+
+// UploadDir calls UploadDirFunc.
+func (m *API) UploadDir(ctx context.Context, dir string, parentID string, opts operand.UploadOptions) (map[string]string, error) {
+	if m.UploadDirFunc == nil {
+		panic("operandmock: unexpected call to UploadDir")
+	}
+	return m.UploadDirFunc(ctx, dir, parentID, opts)
+}
+
+// DownloadFile calls DownloadFileFunc.
+func (m *API) DownloadFile(ctx context.Context, fileID string, opts ...operand.DownloadFileOption) (io.ReadCloser, *operand.FileInfo, error) {
+	if m.DownloadFileFunc == nil {
+		panic("operandmock: unexpected call to DownloadFile")
+	}
+	return m.DownloadFileFunc(ctx, fileID, opts...)
+}
+
+// DownloadDir calls DownloadDirFunc.
+func (m *API) DownloadDir(ctx context.Context, folderID string, dir string, opts operand.DownloadOptions) error {
+	if m.DownloadDirFunc == nil {
+		panic("operandmock: unexpected call to DownloadDir")
+	}
+	return m.DownloadDirFunc(ctx, folderID, dir, opts)
+}
+
+// Preview calls PreviewFunc.
+func (m *API) Preview(ctx context.Context, fileID string, maxBytes int64) (*operand.FilePreview, error) {
+	if m.PreviewFunc == nil {
+		panic("operandmock: unexpected call to Preview")
+	}
+	return m.PreviewFunc(ctx, fileID, maxBytes)
+}
+
+// GetFiles calls GetFilesFunc.
+func (m *API) GetFiles(ctx context.Context, ids []string) map[string]operand.FileResult {
+	if m.GetFilesFunc == nil {
+		panic("operandmock: unexpected call to GetFiles")
+	}
+	return m.GetFilesFunc(ctx, ids)
+}
+
+// ListFolder calls ListFolderFunc.
+func (m *API) ListFolder(ctx context.Context, parentID string) ([]*filev1.File, error) {
+	if m.ListFolderFunc == nil {
+		panic("operandmock: unexpected call to ListFolder")
+	}
+	return m.ListFolderFunc(ctx, parentID)
+}
+
+// ListAll calls ListAllFunc.
+func (m *API) ListAll(ctx context.Context) ([]*filev1.File, error) {
+	if m.ListAllFunc == nil {
+		panic("operandmock: unexpected call to ListAll")
+	}
+	return m.ListAllFunc(ctx)
+}
+
+// IterFiles calls IterFilesFunc.
+func (m *API) IterFiles(ctx context.Context, req *filev1.ListFilesRequest) *operand.FilesIterator {
+	if m.IterFilesFunc == nil {
+		panic("operandmock: unexpected call to IterFiles")
+	}
+	return m.IterFilesFunc(ctx, req)
+}
+
+// IterAPIKeys calls IterAPIKeysFunc.
+func (m *API) IterAPIKeys(ctx context.Context) *operand.Pager[*tenantv1.APIKey] {
+	if m.IterAPIKeysFunc == nil {
+		panic("operandmock: unexpected call to IterAPIKeys")
+	}
+	return m.IterAPIKeysFunc(ctx)
+}
+
+// ListChangedSince calls ListChangedSinceFunc.
+func (m *API) ListChangedSince(ctx context.Context, scope string, since time.Time) ([]*filev1.File, error) {
+	if m.ListChangedSinceFunc == nil {
+		panic("operandmock: unexpected call to ListChangedSince")
+	}
+	return m.ListChangedSinceFunc(ctx, scope, since)
+}
+
+// Walk calls WalkFunc.
+func (m *API) Walk(ctx context.Context, rootID string, fn operand.WalkFunc) error {
+	if m.WalkFunc == nil {
+		panic("operandmock: unexpected call to Walk")
+	}
+	return m.WalkFunc(ctx, rootID, fn)
+}
+
+// DeleteFile calls DeleteFileFunc.
+func (m *API) DeleteFile(ctx context.Context, id string) error {
+	if m.DeleteFileFunc == nil {
+		panic("operandmock: unexpected call to DeleteFile")
+	}
+	return m.DeleteFileFunc(ctx, id)
+}
+
+// DeleteTree calls DeleteTreeFunc.
+func (m *API) DeleteTree(ctx context.Context, rootID string) error {
+	if m.DeleteTreeFunc == nil {
+		panic("operandmock: unexpected call to DeleteTree")
+	}
+	return m.DeleteTreeFunc(ctx, rootID)
+}
+
+// TrackIndexing calls TrackIndexingFunc.
+func (m *API) TrackIndexing(ctx context.Context, fileID string, interval time.Duration) *operand.OperationHandle[*filev1.File] {
+	if m.TrackIndexingFunc == nil {
+		panic("operandmock: unexpected call to TrackIndexing")
+	}
+	return m.TrackIndexingFunc(ctx, fileID, interval)
+}
+
+// WaitForFileIndexed calls WaitForFileIndexedFunc.
+func (m *API) WaitForFileIndexed(ctx context.Context, fileID string, opts *operand.WaitOptions) (filev1.IndexingStatus, error) {
+	if m.WaitForFileIndexedFunc == nil {
+		panic("operandmock: unexpected call to WaitForFileIndexed")
+	}
+	return m.WaitForFileIndexedFunc(ctx, fileID, opts)
+}
+
+// Search calls SearchFunc.
+func (m *API) Search(ctx context.Context, query string, opts ...operand.SearchOption) (*operandv1.SearchResponse, error) {
+	if m.SearchFunc == nil {
+		panic("operandmock: unexpected call to Search")
+	}
+	return m.SearchFunc(ctx, query, opts...)
+}
+
+// SearchHits calls SearchHitsFunc.
+func (m *API) SearchHits(ctx context.Context, query string, opts ...operand.SearchOption) ([]*operand.Hit, error) {
+	if m.SearchHitsFunc == nil {
+		panic("operandmock: unexpected call to SearchHits")
+	}
+	return m.SearchHitsFunc(ctx, query, opts...)
+}
+
+// SearchDocuments calls SearchDocumentsFunc.
+func (m *API) SearchDocuments(ctx context.Context, query string, chunks int, opts ...operand.SearchOption) ([]*operand.DocumentMatch, error) {
+	if m.SearchDocumentsFunc == nil {
+		panic("operandmock: unexpected call to SearchDocuments")
+	}
+	return m.SearchDocumentsFunc(ctx, query, chunks, opts...)
+}
+
+// Cite calls CiteFunc.
+func (m *API) Cite(ctx context.Context, match *operandv1.ContentMatch, file *filev1.File) (*operand.Citation, error) {
+	if m.CiteFunc == nil {
+		panic("operandmock: unexpected call to Cite")
+	}
+	return m.CiteFunc(ctx, match, file)
+}
+
+// Ask calls AskFunc.
+func (m *API) Ask(ctx context.Context, question string, opts *operandv1.ConversationOptions) (*operand.Answer, error) {
+	if m.AskFunc == nil {
+		panic("operandmock: unexpected call to Ask")
+	}
+	return m.AskFunc(ctx, question, opts)
+}
+
+// AskStream calls AskStreamFunc.
+func (m *API) AskStream(ctx context.Context, question string, opts *operandv1.ConversationOptions) (*operand.AnswerStream, error) {
+	if m.AskStreamFunc == nil {
+		panic("operandmock: unexpected call to AskStream")
+	}
+	return m.AskStreamFunc(ctx, question, opts)
+}
+
+// NewConversation calls NewConversationFunc.
+func (m *API) NewConversation(opts *operandv1.ConversationOptions) *operand.Conversation {
+	if m.NewConversationFunc == nil {
+		panic("operandmock: unexpected call to NewConversation")
+	}
+	return m.NewConversationFunc(opts)
+}
+
+// SubmitFeedback calls SubmitFeedbackFunc.
+func (m *API) SubmitFeedback(ctx context.Context, queryID string, fileID string, signal operand.FeedbackSignal) error {
+	if m.SubmitFeedbackFunc == nil {
+		panic("operandmock: unexpected call to SubmitFeedback")
+	}
+	return m.SubmitFeedbackFunc(ctx, queryID, fileID, signal)
+}
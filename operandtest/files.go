@@ -0,0 +1,291 @@
+package operandtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/operandinc/go-sdk/file/v1/filev1connect"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultPageSize is the number of files returned per page by ListFiles, unless
+// requested otherwise.
+const DefaultPageSize = 100
+
+// fileService is the fake File Service.
+type fileService struct {
+	filev1connect.UnimplementedFileServiceHandler
+	s *Server
+}
+
+var _ filev1connect.FileServiceHandler = fileService{}
+
+func (fs fileService) GetFile(
+	_ context.Context,
+	req *connect.Request[filev1.GetFileRequest],
+) (*connect.Response[filev1.GetFileResponse], error) {
+	fs.s.mu.Lock()
+	defer fs.s.mu.Unlock()
+	e, err := fs.s.lookupLocked(req.Msg.Selector)
+	if err != nil {
+		return nil, err
+	}
+	file := fs.s.fileLocked(e, req.Msg.GetReturnOptions().GetIncludeParents())
+	return connect.NewResponse(&filev1.GetFileResponse{File: file}), nil
+}
+
+func (fs fileService) ListFiles(
+	_ context.Context,
+	req *connect.Request[filev1.ListFilesRequest],
+) (*connect.Response[filev1.ListFilesResponse], error) {
+	filter := req.Msg.GetFilter()
+	offset := 0
+	if cursor := req.Msg.GetPagination().GetCursor(); cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid cursor %q", cursor))
+		}
+		offset = n
+	}
+	pageSize := int(req.Msg.GetPagination().GetPageSize())
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	fs.s.mu.Lock()
+	defer fs.s.mu.Unlock()
+	var matches []*entry
+	for _, e := range fs.s.files {
+		switch {
+		case filter.ParentId != nil && e.file.GetParentId() != filter.GetParentId():
+		case filter.Favorite != nil && e.file.Favorite != filter.GetFavorite():
+		case filter.Shared != nil && (len(e.file.SharedWith) > 0) != filter.GetShared():
+		default:
+			matches = append(matches, e)
+		}
+	}
+	// Files are listed in order of creation.
+	sort.Slice(matches, func(i, j int) bool { return fileNumber(matches[i]) < fileNumber(matches[j]) })
+
+	resp := &filev1.ListFilesResponse{Pagination: &filev1.PaginationResponse{}}
+	includeParents := req.Msg.GetReturnOptions().GetIncludeParents()
+	for i := offset; i < len(matches) && i < offset+pageSize; i++ {
+		resp.Files = append(resp.Files, fs.s.fileLocked(matches[i], includeParents))
+	}
+	if offset+pageSize < len(matches) {
+		resp.Pagination.NextCursor = proto.String(strconv.Itoa(offset + pageSize))
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// fileNumber returns the sequence number of a file, from its ID.
+func fileNumber(e *entry) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(e.file.Id, "file_"))
+	return n
+}
+
+func (fs fileService) CreateFile(
+	_ context.Context,
+	stream *connect.ClientStream[filev1.CreateFileRequest],
+) (*connect.Response[filev1.CreateFileResponse], error) {
+	var (
+		meta    *filev1.CreateFileMeta
+		content []byte
+	)
+	for stream.Receive() {
+		if m := stream.Msg().Meta; m != nil {
+			meta = m
+		}
+		if chunk := stream.Msg().DataChunk; len(chunk) > 0 {
+			content = append(content, chunk...)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	if meta == nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("meta is required"))
+	}
+	file, err := fs.s.create(meta, content)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&filev1.CreateFileResponse{File: file}), nil
+}
+
+func (fs fileService) DeleteFile(
+	_ context.Context,
+	req *connect.Request[filev1.DeleteFileRequest],
+) (*connect.Response[filev1.DeleteFileResponse], error) {
+	fs.s.mu.Lock()
+	defer fs.s.mu.Unlock()
+	e, err := fs.s.lookupLocked(req.Msg.Selector)
+	if err != nil {
+		return nil, err
+	}
+	// Deleting a folder deletes everything within it.
+	for id, other := range fs.s.files {
+		if fs.s.withinLocked(other, e.file.Id) {
+			delete(fs.s.files, id)
+		}
+	}
+	delete(fs.s.files, e.file.Id)
+	return connect.NewResponse(&filev1.DeleteFileResponse{}), nil
+}
+
+func (fs fileService) UpdateFile(
+	_ context.Context,
+	req *connect.Request[filev1.UpdateFileRequest],
+) (*connect.Response[filev1.UpdateFileResponse], error) {
+	fs.s.mu.Lock()
+	defer fs.s.mu.Unlock()
+	e, err := fs.s.lookupLocked(req.Msg.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if req.Msg.ParentId != nil {
+		parentID := req.Msg.GetParentId()
+		if parentID != "" {
+			parent, ok := fs.s.files[parentID]
+			switch {
+			case !ok:
+				return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("parent %s not found", parentID))
+			case parent.content != nil:
+				return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("parent %s isn't a folder", parentID))
+			case parentID == e.file.Id || fs.s.withinLocked(parent, e.file.Id):
+				return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("a folder can't be moved into itself"))
+			}
+			e.file.ParentId = proto.String(parentID)
+		} else {
+			e.file.ParentId = nil
+		}
+	}
+	if req.Msg.Name != nil {
+		if req.Msg.GetName() == "" {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("name can't be empty"))
+		}
+		e.file.Name = req.Msg.GetName()
+	}
+	if req.Msg.Favorite != nil {
+		e.file.Favorite = req.Msg.GetFavorite()
+	}
+	e.file.UpdatedAt = timestamppb.New(fs.s.now())
+	file := fs.s.fileLocked(e, req.Msg.GetReturnOptions().GetIncludeParents())
+	return connect.NewResponse(&filev1.UpdateFileResponse{File: file}), nil
+}
+
+// serveUpload serves uploads made by operand.Client.CreateFile: multipart forms
+// with the name, parent_id and properties (encoded with protojson) of the file,
+// and its content in the file part, which is omitted for folders.
+func (s *Server) serveUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r.Header) {
+		http.Error(w, "invalid API key", http.StatusUnauthorized)
+		return
+	}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	meta := new(filev1.CreateFileMeta)
+	var content []byte
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch part.FormName() {
+		case "name":
+			meta.Name = string(value)
+		case "parent_id":
+			meta.ParentId = string(value)
+		case "properties":
+			meta.Properties = new(filev1.Properties)
+			if err := protojson.Unmarshal(value, meta.Properties); err != nil {
+				http.Error(w, fmt.Sprintf("invalid properties: %v", err), http.StatusBadRequest)
+				return
+			}
+		case "file":
+			content = value
+			if content == nil {
+				content = []byte{}
+			}
+		}
+	}
+	file, err := s.create(meta, content)
+	if err != nil {
+		http.Error(w, err.Error(), httpStatus(connect.CodeOf(err)))
+		return
+	}
+	body, err := protojson.Marshal(&filev1.CreateFileResponse{File: file})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// serveDownload serves the content of files, at their download URLs. Ranges are
+// supported.
+func (s *Server) serveDownload(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r.Header) {
+		http.Error(w, "invalid API key", http.StatusUnauthorized)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/download/")
+	s.mu.Lock()
+	e, ok := s.files[id]
+	var (
+		content []byte
+		name    string
+		updated time.Time
+	)
+	if ok {
+		content, name, updated = e.content, e.file.Name, e.file.UpdatedAt.AsTime()
+	}
+	s.mu.Unlock()
+	if content == nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, name, updated, bytes.NewReader(content))
+}
+
+// httpStatus maps the code of an error to the status of an upload which failed
+// with it.
+func httpStatus(code connect.Code) int {
+	switch code {
+	case connect.CodeInvalidArgument:
+		return http.StatusBadRequest
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,325 @@
+package operandtest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	operand "github.com/operandinc/go-sdk"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// RecordEnv is the environment variable which selects the mode of recorders (see
+// ModeFromEnv).
+const RecordEnv = "OPERANDTEST_RECORD"
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// Replay serves requests from the cassette, and fails those which weren't
+	// recorded, without making any calls to the API.
+	Replay Mode = iota
+	// Record makes requests to the API, and records them to the cassette.
+	Record
+)
+
+// ModeFromEnv returns Record if RecordEnv is set, and Replay otherwise, so that
+// cassettes are replayed in CI, and recorded again with e.g.
+//
+//	OPERANDTEST_RECORD=1 OPERAND_API_KEY=... go test ./...
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) != "" {
+		return Record
+	}
+	return Replay
+}
+
+// Interaction is a request to the API, and its response, as stored in a cassette.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded request.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body,omitempty"`
+	Trailer    http.Header `json:"trailer,omitempty"`
+}
+
+// Recorder is a cassette-style transport, which records the interactions of a
+// client with the API to a file (the cassette), and replays them, so that tests
+// against the live API can run hermetically, without credentials:
+//
+//	rec, err := operandtest.NewRecorder("testdata/search.json", operandtest.ModeFromEnv())
+//	...
+//	defer rec.Close()
+//	client := rec.Client(os.Getenv("OPERAND_API_KEY"))
+//
+// Credentials are scrubbed before interactions are recorded: the Authorization
+// header and API key headers of requests (see operand.DefaultRedactor), API keys in
+// the responses of unary RPCs, and the query string of URLs, which may be signed.
+//
+// Requests are replayed by matching their method and path, in the order they were
+// recorded, preferring interactions whose body matches too. The host and query
+// string are ignored, so that the endpoint needn't be set when replaying.
+type Recorder struct {
+	mode Mode
+	path string
+	base http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	replayed     []bool
+}
+
+// NewRecorder returns a recorder of the given cassette. In Replay mode, the
+// cassette is loaded, and must exist. In Record mode, it's written by Close.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path, base: http.DefaultTransport}
+	if mode == Replay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading cassette (record it with %s=1): %w", RecordEnv, err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("loading cassette %s: %w", path, err)
+		}
+		r.replayed = make([]bool, len(r.interactions))
+	}
+	return r, nil
+}
+
+// Mode returns the mode of the recorder, e.g. to skip tests which can't be
+// replayed.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// HTTPClient returns an HTTP client which records or replays its requests.
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Client returns a client of the API which records or replays its calls. The API
+// key is only used when recording. Options are applied after the HTTP client is
+// set.
+func (r *Recorder) Client(apiKey string, opts ...operand.Option) *operand.Client {
+	return operand.NewClient(apiKey, append([]operand.Option{operand.WithHTTPClient(r.HTTPClient())}, opts...)...)
+}
+
+// Close writes the cassette in Record mode.
+func (r *Recorder) Close() error {
+	if r.mode != Record {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.mode == Replay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+// replay serves a request from the cassette.
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	match := -1
+	for i, in := range r.interactions {
+		if r.replayed[i] || in.Request.Method != req.Method || urlPath(in.Request.URL) != req.URL.Path {
+			continue
+		}
+		if bytes.Equal(in.Request.Body, body) {
+			match = i
+			break
+		}
+		if match < 0 {
+			match = i
+		}
+	}
+	if match < 0 {
+		return nil, fmt.Errorf("operandtest: no recorded interaction for %s %s", req.Method, req.URL.Path)
+	}
+	r.replayed[match] = true
+	resp := r.interactions[match].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        resp.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Trailer:       resp.Trailer.Clone(),
+		Request:       req,
+	}, nil
+}
+
+// record makes a request to the API, and records it.
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := r.base.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	header := resp.Header.Clone()
+	if header.Get("Content-Encoding") == "gzip" {
+		// Responses are stored uncompressed, so that they can be scrubbed.
+		if respBody, err = gunzip(respBody); err != nil {
+			return nil, err
+		}
+		header.Del("Content-Encoding")
+		header.Del("Content-Length")
+	}
+	recorded := respBody
+	if resp.StatusCode == http.StatusOK {
+		recorded = scrubBody(req.URL.Path, header.Get("Content-Type"), respBody)
+	}
+
+	redactor := operand.DefaultRedactor()
+	r.mu.Lock()
+	r.interactions = append(r.interactions, &Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    scrubURL(req.URL.String()),
+			Header: redactor.Header(req.Header),
+			Body:   body,
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     redactor.Header(header),
+			Body:       recorded,
+			Trailer:    redactor.Header(resp.Trailer),
+		},
+	})
+	r.mu.Unlock()
+
+	resp.Header = header
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	resp.ContentLength = int64(len(respBody))
+	return resp, nil
+}
+
+// scrubBody masks the credentials in the response of a unary RPC, given the path
+// of its procedure. Other bodies are returned as is.
+func scrubBody(path, contentType string, body []byte) []byte {
+	var (
+		unmarshal func([]byte, proto.Message) error
+		marshal   func(proto.Message) ([]byte, error)
+	)
+	switch contentType {
+	case "application/proto":
+		unmarshal, marshal = proto.Unmarshal, proto.Marshal
+	case "application/json":
+		unmarshal, marshal = protojson.Unmarshal, protojson.Marshal
+	default:
+		return body
+	}
+	service, method, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		return body
+	}
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return body
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return body
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return body
+	}
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return body
+	}
+	msg := mt.New().Interface()
+	if err := unmarshal(body, msg); err != nil {
+		return body
+	}
+	// Content is kept, as it's what the tests check.
+	scrubbed, err := marshal((&operand.Redactor{ShowContent: true}).Message(msg))
+	if err != nil {
+		return body
+	}
+	return scrubbed
+}
+
+// scrubURL strips the query string from a URL, since it may hold a signature.
+func scrubURL(u string) string {
+	base, _, _ := strings.Cut(u, "?")
+	return base
+}
+
+// urlPath returns the path of a recorded URL.
+func urlPath(u string) string {
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+len("://"):]
+		if j := strings.Index(u, "/"); j >= 0 {
+			return u[j:]
+		}
+		return "/"
+	}
+	return u
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err = io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing response: %w", err)
+	}
+	return data, nil
+}
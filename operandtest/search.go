@@ -0,0 +1,105 @@
+package operandtest
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"github.com/operandinc/go-sdk/operand/v1/operandv1connect"
+)
+
+// DefaultMaxResults is the number of matches returned by Search, unless requested
+// otherwise.
+const DefaultMaxResults = 10
+
+// operandService is the fake Operand Service.
+type operandService struct {
+	operandv1connect.UnimplementedOperandServiceHandler
+	s *Server
+}
+
+var _ operandv1connect.OperandServiceHandler = operandService{}
+
+// Search matches the terms of the query against each line of the text files within
+// scope which satisfy the filter. Each file matches once, with its line containing
+// the most terms as the snippet, scored by the share of terms it contains, and
+// surrounded by up to AdjacentSnippets lines on either side.
+func (ops operandService) Search(
+	_ context.Context,
+	req *connect.Request[operandv1.SearchRequest],
+) (*connect.Response[operandv1.SearchResponse], error) {
+	resp := &operandv1.SearchResponse{Files: make(map[string]*filev1.File)}
+	terms := strings.Fields(strings.ToLower(req.Msg.Query))
+	if len(terms) == 0 {
+		return connect.NewResponse(resp), nil
+	}
+	maxResults := int(req.Msg.MaxResults)
+	if maxResults <= 0 {
+		maxResults = DefaultMaxResults
+	}
+	adjacent := int(req.Msg.GetAdjacentSnippets())
+	includeParents := req.Msg.GetFileReturnOptions().GetIncludeParents()
+
+	ops.s.mu.Lock()
+	defer ops.s.mu.Unlock()
+	for id, e := range ops.s.files {
+		if e.content == nil || !utf8.Valid(e.content) || !ops.s.withinLocked(e, req.Msg.GetParentId()) {
+			continue
+		}
+		if req.Msg.Filter != nil && !operand.MatchesFilter(req.Msg.Filter, e.file.Properties) {
+			continue
+		}
+		lines := strings.Split(string(e.content), "\n")
+		best, bestCount := -1, 0
+		for i, line := range lines {
+			lower := strings.ToLower(line)
+			count := 0
+			for _, term := range terms {
+				if strings.Contains(lower, term) {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = i, count
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		match := &operandv1.ContentMatch{
+			MatchId: id + ":" + strconv.Itoa(best),
+			FileId:  id,
+			Snippet: strings.TrimSpace(lines[best]),
+			Score:   float32(bestCount) / float32(len(terms)),
+		}
+		for i := max(best-adjacent, 0); i < best; i++ {
+			match.BeforeSnippets = append(match.BeforeSnippets, strings.TrimSpace(lines[i]))
+		}
+		for i := best + 1; i <= min(best+adjacent, len(lines)-1); i++ {
+			match.AfterSnippets = append(match.AfterSnippets, strings.TrimSpace(lines[i]))
+		}
+		resp.Matches = append(resp.Matches, match)
+		resp.Files[id] = ops.s.fileLocked(e, includeParents)
+	}
+	// Matches are ordered by score, then by file, so that results are deterministic.
+	sort.Slice(resp.Matches, func(i, j int) bool {
+		a, b := resp.Matches[i], resp.Matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return fileNumber(ops.s.files[a.FileId]) < fileNumber(ops.s.files[b.FileId])
+	})
+	if len(resp.Matches) > maxResults {
+		for _, m := range resp.Matches[maxResults:] {
+			delete(resp.Files, m.FileId)
+		}
+		resp.Matches = resp.Matches[:maxResults]
+	}
+	return connect.NewResponse(resp), nil
+}
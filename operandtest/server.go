@@ -0,0 +1,297 @@
+// Package operandtest provides an in-memory fake of the Operand API for tests, so
+// that code using the SDK can be tested without an API key or network access.
+//
+//	srv := operandtest.NewServer()
+//	defer srv.Close()
+//	client := srv.Client()
+//
+// The fake implements the semantics of the File Service (creating, getting,
+// listing, updating and deleting files, uploads through /upload, and downloads),
+// of searches with the Operand Service (keyword matching of lines of text files),
+// and of API keys and the authorized user with the Tenant Service. Other RPCs fail
+// with connect.CodeUnimplemented. Files are indexed as soon as they're created,
+// unless set otherwise with SetIndexingStatus.
+//
+// For tests against the live API, Recorder records their calls, and replays them
+// without credentials, e.g. in CI.
+package operandtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/operandinc/go-sdk/file/v1/filev1connect"
+	"github.com/operandinc/go-sdk/operand/v1/operandv1connect"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+	"github.com/operandinc/go-sdk/tenant/v1/tenantv1connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultAPIKey is the API key accepted by a new server.
+const DefaultAPIKey = "operandtest-key"
+
+// Server is an in-memory fake of the Operand API, served over HTTP.
+type Server struct {
+	// URL is the endpoint of the server, e.g. for operand.WithEndpoint.
+	URL string
+
+	srv *httptest.Server
+
+	handler http.Handler
+
+	mu        sync.Mutex
+	nextID    int
+	nextKeyID int
+	files     map[string]*entry
+	keys      map[string]*tenantv1.APIKey // Secret -> key.
+	user      *tenantv1.User
+	now       func() time.Time
+}
+
+// entry is a stored file.
+type entry struct {
+	file    *filev1.File // Without its download URL and parents.
+	content []byte       // Nil for folders.
+}
+
+// NewServer starts a new, empty server, which accepts DefaultAPIKey. It must be
+// closed once done with.
+func NewServer() *Server {
+	s := &Server{
+		files: make(map[string]*entry),
+		keys:  make(map[string]*tenantv1.APIKey),
+		now:   time.Now,
+	}
+	s.user = &tenantv1.User{
+		Profile:   &tenantv1.UserProfile{Id: "user_1", EmailAddress: "test@example.com"},
+		CreatedAt: timestamppb.New(s.now()),
+		Developer: true,
+	}
+	s.keys[DefaultAPIKey] = &tenantv1.APIKey{Id: "key_0", Name: "default", CreatedAt: timestamppb.New(s.now())}
+
+	mux := http.NewServeMux()
+	opts := connect.WithInterceptors(authInterceptor{s: s})
+	mux.Handle(filev1connect.NewFileServiceHandler(fileService{s: s}, opts))
+	mux.Handle(operandv1connect.NewOperandServiceHandler(operandService{s: s}, opts))
+	mux.Handle(tenantv1connect.NewTenantServiceHandler(tenantService{s: s}, opts))
+	mux.HandleFunc("/upload", s.serveUpload)
+	mux.HandleFunc("/download/", s.serveDownload)
+	s.handler = mux
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Handler returns the handler of the server, e.g. to mount it elsewhere.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Client returns a client of the server, authenticated with DefaultAPIKey.
+// Options are applied after the endpoint is set.
+func (s *Server) Client(opts ...operand.Option) *operand.Client {
+	return operand.NewClient(DefaultAPIKey, append([]operand.Option{operand.WithEndpoint(s.URL)}, opts...)...)
+}
+
+// SetClock sets the function which timestamps files. Defaults to time.Now.
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// SetIndexingStatus sets the indexing status of a file, e.g. to test code waiting
+// for files to be indexed.
+func (s *Server) SetIndexingStatus(id string, status filev1.IndexingStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.files[id]
+	if !ok {
+		return fmt.Errorf("file %s not found", id)
+	}
+	e.file.IndexingStatus = status
+	return nil
+}
+
+// Content returns the content of a file, and whether it exists and isn't a folder.
+func (s *Server) Content(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.files[id]
+	if !ok || e.content == nil {
+		return nil, false
+	}
+	return append([]byte(nil), e.content...), true
+}
+
+// Files returns all of the stored files and folders, ordered by ID.
+func (s *Server) Files() []*filev1.File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := make([]*filev1.File, 0, len(s.files))
+	for _, e := range s.files {
+		files = append(files, s.fileLocked(e, false))
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Id < files[j].Id })
+	return files
+}
+
+// create stores a new file, or a folder if content is nil.
+func (s *Server) create(meta *filev1.CreateFileMeta, content []byte) (*filev1.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if meta.GetName() == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("name is required"))
+	}
+	if parentID := meta.GetParentId(); parentID != "" {
+		parent, ok := s.files[parentID]
+		if !ok {
+			return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("parent %s not found", parentID))
+		}
+		if parent.content != nil {
+			return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("parent %s isn't a folder", parentID))
+		}
+	}
+	s.nextID++
+	now := timestamppb.New(s.now())
+	file := &filev1.File{
+		Id:             fmt.Sprintf("file_%d", s.nextID),
+		Name:           meta.GetName(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		LastAccessedAt: now,
+		IndexingStatus: filev1.IndexingStatus_INDEXING_STATUS_READY,
+		Creator:        s.user.Profile,
+		Role:           filev1.SharingRole_SHARING_ROLE_OWNER,
+		Properties:     meta.GetProperties(),
+	}
+	if meta.GetParentId() != "" {
+		file.ParentId = proto.String(meta.GetParentId())
+	}
+	if content != nil {
+		file.SizeBytes = proto.Int64(int64(len(content)))
+	}
+	e := &entry{file: file, content: content}
+	s.files[file.Id] = e
+	return s.fileLocked(e, false), nil
+}
+
+// lookupLocked returns the file selected by a selector.
+func (s *Server) lookupLocked(sel *filev1.FileSelector) (*entry, error) {
+	switch sel := sel.GetSelector().(type) {
+	case *filev1.FileSelector_Id:
+		if e, ok := s.files[sel.Id]; ok {
+			return e, nil
+		}
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("file %s not found", sel.Id))
+	case *filev1.FileSelector_ByName_:
+		for _, e := range s.files {
+			if e.file.Name == sel.ByName.GetName() && e.file.GetParentId() == sel.ByName.GetParentId() {
+				return e, nil
+			}
+		}
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("file %q not found", sel.ByName.GetName()))
+	default:
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("selector is required"))
+	}
+}
+
+// fileLocked returns a copy of a stored file, with its download URL, and its
+// parents if requested.
+func (s *Server) fileLocked(e *entry, parents bool) *filev1.File {
+	file := proto.Clone(e.file).(*filev1.File)
+	if e.content != nil {
+		file.DownloadUrl = s.URL + "/download/" + file.Id
+	}
+	if parents {
+		for id := file.GetParentId(); id != ""; {
+			parent, ok := s.files[id]
+			if !ok {
+				break
+			}
+			file.Parents = append(file.Parents, s.fileLocked(parent, false))
+			id = parent.file.GetParentId()
+		}
+	}
+	return file
+}
+
+// withinLocked reports whether a file is below the given folder (empty for the
+// root).
+func (s *Server) withinLocked(e *entry, folderID string) bool {
+	if folderID == "" {
+		return true
+	}
+	for id := e.file.GetParentId(); id != ""; {
+		if id == folderID {
+			return true
+		}
+		parent, ok := s.files[id]
+		if !ok {
+			return false
+		}
+		id = parent.file.GetParentId()
+	}
+	return false
+}
+
+// authorized reports whether a request carries a valid API key.
+func (s *Server) authorized(header http.Header) bool {
+	key, ok := strings.CutPrefix(header.Get("Authorization"), "Key ")
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok = s.keys[key]
+	return ok
+}
+
+// authInterceptor rejects RPCs without a valid API key.
+type authInterceptor struct {
+	s *Server
+}
+
+var _ connect.Interceptor = authInterceptor{}
+
+func (ai authInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if !ai.s.authorized(req.Header()) {
+			return nil, errUnauthenticated()
+		}
+		return next(ctx, req)
+	}
+}
+
+func (authInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next // No-op (handler-only interceptor).
+}
+
+func (ai authInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if !ai.s.authorized(conn.RequestHeader()) {
+			return errUnauthenticated()
+		}
+		return next(ctx, conn)
+	}
+}
+
+func errUnauthenticated() error {
+	return connect.NewError(connect.CodeUnauthenticated, errors.New("invalid API key"))
+}
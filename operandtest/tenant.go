@@ -0,0 +1,116 @@
+package operandtest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+	"github.com/operandinc/go-sdk/tenant/v1/tenantv1connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// tenantService is the fake Tenant Service.
+type tenantService struct {
+	tenantv1connect.UnimplementedTenantServiceHandler
+	s *Server
+}
+
+var _ tenantv1connect.TenantServiceHandler = tenantService{}
+
+func (ts tenantService) AuthorizedUser(
+	context.Context,
+	*connect.Request[tenantv1.AuthorizedUserRequest],
+) (*connect.Response[tenantv1.AuthorizedUserResponse], error) {
+	ts.s.mu.Lock()
+	defer ts.s.mu.Unlock()
+	return connect.NewResponse(&tenantv1.AuthorizedUserResponse{User: proto.Clone(ts.s.user).(*tenantv1.User)}), nil
+}
+
+func (ts tenantService) UpdateUser(
+	_ context.Context,
+	req *connect.Request[tenantv1.UpdateUserRequest],
+) (*connect.Response[tenantv1.UpdateUserResponse], error) {
+	ts.s.mu.Lock()
+	defer ts.s.mu.Unlock()
+	if req.Msg.FirstName != nil {
+		ts.s.user.Profile.FirstName = proto.String(req.Msg.GetFirstName())
+	}
+	if req.Msg.LastName != nil {
+		ts.s.user.Profile.LastName = proto.String(req.Msg.GetLastName())
+	}
+	if req.Msg.Developer != nil {
+		ts.s.user.Developer = req.Msg.GetDeveloper()
+	}
+	return connect.NewResponse(&tenantv1.UpdateUserResponse{User: proto.Clone(ts.s.user).(*tenantv1.User)}), nil
+}
+
+// CreateAPIKey creates a key, which the server accepts from then on.
+func (ts tenantService) CreateAPIKey(
+	_ context.Context,
+	req *connect.Request[tenantv1.CreateAPIKeyRequest],
+) (*connect.Response[tenantv1.CreateAPIKeyResponse], error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	secret := hex.EncodeToString(b[:])
+
+	ts.s.mu.Lock()
+	defer ts.s.mu.Unlock()
+	ts.s.nextKeyID++
+	key := &tenantv1.APIKey{
+		Id:        "key_" + strconv.Itoa(ts.s.nextKeyID),
+		Name:      req.Msg.Name,
+		CreatedAt: timestamppb.New(ts.s.now()),
+	}
+	ts.s.keys[secret] = key
+	created := proto.Clone(key).(*tenantv1.APIKey)
+	created.Token = &tenantv1.APIKey_Full{Full: secret}
+	return connect.NewResponse(&tenantv1.CreateAPIKeyResponse{Key: created}), nil
+}
+
+// ListAPIKeys lists keys with the first characters of their secret.
+func (ts tenantService) ListAPIKeys(
+	context.Context,
+	*connect.Request[tenantv1.ListAPIKeysRequest],
+) (*connect.Response[tenantv1.ListAPIKeysResponse], error) {
+	ts.s.mu.Lock()
+	defer ts.s.mu.Unlock()
+	resp := &tenantv1.ListAPIKeysResponse{}
+	for secret, key := range ts.s.keys {
+		listed := proto.Clone(key).(*tenantv1.APIKey)
+		listed.Token = &tenantv1.APIKey_Partial{Partial: secret[:min(len(secret), 4)]}
+		resp.Keys = append(resp.Keys, listed)
+	}
+	sort.Slice(resp.Keys, func(i, j int) bool { return keyNumber(resp.Keys[i]) < keyNumber(resp.Keys[j]) })
+	return connect.NewResponse(resp), nil
+}
+
+// keyNumber returns the sequence number of a key, from its ID.
+func keyNumber(key *tenantv1.APIKey) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(key.Id, "key_"))
+	return n
+}
+
+// DeleteAPIKey deletes a key, which the server rejects from then on.
+func (ts tenantService) DeleteAPIKey(
+	_ context.Context,
+	req *connect.Request[tenantv1.DeleteAPIKeyRequest],
+) (*connect.Response[tenantv1.DeleteAPIKeyResponse], error) {
+	ts.s.mu.Lock()
+	defer ts.s.mu.Unlock()
+	for secret, key := range ts.s.keys {
+		if key.Id == req.Msg.Id {
+			delete(ts.s.keys, secret)
+			return connect.NewResponse(&tenantv1.DeleteAPIKeyResponse{}), nil
+		}
+	}
+	return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("key %s not found", req.Msg.Id))
+}
@@ -0,0 +1,71 @@
+package operand
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/bufbuild/connect-go"
+)
+
+// HeaderOperationID carries the operation ID of a call (see WithOperationID).
+const HeaderOperationID = "Operand-Operation-Id"
+
+type operationIDKey struct{}
+
+// WithOperationID returns a context whose calls belong to the operation with the
+// given ID. The ID is sent with each attempt of each call, so that the server-side
+// and client-side records of a logical operation, including any retries, can be
+// correlated. If a context has no operation ID, the client generates one for each
+// call (e.g. each Search or CreateFile, including any RPCs they make internally).
+func WithOperationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, operationIDKey{}, id)
+}
+
+// OperationID returns the operation ID of a context, if any.
+func OperationID(ctx context.Context) string {
+	id, _ := ctx.Value(operationIDKey{}).(string)
+	return id
+}
+
+// withOperation returns the context with an operation ID, generating one if
+// required.
+func (c *Client) withOperation(ctx context.Context) context.Context {
+	if OperationID(ctx) != "" {
+		return ctx
+	}
+	var b [8]byte
+	c.rand.Read(b[:])
+	return WithOperationID(ctx, hex.EncodeToString(b[:]))
+}
+
+// setOperationID sets the header carrying the context's operation ID.
+func setOperationID(ctx context.Context, header http.Header) {
+	if id := OperationID(ctx); id != "" {
+		header.Set(HeaderOperationID, id)
+	}
+}
+
+// operationInterceptor assigns operation IDs to RPCs. It sits outside of any
+// interceptors which retry calls, so that the ID is constant across attempts.
+type operationInterceptor struct {
+	c *Client
+}
+
+var _ connect.Interceptor = operationInterceptor{}
+
+func (oi operationInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		return next(oi.c.withOperation(ctx), req)
+	}
+}
+
+func (oi operationInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		return next(oi.c.withOperation(ctx), spec)
+	}
+}
+
+func (operationInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
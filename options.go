@@ -0,0 +1,76 @@
+package operand
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+)
+
+// DefaultEndpoint is the endpoint of the Operand API.
+const DefaultEndpoint = "https://mcp.operand.ai"
+
+// Option configures a Client on construction (see NewClient).
+type Option func(*Client)
+
+// WithEndpoint sets the endpoint of the API. Defaults to DefaultEndpoint.
+func WithEndpoint(endpoint string) Option {
+	return func(c *Client) { c.endpoint.Store(newEndpoint(endpoint)) }
+}
+
+// WithHTTPClient sets the HTTP client used to make calls. Defaults to
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithTimeout bounds each RPC and upload made without a deadline of its own.
+// Downloads aren't bounded, as their content is streamed to the caller.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.timeout = timeout }
+}
+
+// WithInterceptors adds interceptors to the RPCs made by the client, after (i.e.
+// within) the client's own.
+func WithInterceptors(interceptors ...connect.Interceptor) Option {
+	return func(c *Client) { c.interceptors = append(c.interceptors, interceptors...) }
+}
+
+// WithUserAgent sets the User-Agent header of the client's requests, e.g. to
+// identify the application to the API.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// callTimeout applies the client's timeout to the context, unless it already has a
+// deadline.
+func (c *Client) callTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// timeoutInterceptor applies the client's timeout to unary RPCs.
+type timeoutInterceptor struct {
+	c *Client
+}
+
+var _ connect.Interceptor = timeoutInterceptor{}
+
+func (ti timeoutInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, cancel := ti.c.callTimeout(ctx)
+		defer cancel()
+		return next(ctx, req)
+	}
+}
+
+func (timeoutInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next // Streams live as long as their caller needs them to.
+}
+
+func (timeoutInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
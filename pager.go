@@ -0,0 +1,76 @@
+package operand
+
+import (
+	"context"
+)
+
+// PageFunc fetches the page of a listing at the given cursor (nil for the first
+// page), returning its items, and the cursor of the next page, which is nil or
+// empty after the last page.
+type PageFunc[T any] func(ctx context.Context, cursor *string) (items []T, next *string, err error)
+
+// Pager iterates over the items of a paginated listing, fetching pages as
+// required:
+//
+//	for p.Next() {
+//		item := p.Item()
+//		...
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+//
+// It's used by the iterators of the client's list RPCs (e.g. IterFiles), and can
+// be used with any other listing through NewPager.
+type Pager[T any] struct {
+	ctx   context.Context
+	fetch PageFunc[T]
+
+	cursor *string
+	page   []T
+	item   T
+	done   bool // Set once the last page has been fetched.
+	err    error
+}
+
+// NewPager returns a pager over the pages fetched by the given function, starting
+// from the given cursor (nil for the first page).
+func NewPager[T any](ctx context.Context, cursor *string, fetch PageFunc[T]) *Pager[T] {
+	return &Pager[T]{ctx: ctx, fetch: fetch, cursor: cursor}
+}
+
+// Next advances to the next item, fetching the next page if required. It returns
+// false once there are no more items, or fetching a page failed (see Err).
+func (p *Pager[T]) Next() bool {
+	for len(p.page) == 0 {
+		if p.done || p.err != nil {
+			var zero T
+			p.item = zero
+			return false
+		}
+		p.page, p.cursor, p.err = p.fetch(p.ctx, p.cursor)
+		p.done = p.cursor == nil || *p.cursor == ""
+	}
+	p.item, p.page = p.page[0], p.page[1:]
+	return true
+}
+
+// Item returns the current item.
+func (p *Pager[T]) Item() T {
+	return p.item
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// All drains the remaining items into a slice. If fetching a page fails, the items
+// fetched so far are returned along with the error.
+func (p *Pager[T]) All() ([]T, error) {
+	var items []T
+	for p.Next() {
+		items = append(items, p.Item())
+	}
+	return items, p.Err()
+}
@@ -0,0 +1,117 @@
+package operand
+
+import (
+	"context"
+	"sort"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+// DocumentMatch is a document matched by SearchDocuments, along with its best chunks.
+type DocumentMatch struct {
+	// Document is the original file. For chunked files (see CreateChunkedFile), this
+	// is the folder holding the chunks; otherwise it's the matching file itself.
+	Document *filev1.File
+	// Chunks are the best matches within the document, in relevance order.
+	Chunks []*operandv1.ContentMatch
+	// Files holds the chunk files referenced by Chunks, keyed by ID.
+	Files map[string]*filev1.File
+	// Score is the score of the best chunk.
+	Score float32
+}
+
+// SearchDocuments searches over chunks, but returns the documents they belong to,
+// each with up to chunks of its best matching chunks attached (i.e. small-to-big
+// retrieval). WithMaxResults limits the number of documents returned, rather than
+// the number of matches.
+func (c *Client) SearchDocuments(
+	ctx context.Context,
+	query string,
+	chunks int,
+	opts ...SearchOption,
+) ([]*DocumentMatch, error) {
+	o := new(searchOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	if chunks <= 0 {
+		chunks = 1
+	}
+
+	// Ask for enough matches to fill each document, assuming some overlap.
+	candidates := int32(DefaultRerankCandidates)
+	if o.maxResults > 0 {
+		candidates = o.maxResults * int32(chunks) * 2
+	}
+	opts = append(opts[:len(opts):len(opts)], WithMaxResults(candidates))
+	resp, err := c.Search(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		documents []*DocumentMatch
+		byID      = make(map[string]*DocumentMatch)
+		parents   = make(map[string]*filev1.File)
+	)
+	for _, m := range resp.Matches {
+		file, ok := resp.Files[m.FileId]
+		if !ok {
+			continue
+		}
+		document := file
+		if _, chunked := ChunkSpan(file); chunked {
+			if document, err = c.parentFile(ctx, file.GetParentId(), parents); err != nil {
+				return nil, err
+			}
+		}
+
+		dm, ok := byID[document.Id]
+		if !ok {
+			dm = &DocumentMatch{
+				Document: document,
+				Files:    make(map[string]*filev1.File),
+			}
+			byID[document.Id] = dm
+			documents = append(documents, dm)
+		}
+		if len(dm.Chunks) >= chunks {
+			continue
+		}
+		dm.Chunks = append(dm.Chunks, m)
+		dm.Files[file.Id] = file
+		if m.Score > dm.Score || len(dm.Chunks) == 1 {
+			dm.Score = m.Score
+		}
+	}
+
+	for _, dm := range documents {
+		sort.SliceStable(dm.Chunks, func(i, j int) bool {
+			return dm.Chunks[i].Score > dm.Chunks[j].Score
+		})
+	}
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i].Score > documents[j].Score
+	})
+	if o.maxResults > 0 && len(documents) > int(o.maxResults) {
+		documents = documents[:o.maxResults]
+	}
+	return documents, nil
+}
+
+// parentFile returns the file with the given ID, using the cache if possible.
+func (c *Client) parentFile(ctx context.Context, id string, cache map[string]*filev1.File) (*filev1.File, error) {
+	if file, ok := cache[id]; ok {
+		return file, nil
+	}
+	resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	cache[id] = resp.Msg.File
+	return resp.Msg.File, nil
+}
@@ -0,0 +1,93 @@
+// Package parquetexport writes file metadata and search events to Parquet files,
+// for analysis with tools such as DuckDB, Spark, or Arrow-based dataframes (which
+// read Parquet natively).
+package parquetexport
+
+import (
+	"context"
+	"io"
+
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/parquet-go/parquet-go"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// FileRow is the schema of the rows written by WriteFiles.
+type FileRow struct {
+	ID             string `parquet:"id"`
+	ParentID       string `parquet:"parent_id"`
+	Name           string `parquet:"name"`
+	Path           string `parquet:"path"`
+	Folder         bool   `parquet:"folder"`
+	SizeBytes      int64  `parquet:"size_bytes"`
+	IndexingStatus string `parquet:"indexing_status,dict"`
+	CreatedAt      int64  `parquet:"created_at,timestamp"`
+	UpdatedAt      int64  `parquet:"updated_at,timestamp"`
+	// Properties are the file's properties, encoded as a JSON object.
+	Properties string `parquet:"properties"`
+}
+
+// SearchRow is the schema of the rows written by WriteSearchEvents.
+type SearchRow struct {
+	Time       int64  `parquet:"time,timestamp"`
+	Query      string `parquet:"query"`
+	ParentID   string `parquet:"parent_id"`
+	Filtered   bool   `parquet:"filtered"`
+	MaxResults int32  `parquet:"max_results"`
+	Matches    int32  `parquet:"matches"`
+	DurationMS int64  `parquet:"duration_ms"`
+	Error      string `parquet:"error,optional"`
+}
+
+// WriteFiles writes the metadata of every file and folder below the given folder
+// (empty for the root) to w as Parquet, streaming rows as the tree is walked.
+func WriteFiles(ctx context.Context, client *operand.Client, rootID string, w io.Writer) error {
+	pw := parquet.NewGenericWriter[FileRow](w)
+	err := client.Walk(ctx, rootID, func(file *filev1.File, path string) error {
+		row := FileRow{
+			ID:             file.Id,
+			ParentID:       file.GetParentId(),
+			Name:           file.Name,
+			Path:           path,
+			Folder:         operand.IsFolder(file),
+			SizeBytes:      file.GetSizeBytes(),
+			IndexingStatus: file.IndexingStatus.String(),
+			CreatedAt:      file.GetCreatedAt().AsTime().UnixMilli(),
+			UpdatedAt:      file.GetUpdatedAt().AsTime().UnixMilli(),
+			Properties:     "{}",
+		}
+		if len(file.GetProperties().GetProperties()) > 0 {
+			properties, err := protojson.Marshal(file.Properties)
+			if err != nil {
+				return err
+			}
+			row.Properties = string(properties)
+		}
+		_, err := pw.Write([]FileRow{row})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+// WriteSearchEvents writes search events, e.g. those captured by an
+// operand.SearchEventRecorder, to w as Parquet.
+func WriteSearchEvents(events []*operand.SearchEvent, w io.Writer) error {
+	rows := make([]SearchRow, len(events))
+	for i, e := range events {
+		rows[i] = SearchRow{
+			Time:       e.Time.UnixMilli(),
+			Query:      e.Query,
+			ParentID:   e.ParentID,
+			Filtered:   e.Filtered,
+			MaxResults: e.MaxResults,
+			Matches:    int32(e.Matches),
+			DurationMS: e.Duration.Milliseconds(),
+			Error:      e.Err,
+		}
+	}
+	return parquet.Write(w, rows)
+}
@@ -0,0 +1,100 @@
+package operand
+
+import (
+	"context"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// DefaultPreviewBytes is the number of bytes of content fetched by Preview, by
+// default.
+const DefaultPreviewBytes = 4 << 10
+
+// FilePreview is the first portion of the content of a file, for display in list
+// views.
+type FilePreview struct {
+	File *filev1.File
+	// Content is the first portion of the raw content.
+	Content     []byte
+	ContentType string
+	// Text is a plain-text rendering of the content, with markup stripped and
+	// whitespace collapsed. It's empty for binary formats such as PDFs.
+	Text string
+	// Truncated reports whether the file has more content than was fetched.
+	Truncated bool
+}
+
+var (
+	htmlSkipPattern = regexp.MustCompile(`(?is)<(script|style)\b.*?(</(script|style)>|$)`)
+	htmlTagPattern  = regexp.MustCompile(`(?s)<[^>]*(>|$)`)
+)
+
+// Preview fetches the first maxBytes bytes (DefaultPreviewBytes if zero) of the
+// content of a file, using a range request where the server supports it, so that
+// large files aren't downloaded in full.
+func (c *Client) Preview(ctx context.Context, fileID string, maxBytes int64) (*FilePreview, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultPreviewBytes
+	}
+	resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: fileID}},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	p := &FilePreview{File: resp.Msg.File}
+	if IsFolder(p.File) {
+		return p, nil
+	}
+
+	body, err := c.downloadRange(ctx, p.File.DownloadUrl, 0, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Body.Close()
+	// Read one more byte than required, in case the range was ignored.
+	if p.Content, err = io.ReadAll(io.LimitReader(body.Body, maxBytes+1)); err != nil {
+		return nil, err
+	}
+	if int64(len(p.Content)) > maxBytes {
+		p.Content = p.Content[:maxBytes]
+	}
+	p.Truncated = p.File.GetSizeBytes() > int64(len(p.Content))
+
+	p.ContentType = body.Header.Get("Content-Type")
+	if p.ContentType == "" || p.ContentType == "application/octet-stream" {
+		p.ContentType = http.DetectContentType(p.Content)
+	}
+	p.Text = previewText(p.ContentType, p.Content)
+	return p, nil
+}
+
+// previewText renders content as plain text.
+func previewText(contentType string, content []byte) string {
+	// Drop a rune cut off by the end of the range.
+	for i := 0; i < utf8.UTFMax && len(content) > 0 && !utf8.Valid(content); i++ {
+		content = content[:len(content)-1]
+	}
+	if !utf8.Valid(content) {
+		return ""
+	}
+	text := string(content)
+	switch {
+	case strings.HasPrefix(contentType, "text/html"), strings.HasPrefix(contentType, "application/xhtml"):
+		text = htmlSkipPattern.ReplaceAllString(text, " ")
+		text = html.UnescapeString(htmlTagPattern.ReplaceAllString(text, " "))
+	case strings.HasPrefix(contentType, "text/"),
+		strings.HasPrefix(contentType, "application/json"),
+		strings.HasPrefix(contentType, "application/xml"):
+	default:
+		return ""
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
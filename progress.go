@@ -0,0 +1,72 @@
+package operand
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// UploadProgressFunc is called as the content of an upload is sent, with the number
+// of bytes sent so far, and the total number of bytes, or -1 if it isn't known.
+type UploadProgressFunc func(sent, total int64)
+
+type uploadProgressKey struct{}
+
+// WithUploadProgress returns a context with which CreateFile (and the helpers built
+// on it, such as CreateChunkedFile and UploadDir) report the progress of each upload
+// to fn. Calls are made from the goroutine writing the request body, so fn should
+// return quickly. ResumableUpload reports the progress of the upload as a whole.
+func WithUploadProgress(ctx context.Context, fn UploadProgressFunc) context.Context {
+	return context.WithValue(ctx, uploadProgressKey{}, fn)
+}
+
+func uploadProgress(ctx context.Context) UploadProgressFunc {
+	fn, _ := ctx.Value(uploadProgressKey{}).(UploadProgressFunc)
+	return fn
+}
+
+// progressReader wraps the data of an upload to report its progress, if requested.
+func progressReader(ctx context.Context, data io.Reader) io.Reader {
+	fn := uploadProgress(ctx)
+	if fn == nil || data == nil {
+		return data
+	}
+	return &countingReader{r: data, total: readerSize(data), fn: fn}
+}
+
+// readerSize returns the number of bytes remaining in a reader, or -1 if it isn't
+// known.
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }: // E.g. *bytes.Reader, *bytes.Buffer, *strings.Reader.
+		return int64(r.Len())
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	default:
+		return -1
+	}
+}
+
+type countingReader struct {
+	r     io.Reader
+	sent  int64
+	total int64
+	fn    UploadProgressFunc
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	if n > 0 {
+		cr.sent += int64(n)
+		cr.fn(cr.sent, cr.total)
+	}
+	return n, err
+}
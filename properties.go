@@ -0,0 +1,126 @@
+package operand
+
+import (
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+// Properties set by the SDK itself are prefixed with "operand_", to avoid colliding
+// with properties set by users.
+const (
+	// PropertyExternalID holds the ID of a file within its source system.
+	PropertyExternalID = "operand_external_id"
+	// PropertySourceURL holds the URL a file was fetched from.
+	PropertySourceURL = "operand_source_url"
+)
+
+// TextProperty returns a text property.
+func TextProperty(v string) *filev1.Property {
+	return &filev1.Property{Value: &filev1.Property_Text{Text: v}}
+}
+
+// NumberProperty returns a number property.
+func NumberProperty(v float64) *filev1.Property {
+	return &filev1.Property{Value: &filev1.Property_Number{Number: v}}
+}
+
+// TimeProperty returns a text property holding a time, in the canonical encoding
+// of times in properties: RFC 3339 in UTC, with as many fractional digits as
+// required.
+func TimeProperty(t time.Time) *filev1.Property {
+	return TextProperty(t.UTC().Format(time.RFC3339Nano))
+}
+
+// DurationProperty returns a number property holding a duration, in the canonical
+// encoding of durations in properties: nanoseconds. Unlike times, durations can be
+// filtered by range (see DurationRange).
+func DurationProperty(d time.Duration) *filev1.Property {
+	return NumberProperty(float64(d))
+}
+
+// TextArrayProperty returns a text array property.
+func TextArrayProperty(v ...string) *filev1.Property {
+	return &filev1.Property{Value: &filev1.Property_TextArray{
+		TextArray: &filev1.TextArray{Values: v},
+	}}
+}
+
+// NumberArrayProperty returns a number array property.
+func NumberArrayProperty(v ...float64) *filev1.Property {
+	return &filev1.Property{Value: &filev1.Property_NumberArray{
+		NumberArray: &filev1.NumberArray{Values: v},
+	}}
+}
+
+// PropertyText returns the value of a text property, if present.
+func PropertyText(properties *filev1.Properties, key string) (string, bool) {
+	v, ok := properties.GetProperties()[key].GetValue().(*filev1.Property_Text)
+	if !ok {
+		return "", false
+	}
+	return v.Text, true
+}
+
+// PropertyNumber returns the value of a number property, if present.
+func PropertyNumber(properties *filev1.Properties, key string) (float64, bool) {
+	v, ok := properties.GetProperties()[key].GetValue().(*filev1.Property_Number)
+	if !ok {
+		return 0, false
+	}
+	return v.Number, true
+}
+
+// PropertyTime returns the value of a time property (see TimeProperty), if present
+// and valid.
+func PropertyTime(properties *filev1.Properties, key string) (time.Time, bool) {
+	v, ok := PropertyText(properties, key)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// PropertyDuration returns the value of a duration property (see
+// DurationProperty), if present.
+func PropertyDuration(properties *filev1.Properties, key string) (time.Duration, bool) {
+	v, ok := PropertyNumber(properties, key)
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(v), true
+}
+
+// DurationRange returns a filter condition matching duration properties (see
+// DurationProperty) within [min, max). A zero bound is open, e.g. DurationRange(k,
+// time.Hour, 0) matches durations of an hour or more.
+func DurationRange(key string, min, max time.Duration) *operandv1.Condition {
+	r := &operandv1.Range{Key: key}
+	if min != 0 {
+		gte := float64(min)
+		r.Gte = &gte
+	}
+	if max != 0 {
+		lt := float64(max)
+		r.Lt = &lt
+	}
+	return &operandv1.Condition{Condition: &operandv1.Condition_Range{Range: r}}
+}
+
+// SetProperty sets a property, allocating the properties if required. It returns
+// the (possibly newly allocated) properties.
+func SetProperty(properties *filev1.Properties, key string, value *filev1.Property) *filev1.Properties {
+	if properties == nil {
+		properties = new(filev1.Properties)
+	}
+	if properties.Properties == nil {
+		properties.Properties = make(map[string]*filev1.Property)
+	}
+	properties.Properties[key] = value
+	return properties
+}
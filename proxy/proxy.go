@@ -0,0 +1,169 @@
+// Package proxy contains scaffolding for exposing (a subset of) the Operand API from
+// your own gateway. Incoming requests are authenticated with your own scheme, and
+// then forwarded to Operand using a client chosen for the caller, e.g. one holding
+// the API key of the caller's tenant.
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/operandinc/go-sdk/file/v1/filev1connect"
+	"github.com/operandinc/go-sdk/operand/v1/operandv1connect"
+	"github.com/operandinc/go-sdk/tenant/v1/tenantv1connect"
+)
+
+// Authenticator authenticates an incoming request, and returns the client used to
+// forward it to Operand. Errors are returned to the caller as-is, so they should
+// usually be connect errors with CodeUnauthenticated or CodePermissionDenied.
+type Authenticator func(ctx context.Context, procedure string, header http.Header) (*operand.Client, error)
+
+// Config configures the proxy.
+type Config struct {
+	// Authenticate is called for every incoming request. Required.
+	Authenticate Authenticator
+	// Allow lists the procedures which may be called, e.g.
+	// "/file.v1.FileService/GetFile". If empty, all procedures are allowed.
+	Allow []string
+	// HandlerOptions are applied to all of the handlers, after the proxy's own
+	// authentication interceptor.
+	HandlerOptions []connect.HandlerOption
+}
+
+// Mount registers handlers for all of the Operand services on the mux.
+func Mount(mux *http.ServeMux, cfg Config) {
+	opts := handlerOptions(cfg)
+	mux.Handle(filev1connect.NewFileServiceHandler(fileService{}, opts...))
+	mux.Handle(tenantv1connect.NewTenantServiceHandler(tenantService{}, opts...))
+	mux.Handle(operandv1connect.NewOperandServiceHandler(operandService{}, opts...))
+}
+
+// NewFileServiceHandler returns a handler for the File Service, which can be mounted
+// on its returned path.
+func NewFileServiceHandler(cfg Config) (string, http.Handler) {
+	return filev1connect.NewFileServiceHandler(fileService{}, handlerOptions(cfg)...)
+}
+
+// NewTenantServiceHandler returns a handler for the Tenant Service, which can be
+// mounted on its returned path.
+func NewTenantServiceHandler(cfg Config) (string, http.Handler) {
+	return tenantv1connect.NewTenantServiceHandler(tenantService{}, handlerOptions(cfg)...)
+}
+
+// NewOperandServiceHandler returns a handler for the Operand Service, which can be
+// mounted on its returned path.
+func NewOperandServiceHandler(cfg Config) (string, http.Handler) {
+	return operandv1connect.NewOperandServiceHandler(operandService{}, handlerOptions(cfg)...)
+}
+
+func handlerOptions(cfg Config) []connect.HandlerOption {
+	g := &gateway{authenticate: cfg.Authenticate}
+	if len(cfg.Allow) > 0 {
+		g.allowed = make(map[string]bool, len(cfg.Allow))
+		for _, procedure := range cfg.Allow {
+			g.allowed[procedure] = true
+		}
+	}
+	return append([]connect.HandlerOption{connect.WithInterceptors(g)}, cfg.HandlerOptions...)
+}
+
+type upstreamKey struct{}
+
+// upstream returns the client the current request should be forwarded with.
+func upstream(ctx context.Context) *operand.Client {
+	return ctx.Value(upstreamKey{}).(*operand.Client)
+}
+
+// gateway is an interceptor which enforces the allowlist, and authenticates requests.
+type gateway struct {
+	authenticate Authenticator
+	allowed      map[string]bool // Nil if everything is allowed.
+}
+
+var _ connect.Interceptor = (*gateway)(nil)
+
+func (g *gateway) admit(ctx context.Context, procedure string, header http.Header) (context.Context, error) {
+	if g.allowed != nil && !g.allowed[procedure] {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("procedure not allowed"))
+	}
+	client, err := g.authenticate(ctx, procedure, header)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, upstreamKey{}, client), nil
+}
+
+func (g *gateway) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, err := g.admit(ctx, req.Spec().Procedure, req.Header())
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (g *gateway) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next // No-op (handler-only interceptor).
+}
+
+func (g *gateway) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := g.admit(ctx, conn.Spec().Procedure, conn.RequestHeader())
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+// forward forwards a unary request upstream. Only the messages are forwarded, not
+// the headers, so that e.g. the caller's credentials never reach Operand.
+func forward[Req, Res any](
+	ctx context.Context,
+	req *connect.Request[Req],
+	call func(context.Context, *connect.Request[Req]) (*connect.Response[Res], error),
+) (*connect.Response[Res], error) {
+	resp, err := call(ctx, connect.NewRequest(req.Msg))
+	if err != nil {
+		return nil, forwardError(err)
+	}
+	return connect.NewResponse(resp.Msg), nil
+}
+
+// deleteFile deletes the selected file with Client.DeleteFile, rather than the raw
+// RPC, so that legal holds are enforced. Files protected by a hold fail with
+// connect.CodeFailedPrecondition.
+func deleteFile(ctx context.Context, client *operand.Client, selector *filev1.FileSelector) error {
+	id := selector.GetId()
+	if id == "" {
+		resp, err := client.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{Selector: selector}))
+		if err != nil {
+			return err
+		}
+		id = resp.Msg.File.Id
+	}
+	err := client.DeleteFile(ctx, id)
+	if errors.Is(err, operand.ErrProtected) {
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	}
+	return err
+}
+
+// forwardError strips the upstream metadata from an error, keeping its code,
+// message and details.
+func forwardError(err error) error {
+	var ce *connect.Error
+	if !errors.As(err, &ce) {
+		return connect.NewError(connect.CodeUnavailable, err)
+	}
+	forwarded := connect.NewError(ce.Code(), errors.New(ce.Message()))
+	for _, detail := range ce.Details() {
+		forwarded.AddDetail(detail)
+	}
+	return forwarded
+}
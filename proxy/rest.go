@@ -0,0 +1,322 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// The operations exposed by the REST handler, passed to the authenticator as the
+// procedure being called.
+const (
+	RESTUpload = "rest.Upload"
+	RESTGet    = "rest.Get"
+	RESTSearch = "rest.Search"
+	RESTDelete = "rest.Delete"
+	RESTAsk    = "rest.Ask"
+)
+
+// MaxUploadMemory is the amount of an upload which is buffered in memory by the
+// REST handler, before spilling over to temporary files.
+const MaxUploadMemory = 32 << 20
+
+// RESTFile is the JSON representation of a file in the REST API.
+type RESTFile struct {
+	ID             string          `json:"id"`
+	ParentID       string          `json:"parent_id,omitempty"`
+	Name           string          `json:"name"`
+	SizeBytes      *int64          `json:"size_bytes,omitempty"`
+	IndexingStatus string          `json:"indexing_status"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Properties     json.RawMessage `json:"properties,omitempty"`
+}
+
+// RESTSearchRequest is the body of a search request in the REST API.
+type RESTSearchRequest struct {
+	Query      string  `json:"query"`
+	MaxResults int32   `json:"max_results,omitempty"`
+	ParentID   *string `json:"parent_id,omitempty"`
+}
+
+// RESTMatch is a single search result in the REST API.
+type RESTMatch struct {
+	FileID  string    `json:"file_id"`
+	Snippet string    `json:"snippet"`
+	Score   float32   `json:"score"`
+	File    *RESTFile `json:"file,omitempty"`
+}
+
+// RESTSearchResponse is the body of a search response in the REST API.
+type RESTSearchResponse struct {
+	Matches []*RESTMatch `json:"matches"`
+}
+
+// RESTError is the body of an error response in the REST API.
+type RESTError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewRESTHandler returns a handler exposing a small, stable REST/JSON API, for
+// callers which would rather not use connect or protobuf directly:
+//
+//	POST   /v1/files       Upload a file (multipart form: file, name, parent_id, properties).
+//	GET    /v1/files/{id}  Get a file.
+//	DELETE /v1/files/{id}  Delete a file.
+//	POST   /v1/search      Search (JSON body, see RESTSearchRequest).
+//
+// Results can also be streamed progressively as server-sent events:
+//
+//	POST   /v1/search/stream  Search, streaming a "match" event per result (see
+//	                          RESTMatch), then a "done" event.
+//	POST   /v1/ask/stream     Ask a question (JSON body, see RESTAskRequest),
+//	                          streaming "answer" events as the answer is generated.
+//
+// The authenticator is called with one of the REST* operations as the procedure.
+// Only Authenticate is used from the config.
+func NewRESTHandler(cfg Config) http.Handler {
+	h := &restHandler{authenticate: cfg.Authenticate}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/files", h.files)
+	mux.HandleFunc("/v1/files/", h.file)
+	mux.HandleFunc("/v1/search", h.search)
+	mux.HandleFunc("/v1/search/stream", h.searchStream)
+	mux.HandleFunc("/v1/ask/stream", h.askStream)
+	return mux
+}
+
+type restHandler struct {
+	authenticate Authenticator
+}
+
+func (h *restHandler) files(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeRESTError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	client, ok := h.admit(w, r, RESTUpload)
+	if !ok {
+		return
+	}
+
+	if err := r.ParseMultipartForm(MaxUploadMemory); err != nil {
+		writeRESTError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var data io.Reader // Nil creates a folder.
+	name := r.FormValue("name")
+	if f, header, err := r.FormFile("file"); err == nil {
+		defer f.Close()
+		data = f
+		if name == "" {
+			name = header.Filename
+		}
+	} else if !errors.Is(err, http.ErrMissingFile) {
+		writeRESTError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+	if name == "" {
+		writeRESTError(w, http.StatusBadRequest, "invalid_argument", "name is required")
+		return
+	}
+
+	var parent *string
+	if v := r.FormValue("parent_id"); v != "" {
+		parent = &v
+	}
+	var properties *filev1.Properties
+	if v := r.FormValue("properties"); v != "" {
+		properties = new(filev1.Properties)
+		if err := protojson.Unmarshal([]byte(v), properties); err != nil {
+			writeRESTError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+			return
+		}
+	}
+
+	resp, err := client.CreateFile(r.Context(), name, parent, data, properties)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, restFile(resp.File))
+}
+
+func (h *restHandler) file(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/files/")
+	if id == "" || strings.Contains(id, "/") {
+		writeRESTError(w, http.StatusNotFound, "not_found", "not found")
+		return
+	}
+	selector := &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}}
+
+	switch r.Method {
+	case http.MethodGet:
+		client, ok := h.admit(w, r, RESTGet)
+		if !ok {
+			return
+		}
+		resp, err := client.FileService().GetFile(r.Context(), connect.NewRequest(&filev1.GetFileRequest{
+			Selector: selector,
+		}))
+		if err != nil {
+			writeUpstreamError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, restFile(resp.Msg.File))
+	case http.MethodDelete:
+		client, ok := h.admit(w, r, RESTDelete)
+		if !ok {
+			return
+		}
+		if err := deleteFile(r.Context(), client, selector); err != nil {
+			writeUpstreamError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeRESTError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+	}
+}
+
+func (h *restHandler) search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeRESTError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	client, ok := h.admit(w, r, RESTSearch)
+	if !ok {
+		return
+	}
+
+	var req RESTSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRESTError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	resp, err := client.Search(r.Context(), req.Query, searchOptions(&req)...)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+
+	out := &RESTSearchResponse{Matches: make([]*RESTMatch, 0, len(resp.Matches))}
+	for _, m := range resp.Matches {
+		out.Matches = append(out.Matches, restMatch(m, resp.Files[m.FileId]))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func searchOptions(req *RESTSearchRequest) []operand.SearchOption {
+	var opts []operand.SearchOption
+	if req.MaxResults > 0 {
+		opts = append(opts, operand.WithMaxResults(req.MaxResults))
+	}
+	if req.ParentID != nil {
+		opts = append(opts, operand.WithParent(*req.ParentID))
+	}
+	return opts
+}
+
+// admit authenticates the request, writing an error response if that fails.
+func (h *restHandler) admit(w http.ResponseWriter, r *http.Request, op string) (*operand.Client, bool) {
+	client, err := h.authenticate(r.Context(), op, r.Header)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return nil, false
+	}
+	return client, true
+}
+
+func restFile(f *filev1.File) *RESTFile {
+	if f == nil {
+		return nil
+	}
+	out := &RESTFile{
+		ID:             f.Id,
+		ParentID:       f.GetParentId(),
+		Name:           f.Name,
+		SizeBytes:      f.SizeBytes,
+		IndexingStatus: strings.ToLower(strings.TrimPrefix(f.IndexingStatus.String(), "INDEXING_STATUS_")),
+		CreatedAt:      f.CreatedAt.AsTime(),
+		UpdatedAt:      f.UpdatedAt.AsTime(),
+	}
+	if len(f.GetProperties().GetProperties()) > 0 {
+		out.Properties, _ = protojson.Marshal(f.Properties)
+	}
+	return out
+}
+
+func restMatch(m *operandv1.ContentMatch, f *filev1.File) *RESTMatch {
+	return &RESTMatch{
+		FileID:  m.FileId,
+		Snippet: m.Snippet,
+		Score:   m.Score,
+		File:    restFile(f),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeRESTError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, &RESTError{Code: code, Message: message})
+}
+
+// writeUpstreamError writes an error returned by the authenticator or Operand.
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	code := connect.CodeOf(err)
+	if errors.Is(err, context.Canceled) {
+		code = connect.CodeCanceled
+	}
+	message := err.Error()
+	var ce *connect.Error
+	if errors.As(err, &ce) {
+		message = ce.Message()
+	}
+	writeRESTError(w, httpStatus(code), code.String(), message)
+}
+
+// httpStatus maps connect codes to HTTP statuses, following the connect protocol.
+func httpStatus(code connect.Code) int {
+	switch code {
+	case connect.CodeCanceled, connect.CodeDeadlineExceeded:
+		return http.StatusRequestTimeout
+	case connect.CodeInvalidArgument, connect.CodeOutOfRange:
+		return http.StatusBadRequest
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	case connect.CodeAlreadyExists, connect.CodeAborted:
+		return http.StatusConflict
+	case connect.CodePermissionDenied:
+		return http.StatusForbidden
+	case connect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case connect.CodeFailedPrecondition:
+		return http.StatusPreconditionFailed
+	case connect.CodeUnimplemented:
+		return http.StatusNotFound
+	case connect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,122 @@
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/operandinc/go-sdk/file/v1/filev1connect"
+	"github.com/operandinc/go-sdk/operandtest"
+	"github.com/operandinc/go-sdk/proxy"
+)
+
+// heldFile returns a client of a fake API, and the ID of a file it holds.
+func heldFile(t *testing.T) (*operand.Client, string) {
+	srv := operandtest.NewServer()
+	t.Cleanup(srv.Close)
+	client := srv.Client()
+	resp, err := client.CreateFile(context.Background(), "held.txt", nil, strings.NewReader("evidence"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Hold(context.Background(), resp.File.Id); err != nil {
+		t.Fatal(err)
+	}
+	return client, resp.File.Id
+}
+
+func config(client *operand.Client) proxy.Config {
+	return proxy.Config{
+		Authenticate: func(context.Context, string, http.Header) (*operand.Client, error) {
+			return client, nil
+		},
+	}
+}
+
+func TestRESTDeleteOfHeldFileFails(t *testing.T) {
+	client, id := heldFile(t)
+	gateway := httptest.NewServer(proxy.NewRESTHandler(config(client)))
+	defer gateway.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, gateway.URL+"/v1/files/"+id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusPreconditionFailed)
+	}
+	var body proxy.RESTError
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != connect.CodeFailedPrecondition.String() {
+		t.Errorf("got code %q, want %q", body.Code, connect.CodeFailedPrecondition)
+	}
+	if held, err := client.Protected(context.Background(), id); err != nil || !held {
+		t.Errorf("Protected: got %v, %v; the held file should remain", held, err)
+	}
+}
+
+func TestConnectDeleteOfHeldFileFails(t *testing.T) {
+	client, id := heldFile(t)
+	mux := http.NewServeMux()
+	proxy.Mount(mux, config(client))
+	gateway := httptest.NewServer(mux)
+	defer gateway.Close()
+
+	files := filev1connect.NewFileServiceClient(http.DefaultClient, gateway.URL)
+	_, err := files.DeleteFile(context.Background(), connect.NewRequest(&filev1.DeleteFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+	}))
+	if connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("got %v, want a failed_precondition error", err)
+	}
+	if _, err := client.FileService().GetFile(context.Background(), connect.NewRequest(&filev1.GetFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+	})); err != nil {
+		t.Errorf("the held file was deleted: %v", err)
+	}
+}
+
+func TestRESTDeleteOfFolderHoldingAHeldFileFails(t *testing.T) {
+	client, id := heldFile(t)
+	folder, err := client.CreateFile(context.Background(), "folder", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.FileService().UpdateFile(context.Background(), connect.NewRequest(&filev1.UpdateFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+		ParentId: &folder.File.Id,
+	})); err != nil {
+		t.Fatal(err)
+	}
+	gateway := httptest.NewServer(proxy.NewRESTHandler(config(client)))
+	defer gateway.Close()
+
+	req, err := http.NewRequest(http.MethodDelete, gateway.URL+"/v1/files/"+folder.File.Id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusPreconditionFailed)
+	}
+	if held, err := client.Protected(context.Background(), id); err != nil || !held {
+		t.Errorf("Protected: got %v, %v; the held file should remain", held, err)
+	}
+}
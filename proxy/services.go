@@ -0,0 +1,199 @@
+package proxy
+
+import (
+	"context"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/operandinc/go-sdk/file/v1/filev1connect"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"github.com/operandinc/go-sdk/operand/v1/operandv1connect"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+	"github.com/operandinc/go-sdk/tenant/v1/tenantv1connect"
+)
+
+// fileService forwards the FileService to Operand.
+type fileService struct{}
+
+var _ filev1connect.FileServiceHandler = fileService{}
+
+func (fileService) GetFile(
+	ctx context.Context,
+	req *connect.Request[filev1.GetFileRequest],
+) (*connect.Response[filev1.GetFileResponse], error) {
+	return forward(ctx, req, upstream(ctx).FileService().GetFile)
+}
+
+func (fileService) ListFiles(
+	ctx context.Context,
+	req *connect.Request[filev1.ListFilesRequest],
+) (*connect.Response[filev1.ListFilesResponse], error) {
+	return forward(ctx, req, upstream(ctx).FileService().ListFiles)
+}
+
+func (fileService) ImportFromURL(
+	ctx context.Context,
+	req *connect.Request[filev1.ImportFromURLRequest],
+) (*connect.Response[filev1.ImportFromURLResponse], error) {
+	return forward(ctx, req, upstream(ctx).FileService().ImportFromURL)
+}
+
+func (fileService) DeleteFile(
+	ctx context.Context,
+	req *connect.Request[filev1.DeleteFileRequest],
+) (*connect.Response[filev1.DeleteFileResponse], error) {
+	if err := deleteFile(ctx, upstream(ctx), req.Msg.Selector); err != nil {
+		return nil, forwardError(err)
+	}
+	return connect.NewResponse(&filev1.DeleteFileResponse{}), nil
+}
+
+func (fileService) UpdateFile(
+	ctx context.Context,
+	req *connect.Request[filev1.UpdateFileRequest],
+) (*connect.Response[filev1.UpdateFileResponse], error) {
+	return forward(ctx, req, upstream(ctx).FileService().UpdateFile)
+}
+
+func (fileService) ShareFile(
+	ctx context.Context,
+	req *connect.Request[filev1.ShareFileRequest],
+) (*connect.Response[filev1.ShareFileResponse], error) {
+	return forward(ctx, req, upstream(ctx).FileService().ShareFile)
+}
+
+func (fileService) UnshareFile(
+	ctx context.Context,
+	req *connect.Request[filev1.UnshareFileRequest],
+) (*connect.Response[filev1.UnshareFileResponse], error) {
+	return forward(ctx, req, upstream(ctx).FileService().UnshareFile)
+}
+
+func (fileService) AttachSync(
+	ctx context.Context,
+	req *connect.Request[filev1.AttachSyncRequest],
+) (*connect.Response[filev1.AttachSyncResponse], error) {
+	return forward(ctx, req, upstream(ctx).FileService().AttachSync)
+}
+
+func (fileService) DeleteSync(
+	ctx context.Context,
+	req *connect.Request[filev1.DeleteSyncRequest],
+) (*connect.Response[filev1.DeleteSyncResponse], error) {
+	return forward(ctx, req, upstream(ctx).FileService().DeleteSync)
+}
+
+// tenantService forwards the TenantService to Operand.
+type tenantService struct{}
+
+var _ tenantv1connect.TenantServiceHandler = tenantService{}
+
+func (tenantService) AuthorizedUser(
+	ctx context.Context,
+	req *connect.Request[tenantv1.AuthorizedUserRequest],
+) (*connect.Response[tenantv1.AuthorizedUserResponse], error) {
+	return forward(ctx, req, upstream(ctx).TenantService().AuthorizedUser)
+}
+
+func (tenantService) CreateAPIKey(
+	ctx context.Context,
+	req *connect.Request[tenantv1.CreateAPIKeyRequest],
+) (*connect.Response[tenantv1.CreateAPIKeyResponse], error) {
+	return forward(ctx, req, upstream(ctx).TenantService().CreateAPIKey)
+}
+
+func (tenantService) ListAPIKeys(
+	ctx context.Context,
+	req *connect.Request[tenantv1.ListAPIKeysRequest],
+) (*connect.Response[tenantv1.ListAPIKeysResponse], error) {
+	return forward(ctx, req, upstream(ctx).TenantService().ListAPIKeys)
+}
+
+func (tenantService) DeleteAPIKey(
+	ctx context.Context,
+	req *connect.Request[tenantv1.DeleteAPIKeyRequest],
+) (*connect.Response[tenantv1.DeleteAPIKeyResponse], error) {
+	return forward(ctx, req, upstream(ctx).TenantService().DeleteAPIKey)
+}
+
+func (tenantService) OAuthLink(
+	ctx context.Context,
+	req *connect.Request[tenantv1.OAuthLinkRequest],
+) (*connect.Response[tenantv1.OAuthLinkResponse], error) {
+	return forward(ctx, req, upstream(ctx).TenantService().OAuthLink)
+}
+
+func (tenantService) UpdateUser(
+	ctx context.Context,
+	req *connect.Request[tenantv1.UpdateUserRequest],
+) (*connect.Response[tenantv1.UpdateUserResponse], error) {
+	return forward(ctx, req, upstream(ctx).TenantService().UpdateUser)
+}
+
+func (tenantService) Usage(
+	ctx context.Context,
+	req *connect.Request[tenantv1.UsageRequest],
+) (*connect.Response[tenantv1.UsageResponse], error) {
+	return forward(ctx, req, upstream(ctx).TenantService().Usage)
+}
+
+func (tenantService) UpdateSubscription(
+	ctx context.Context,
+	req *connect.Request[tenantv1.UpdateSubscriptionRequest],
+) (*connect.Response[tenantv1.UpdateSubscriptionResponse], error) {
+	return forward(ctx, req, upstream(ctx).TenantService().UpdateSubscription)
+}
+
+// operandService forwards the OperandService to Operand.
+type operandService struct{}
+
+var _ operandv1connect.OperandServiceHandler = operandService{}
+
+func (operandService) Search(
+	ctx context.Context,
+	req *connect.Request[operandv1.SearchRequest],
+) (*connect.Response[operandv1.SearchResponse], error) {
+	return forward(ctx, req, upstream(ctx).OperandService().Search)
+}
+
+func (fileService) CreateFile(
+	ctx context.Context,
+	stream *connect.ClientStream[filev1.CreateFileRequest],
+) (*connect.Response[filev1.CreateFileResponse], error) {
+	up := upstream(ctx).FileService().CreateFile(ctx)
+	for stream.Receive() {
+		if err := up.Send(stream.Msg()); err != nil {
+			break // The actual error is returned by CloseAndReceive.
+		}
+	}
+	if err := stream.Err(); err != nil {
+		up.CloseAndReceive()
+		return nil, err
+	}
+	resp, err := up.CloseAndReceive()
+	if err != nil {
+		return nil, forwardError(err)
+	}
+	return connect.NewResponse(resp.Msg), nil
+}
+
+func (operandService) Converse(
+	ctx context.Context,
+	req *connect.Request[operandv1.ConverseRequest],
+	stream *connect.ServerStream[operandv1.ConverseResponse],
+) error {
+	up, err := upstream(ctx).OperandService().Converse(ctx, connect.NewRequest(req.Msg))
+	if err != nil {
+		return forwardError(err)
+	}
+	defer up.Close()
+	for up.Receive() {
+		if err := stream.Send(up.Msg()); err != nil {
+			return err
+		}
+	}
+	if err := up.Err(); err != nil {
+		return forwardError(err)
+	}
+	return nil
+}
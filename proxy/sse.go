@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bufbuild/connect-go"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+// RESTAskRequest is the body of an ask request in the REST API.
+type RESTAskRequest struct {
+	Input string `json:"input"`
+	// ConversationID continues an existing conversation. Empty to start a new one.
+	ConversationID string  `json:"conversation_id,omitempty"`
+	ParentID       *string `json:"parent_id,omitempty"`
+}
+
+// RESTAnswerPart is a part of an answer streamed by the REST API.
+type RESTAnswerPart struct {
+	ConversationID string `json:"conversation_id"`
+	Text           string `json:"text"`
+}
+
+// sseWriter writes server-sent events.
+type sseWriter struct {
+	w http.ResponseWriter
+	f http.Flusher
+}
+
+// newSSEWriter starts an event stream, or writes an error and returns nil if the
+// response writer doesn't support streaming.
+func newSSEWriter(w http.ResponseWriter) *sseWriter {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		writeRESTError(w, http.StatusInternalServerError, "internal", "streaming unsupported")
+		return nil
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+	return &sseWriter{w: w, f: f}
+}
+
+func (s *sseWriter) send(event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	s.f.Flush()
+	return nil
+}
+
+// sendError sends an error event. Once the stream has started, errors can no
+// longer be reported with a status code.
+func (s *sseWriter) sendError(err error) {
+	code := connect.CodeOf(err)
+	message := err.Error()
+	var ce *connect.Error
+	if errors.As(err, &ce) {
+		message = ce.Message()
+	}
+	s.send("error", &RESTError{Code: code.String(), Message: message})
+}
+
+// searchStream streams search results as server-sent events: a "match" event
+// per result (see RESTMatch), followed by a "done" event.
+func (h *restHandler) searchStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeRESTError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	client, ok := h.admit(w, r, RESTSearch)
+	if !ok {
+		return
+	}
+
+	var req RESTSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRESTError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+
+	sse := newSSEWriter(w)
+	if sse == nil {
+		return
+	}
+	resp, err := client.Search(r.Context(), req.Query, searchOptions(&req)...)
+	if err != nil {
+		sse.sendError(err)
+		return
+	}
+	for _, m := range resp.Matches {
+		if err := sse.send("match", restMatch(m, resp.Files[m.FileId])); err != nil {
+			return // The client went away.
+		}
+	}
+	sse.send("done", struct{}{})
+}
+
+// askStream streams an answer as server-sent events: a "files" event listing the
+// files relevant to the answer, an "answer" event per part of the answer as it's
+// generated (see RESTAnswerPart), and finally a "done" event.
+func (h *restHandler) askStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeRESTError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+		return
+	}
+	client, ok := h.admit(w, r, RESTAsk)
+	if !ok {
+		return
+	}
+
+	var req RESTAskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeRESTError(w, http.StatusBadRequest, "invalid_argument", err.Error())
+		return
+	}
+	converse := &operandv1.ConverseRequest{Input: req.Input}
+	if req.ConversationID != "" {
+		converse.ConversationId = &req.ConversationID
+	}
+	if req.ParentID != nil {
+		converse.Options = &operandv1.ConversationOptions{ParentId: req.ParentID}
+	}
+
+	sse := newSSEWriter(w)
+	if sse == nil {
+		return
+	}
+	stream, err := client.OperandService().Converse(r.Context(), connect.NewRequest(converse))
+	if err != nil {
+		sse.sendError(err)
+		return
+	}
+	defer stream.Close()
+
+	for stream.Receive() {
+		msg := stream.Msg()
+		if len(msg.RelevantFiles) > 0 {
+			files := make([]*RESTFile, 0, len(msg.RelevantFiles))
+			for _, f := range msg.RelevantFiles {
+				files = append(files, restFile(f))
+			}
+			if err := sse.send("files", files); err != nil {
+				return
+			}
+		}
+		if msg.MessagePart == "" {
+			continue
+		}
+		if err := sse.send("answer", &RESTAnswerPart{
+			ConversationID: msg.ConversationId,
+			Text:           msg.MessagePart,
+		}); err != nil {
+			return
+		}
+	}
+	if err := stream.Err(); err != nil {
+		sse.sendError(err)
+		return
+	}
+	sse.send("done", struct{}{})
+}
@@ -0,0 +1,59 @@
+package proxy_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/operandinc/go-sdk/operandtest"
+	"github.com/operandinc/go-sdk/proxy"
+)
+
+func TestSearchStreamSendsAnEventPerHit(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if _, err := client.CreateFile(context.Background(), name, nil, strings.NewReader("the quick fox"), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gateway := httptest.NewServer(proxy.NewRESTHandler(config(client)))
+	defer gateway.Close()
+
+	resp, err := http.Post(gateway.URL+"/v1/search/stream", "application/json", strings.NewReader(`{"query":"fox"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("got content type %q, want text/event-stream", ct)
+	}
+
+	var events []string
+	files := make(map[string]bool)
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if event, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, event)
+		} else if data, ok := strings.CutPrefix(line, "data: "); ok && events[len(events)-1] == "match" {
+			var match proxy.RESTMatch
+			if err := json.Unmarshal([]byte(data), &match); err != nil {
+				t.Fatal(err)
+			}
+			files[match.FileID] = true
+		}
+	}
+	want := "match,match,match,done"
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("got events %s, want %s", got, want)
+	}
+	if len(files) != 3 {
+		t.Errorf("matched %d files, want 3", len(files))
+	}
+}
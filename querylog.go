@@ -0,0 +1,239 @@
+package operand
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// QueryLogEntry is a search recorded by a QueryLog, with enough detail to replay it.
+type QueryLogEntry struct {
+	Time             time.Time       `json:"time"`
+	Query            string          `json:"query"`
+	ParentID         *string         `json:"parent_id,omitempty"`
+	Filter           json.RawMessage `json:"filter,omitempty"` // An operandv1.Filter, encoded with protojson.
+	MaxResults       int32           `json:"max_results,omitempty"`
+	AdjacentSnippets *int32          `json:"adjacent_snippets,omitempty"`
+	// OperationID is the operation ID of the search (see WithOperationID), which
+	// feedback on its results refers to (see SubmitFeedback).
+	OperationID string `json:"operation_id,omitempty"`
+}
+
+// Options returns the search options which reproduce the entry's search.
+func (e *QueryLogEntry) Options() ([]SearchOption, error) {
+	var opts []SearchOption
+	if e.ParentID != nil {
+		opts = append(opts, WithParent(*e.ParentID))
+	}
+	if len(e.Filter) > 0 {
+		filter := new(operandv1.Filter)
+		if err := protojson.Unmarshal(e.Filter, filter); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithFilter(filter))
+	}
+	if e.MaxResults > 0 {
+		opts = append(opts, WithMaxResults(e.MaxResults))
+	}
+	if e.AdjacentSnippets != nil {
+		opts = append(opts, WithAdjacentSnippets(*e.AdjacentSnippets))
+	}
+	return opts, nil
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+	digitsPattern = regexp.MustCompile(`\d[\d -]{4,}\d`)
+)
+
+// SanitizeQuery is the default sanitizer of QueryLog. It masks email addresses and
+// long runs of digits, such as phone, card or account numbers.
+func SanitizeQuery(query string) string {
+	query = emailPattern.ReplaceAllString(query, Redacted)
+	return digitsPattern.ReplaceAllString(query, Redacted)
+}
+
+// QueryLog records searches made with a client (see WithQueryLog) as JSON lines,
+// so that they can be replayed (see Replay), e.g. for load testing or relevance
+// regression runs against staging. Unlike the audit hook, it records the query
+// text, after sanitizing it.
+type QueryLog struct {
+	// Sanitize sanitizes queries before they're recorded. Defaults to SanitizeQuery.
+	Sanitize func(query string) string
+	// OnError, if set, is called when an entry can't be written.
+	OnError func(error)
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewQueryLog returns a QueryLog which writes to w.
+func NewQueryLog(w io.Writer) *QueryLog {
+	return &QueryLog{enc: json.NewEncoder(w)}
+}
+
+// WithQueryLog records every search made with the client to the log.
+func WithQueryLog(l *QueryLog) Option {
+	return func(c *Client) { c.queryLog = l }
+}
+
+// record records a search.
+func (l *QueryLog) record(ctx context.Context, started time.Time, query string, o *searchOptions) {
+	sanitize := l.Sanitize
+	if sanitize == nil {
+		sanitize = SanitizeQuery
+	}
+	entry := &QueryLogEntry{
+		Time:             started.UTC(),
+		Query:            sanitize(query),
+		ParentID:         o.parentID,
+		MaxResults:       o.maxResults,
+		AdjacentSnippets: o.adjacentSnippets,
+		OperationID:      OperationID(ctx),
+	}
+	if o.filter != nil {
+		filter, err := protojson.Marshal(o.filter)
+		if err != nil {
+			l.fail(err)
+			return
+		}
+		entry.Filter = filter
+	}
+
+	l.mu.Lock()
+	err := l.enc.Encode(entry)
+	l.mu.Unlock()
+	if err != nil {
+		l.fail(err)
+	}
+}
+
+func (l *QueryLog) fail(err error) {
+	if l.OnError != nil {
+		l.OnError(err)
+	}
+}
+
+// ReadQueryLog reads the entries written by a QueryLog.
+func ReadQueryLog(r io.Reader) ([]*QueryLogEntry, error) {
+	var entries []*QueryLogEntry
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		entry := new(QueryLogEntry)
+		if err := dec.Decode(entry); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// Replay re-issues logged searches against a client.
+type Replay struct {
+	Client *Client
+	// Rate is the number of searches started per second. Zero means as fast as
+	// Concurrency allows.
+	Rate float64
+	// Concurrency is the maximum number of concurrent searches. Defaults to 1.
+	Concurrency int
+	// Options are applied to every search after the logged ones, e.g. to redirect
+	// searches to a different scope.
+	Options []SearchOption
+	// OnResult, if set, is called (concurrently) with the result of each search.
+	OnResult func(entry *QueryLogEntry, resp *operandv1.SearchResponse, err error, latency time.Duration)
+}
+
+// ReplayReport is the result of a replay.
+type ReplayReport struct {
+	Queries       int
+	Failed        int
+	Elapsed       time.Duration
+	P50, P95, Max time.Duration
+}
+
+// Run replays the entries in order. Failed searches are counted, but don't stop
+// the replay; an error is only returned for an invalid entry, or if the context is
+// cancelled.
+func (r *Replay) Run(ctx context.Context, entries []*QueryLogEntry) (*ReplayReport, error) {
+	clock := r.Client.clock
+	start := clock.Now()
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var tick <-chan time.Time
+	if r.Rate > 0 {
+		ticker := clock.NewTicker(time.Duration(float64(time.Second) / r.Rate))
+		defer ticker.Stop()
+		tick = ticker.C()
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		latencies []time.Duration
+		failed    int
+		sem       = make(chan struct{}, concurrency)
+		err       error
+	)
+	for _, entry := range entries {
+		var opts []SearchOption
+		if opts, err = entry.Options(); err != nil {
+			break
+		}
+		opts = append(opts, r.Options...)
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+			}
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		wg.Add(1)
+		go func(entry *QueryLogEntry, opts []SearchOption) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			began := clock.Now()
+			resp, err := r.Client.Search(ctx, entry.Query, opts...)
+			latency := clock.Now().Sub(began)
+			if r.OnResult != nil {
+				r.OnResult(entry, resp, err, latency)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			latencies = append(latencies, latency)
+			if err != nil {
+				failed++
+			}
+		}(entry, opts)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReplayReport{Queries: len(latencies), Failed: failed, Elapsed: clock.Now().Sub(start)}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		report.P50 = percentile(latencies, 50)
+		report.P95 = percentile(latencies, 95)
+		report.Max = latencies[len(latencies)-1]
+	}
+	return report, nil
+}
@@ -0,0 +1,107 @@
+package operand
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+)
+
+// Headers describing the rate limit of the API, sent along with responses.
+const (
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+)
+
+// RateLimit describes the rate limit of the API, as reported by a response.
+type RateLimit struct {
+	// Limit and Remaining are the number of requests allowed per window, and left
+	// in the current one, or -1 if they weren't reported.
+	Limit     int
+	Remaining int
+	// Reset is when the current window ends, if reported.
+	Reset time.Time
+	// RetryAfter is how long to wait before retrying, if reported.
+	RetryAfter time.Duration
+}
+
+// Wait returns how long to wait before making another request, given the current
+// time, and whether the response reported it at all.
+func (r *RateLimit) Wait(now time.Time) (time.Duration, bool) {
+	if r.RetryAfter > 0 {
+		return r.RetryAfter, true
+	}
+	if r.Remaining == 0 && !r.Reset.IsZero() {
+		return max(r.Reset.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// parseRateLimit parses the rate-limit headers of a response, returning nil if
+// there are none.
+func parseRateLimit(header http.Header, now time.Time) *RateLimit {
+	if header.Get(HeaderRetryAfter) == "" && header.Get(HeaderRateLimitLimit) == "" &&
+		header.Get(HeaderRateLimitRemaining) == "" && header.Get(HeaderRateLimitReset) == "" {
+		return nil
+	}
+	r := &RateLimit{Limit: -1, Remaining: -1}
+	if n, err := strconv.Atoi(header.Get(HeaderRateLimitLimit)); err == nil {
+		r.Limit = n
+	}
+	if n, err := strconv.Atoi(header.Get(HeaderRateLimitRemaining)); err == nil {
+		r.Remaining = n
+	}
+	// The reset time is either a Unix time, or a number of seconds from now.
+	if n, err := strconv.ParseInt(header.Get(HeaderRateLimitReset), 10, 64); err == nil {
+		if n > 1e9 {
+			r.Reset = time.Unix(n, 0)
+		} else {
+			r.Reset = now.Add(time.Duration(n) * time.Second)
+		}
+	}
+	// Retry-After is either a number of seconds, or an HTTP date.
+	if v := header.Get(HeaderRetryAfter); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			r.RetryAfter = time.Duration(n) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			r.RetryAfter = max(t.Sub(now), 0)
+		}
+	}
+	return r
+}
+
+// WithThrottle waits out the rate limit, and retries, when a unary RPC or an upload
+// is rejected with rate-limit information (see Error.RateLimit), as long as the
+// total wait of the call stays within maxWait. Each wait is at least the base delay
+// of the retry policy (DefaultRetryBaseDelay without WithRetry), and throttled
+// attempts count toward its maximum number of attempts.
+func WithThrottle(maxWait time.Duration) Option {
+	return func(c *Client) { c.throttle = maxWait }
+}
+
+// throttleWait returns how long to wait before retrying a call which was rejected by
+// the rate limiter.
+func (c *Client) throttleWait(err error) (time.Duration, bool) {
+	var e *Error
+	if c.throttle <= 0 || !errors.As(c.apiError(err), &e) || e.RateLimit == nil {
+		return 0, false
+	}
+	if e.Code != connect.CodeResourceExhausted && e.Code != connect.CodeUnavailable {
+		return 0, false
+	}
+	wait, ok := e.RateLimit.Wait(c.clock.Now())
+	if !ok {
+		return 0, false
+	}
+	// A reset which has already passed (e.g. due to clock skew) mustn't retry
+	// without any delay.
+	minWait := DefaultRetryBaseDelay
+	if c.retry != nil {
+		minWait = c.retry.BaseDelay
+	}
+	return max(wait, minWait), true
+}
@@ -0,0 +1,352 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Action is a change made to the live tree.
+type Action string
+
+const (
+	Create Action = "create"
+	// Replace replaces a file or folder whose content, properties or kind differ
+	// from the declared ones, as the API can't update them in place. Folders are
+	// replaced by a new folder, into which their contents are moved.
+	Replace Action = "replace"
+	Delete  Action = "delete"
+)
+
+// Change is a change made (or, in a dry run, to be made) to the live tree.
+type Change struct {
+	Action Action `json:"action"`
+	Path   string `json:"path"` // Relative to the root of the spec.
+	// ID is the ID of the file or folder after the change, or of the deleted one.
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Report is the result of a reconcile.
+type Report struct {
+	DryRun    bool                     `json:"dry_run"`
+	Changes   []*Change                `json:"changes"`
+	Unchanged int                      `json:"unchanged"`
+	Retention *operand.RetentionReport `json:"retention,omitempty"`
+}
+
+// Reconciler reconciles a tenant with a spec.
+type Reconciler struct {
+	Client *operand.Client
+	Spec   *Spec
+	// BaseDir is the directory which the paths of files are relative to. Defaults to
+	// the working directory.
+	BaseDir string
+	// HTTPClient fetches files declared by URL. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// DryRun reports the changes which would be made, without making them.
+	DryRun bool
+	// Hash is the algorithm of the content hashes of files. Defaults to
+	// operand.SHA256.
+	Hash operand.HashAlgorithm
+}
+
+// Reconcile computes the difference between the spec and the live tree, and
+// applies it: missing files and folders are created, those which differ are
+// replaced, and, if the spec prunes, undeclared ones are deleted. Then, the spec's
+// retention policies are applied. Files are compared by the hash of their content
+// (see operand.PropertyContentHash) and their properties; properties set by the SDK
+// (prefixed by operand_) are ignored, unless declared.
+//
+// The first failure stops the reconcile, and is returned along with the changes
+// made until then. As the spec is declarative, running it again picks up where it
+// left off.
+func (r *Reconciler) Reconcile(ctx context.Context) (*Report, error) {
+	if err := r.Spec.Validate(); err != nil {
+		return nil, err
+	}
+	live := make(map[string]*filev1.File)
+	err := r.Client.Walk(ctx, r.Spec.Root, func(file *filev1.File, p string) error {
+		live[p] = file
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	run := &run{r: r, live: live, declared: make(map[string]bool), report: &Report{DryRun: r.DryRun}}
+	if err := run.dir(ctx, r.Spec.Root, "", r.Spec.Folders, r.Spec.Files); err != nil {
+		return run.report, err
+	}
+	if r.Spec.Prune {
+		if err := run.prune(ctx); err != nil {
+			return run.report, err
+		}
+	}
+	if len(r.Spec.Retention) > 0 {
+		retention := &operand.Retention{Client: r.Client, RootID: r.Spec.Root, DryRun: r.DryRun}
+		for _, declared := range r.Spec.Retention {
+			policy, _ := declared.policy() // Validated above.
+			retention.Policies = append(retention.Policies, policy)
+		}
+		if run.report.Retention, err = retention.Apply(ctx); err != nil {
+			return run.report, err
+		}
+	}
+	return run.report, nil
+}
+
+// run is the state of a single reconcile.
+type run struct {
+	r *Reconciler
+	// live maps the paths of the live files and folders to them.
+	live map[string]*filev1.File
+	// declared holds the paths of declared folders, and marks those of declared
+	// files false.
+	declared map[string]bool
+	report   *Report
+}
+
+func (r *run) record(action Action, p, id, reason string) {
+	r.report.Changes = append(r.report.Changes, &Change{Action: action, Path: p, ID: id, Reason: reason})
+}
+
+// dir reconciles the contents of a folder, which is empty in a dry run if the
+// folder doesn't exist yet.
+func (r *run) dir(ctx context.Context, folderID, dir string, folders []Folder, files []File) error {
+	for _, f := range folders {
+		p := path.Join(dir, f.Name)
+		r.declared[p] = true
+		id, err := r.folder(ctx, folderID, p, &f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		if err := r.dir(ctx, id, p, f.Folders, f.Files); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		p := path.Join(dir, f.Name)
+		r.declared[p] = false
+		if err := r.file(ctx, folderID, p, &f); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// folder reconciles a folder, and returns its ID.
+func (r *run) folder(ctx context.Context, parentID, p string, f *Folder) (string, error) {
+	properties, _ := f.properties() // Validated by Reconcile.
+	existing, ok := r.live[p]
+	switch {
+	case !ok:
+		id, err := r.create(ctx, parentID, f.Name, nil, properties)
+		r.record(Create, p, id, "")
+		return id, err
+	case operand.IsFolder(existing) && sameProperties(existing.Properties, properties):
+		r.report.Unchanged++
+		return existing.Id, nil
+	case !operand.IsFolder(existing):
+		r.record(Replace, p, "", "file declared as a folder")
+		if err := r.remove(ctx, existing); err != nil {
+			return "", err
+		}
+		id, err := r.create(ctx, parentID, f.Name, nil, properties)
+		r.report.Changes[len(r.report.Changes)-1].ID = id
+		return id, err
+	default:
+		r.record(Replace, p, existing.Id, "properties changed")
+		if r.r.DryRun {
+			return existing.Id, nil
+		}
+		id, err := r.create(ctx, parentID, f.Name, nil, properties)
+		if err != nil {
+			return "", err
+		}
+		r.report.Changes[len(r.report.Changes)-1].ID = id
+		return id, r.moveContents(ctx, existing.Id, id)
+	}
+}
+
+// moveContents moves the contents of a folder into another, and deletes it.
+func (r *run) moveContents(ctx context.Context, fromID, toID string) error {
+	children, err := r.r.Client.ListFolder(ctx, fromID)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		_, err := r.r.Client.FileService().UpdateFile(ctx, connect.NewRequest(&filev1.UpdateFileRequest{
+			Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: child.Id}},
+			ParentId: &toID,
+		}))
+		if err != nil {
+			return fmt.Errorf("failed to move %s: %w", child.Id, err)
+		}
+	}
+	return r.r.Client.DeleteFile(ctx, fromID)
+}
+
+// file reconciles a file.
+func (r *run) file(ctx context.Context, parentID, p string, f *File) error {
+	content, err := r.content(ctx, f)
+	if err != nil {
+		return err
+	}
+	hash := r.r.Hash.SumBytes(content)
+	properties, _ := f.Properties.convert() // Validated by Reconcile.
+	properties = operand.SetProperty(properties, operand.PropertyContentHash, operand.TextProperty(hash))
+
+	existing, ok := r.live[p]
+	var reason string
+	switch {
+	case !ok:
+		id, err := r.create(ctx, parentID, f.Name, content, properties)
+		r.record(Create, p, id, "")
+		return err
+	case operand.IsFolder(existing):
+		reason = "folder declared as a file"
+	case !sameProperties(existing.Properties, properties):
+		reason = "content or properties changed"
+		if live, _ := operand.PropertyText(existing.Properties, operand.PropertyContentHash); !operand.SameContentHash(live, hash) {
+			reason = "content changed"
+		}
+	default:
+		r.report.Unchanged++
+		return nil
+	}
+	// The new version is uploaded before the old one is removed, so that the file
+	// is never missing if the upload fails.
+	id, err := r.create(ctx, parentID, f.Name, content, properties)
+	r.record(Replace, p, id, reason)
+	if err != nil {
+		return err
+	}
+	return r.remove(ctx, existing)
+}
+
+// content returns the content of a declared file.
+func (r *run) content(ctx context.Context, f *File) ([]byte, error) {
+	switch {
+	case f.Path != "":
+		p := f.Path
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(r.r.BaseDir, p)
+		}
+		return os.ReadFile(p)
+	case f.URL != "":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		httpClient := r.r.HTTPClient
+		if httpClient == nil {
+			httpClient = http.DefaultClient
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch %s: %s", f.URL, resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	default:
+		return []byte(f.Content), nil
+	}
+}
+
+// create creates a file, or a folder if content is nil, unless this is a dry run.
+func (r *run) create(
+	ctx context.Context,
+	parentID, name string,
+	content []byte,
+	properties *filev1.Properties,
+) (string, error) {
+	if r.r.DryRun {
+		return "", nil
+	}
+	var parent *string
+	if parentID != "" {
+		parent = &parentID
+	}
+	var data io.Reader
+	if content != nil {
+		data = bytes.NewReader(content)
+	}
+	resp, err := r.r.Client.CreateFile(ctx, name, parent, data, properties)
+	if err != nil {
+		return "", err
+	}
+	return resp.File.Id, nil
+}
+
+// remove deletes a file or folder, unless this is a dry run.
+func (r *run) remove(ctx context.Context, file *filev1.File) error {
+	if r.r.DryRun {
+		return nil
+	}
+	if operand.IsFolder(file) {
+		return r.r.Client.DeleteTree(ctx, file.Id)
+	}
+	return r.r.Client.DeleteFile(ctx, file.Id)
+}
+
+// prune deletes the live files and folders which aren't declared, within declared
+// folders.
+func (r *run) prune(ctx context.Context) error {
+	paths := make([]string, 0, len(r.live))
+	for p := range r.live {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if _, ok := r.declared[p]; ok {
+			continue
+		}
+		// Only the topmost undeclared files are deleted, along with their contents.
+		if dir := path.Dir(p); dir != "." && !r.declared[dir] {
+			continue
+		}
+		file := r.live[p]
+		r.record(Delete, p, file.Id, "not declared")
+		if err := r.remove(ctx, file); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// sameProperties reports whether live properties match declared ones. Undeclared
+// properties set by the SDK are ignored, and content hashes are compared with
+// operand.SameContentHash.
+func sameProperties(live, declared *filev1.Properties) bool {
+	for key, value := range declared.GetProperties() {
+		same := proto.Equal(live.GetProperties()[key], value)
+		if key == operand.PropertyContentHash {
+			same = operand.SameContentHash(live.GetProperties()[key].GetText(), value.GetText())
+		}
+		if !same {
+			return false
+		}
+	}
+	for key := range live.GetProperties() {
+		if _, ok := declared.GetProperties()[key]; !ok && !strings.HasPrefix(key, "operand_") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,251 @@
+// Package reconcile applies a declared state of a tree of files to a tenant, in
+// the manner of infrastructure-as-code tools: each run computes the difference
+// between the declared tree (folders, files and their sources, properties, and
+// retention policies) and the live one, and applies it.
+//
+//	root: <folder ID, or empty for the whole tenant>
+//	prune: true
+//	folders:
+//	  - name: handbook
+//	    chunk_profile: markdown
+//	    properties:
+//	      team: people
+//	    files:
+//	      - name: leave.md
+//	        path: docs/leave.md
+//	      - name: faq.html
+//	        url: https://example.com/faq
+//	files:
+//	  - name: README.txt
+//	    content: Managed by reconcile, don't edit by hand.
+//	retention:
+//	  - name: drafts
+//	    match:
+//	      status: draft
+//	    max_age: 720h
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	operand "github.com/operandinc/go-sdk"
+	"github.com/operandinc/go-sdk/chunk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the declared state of a tree of files.
+type Spec struct {
+	// Root is the folder the tree is declared within. Empty for the whole tenant.
+	Root string `yaml:"root"`
+	// Prune deletes live files and folders within the tree which aren't declared.
+	Prune     bool        `yaml:"prune"`
+	Folders   []Folder    `yaml:"folders"`
+	Files     []File      `yaml:"files"`
+	Retention []Retention `yaml:"retention"`
+}
+
+// Folder is a declared folder.
+type Folder struct {
+	Name string `yaml:"name"`
+	// ChunkProfile is the chunk profile (see chunk.Profiles) of files chunked into
+	// the folder with operand.CreateChunkedFile, recorded in
+	// operand.PropertyChunkProfile.
+	ChunkProfile string     `yaml:"chunk_profile"`
+	Properties   Properties `yaml:"properties"`
+	Folders      []Folder   `yaml:"folders"`
+	Files        []File     `yaml:"files"`
+}
+
+// File is a declared file. Its content comes from exactly one of a local path
+// (relative to Reconciler.BaseDir), a URL, or inline content.
+type File struct {
+	Name       string     `yaml:"name"`
+	Path       string     `yaml:"path"`
+	URL        string     `yaml:"url"`
+	Content    string     `yaml:"content"`
+	Properties Properties `yaml:"properties"`
+}
+
+// Properties are declared properties. Values are text, numbers, or lists of either.
+type Properties map[string]any
+
+// Retention is a declared retention policy (see operand.RetentionPolicy), which
+// applies to the files within the tree whose properties include all of Match.
+type Retention struct {
+	Name      string     `yaml:"name"`
+	Match     Properties `yaml:"match"`
+	MaxAge    string     `yaml:"max_age"` // A duration, e.g. "720h".
+	MaxCount  int        `yaml:"max_count"`
+	ExpiresAt bool       `yaml:"expires_at"`
+}
+
+// Parse parses and validates a spec in YAML.
+func Parse(data []byte) (*Spec, error) {
+	spec := new(Spec)
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// ReadFile reads and validates a spec from a YAML file.
+func ReadFile(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// Validate checks that the spec is well-formed: that names are valid and unique
+// within each folder, that each file has a single source, and that properties and
+// retention policies can be converted.
+func (s *Spec) Validate() error {
+	var errs []error
+	validateDir(&errs, "", s.Folders, s.Files)
+	for i, r := range s.Retention {
+		if _, err := r.policy(); err != nil {
+			errs = append(errs, fmt.Errorf("retention %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validateDir(errs *[]error, dir string, folders []Folder, files []File) {
+	names := make(map[string]bool)
+	checkName := func(name string) string {
+		p := strings.TrimPrefix(dir+"/"+name, "/")
+		switch {
+		case name == "" || name == "." || name == ".." || strings.Contains(name, "/"):
+			*errs = append(*errs, fmt.Errorf("%s: invalid name %q", p, name))
+		case names[name]:
+			*errs = append(*errs, fmt.Errorf("%s: declared more than once", p))
+		}
+		names[name] = true
+		return p
+	}
+	for _, f := range folders {
+		p := checkName(f.Name)
+		if _, err := f.properties(); err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", p, err))
+		}
+		validateDir(errs, p, f.Folders, f.Files)
+	}
+	for _, f := range files {
+		p := checkName(f.Name)
+		sources := 0
+		for _, s := range []string{f.Path, f.URL, f.Content} {
+			if s != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			*errs = append(*errs, fmt.Errorf("%s: exactly one of path, url and content must be set", p))
+		}
+		if _, err := f.Properties.convert(); err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", p, err))
+		}
+	}
+}
+
+// convert converts declared properties into file properties.
+func (p Properties) convert() (*filev1.Properties, error) {
+	var properties *filev1.Properties
+	for key, v := range p {
+		value, err := property(v)
+		if err != nil {
+			return nil, fmt.Errorf("property %s: %w", key, err)
+		}
+		properties = operand.SetProperty(properties, key, value)
+	}
+	return properties, nil
+}
+
+// properties returns the properties of a declared folder, including its chunk
+// profile.
+func (f *Folder) properties() (*filev1.Properties, error) {
+	properties, err := f.Properties.convert()
+	if err != nil || f.ChunkProfile == "" {
+		return properties, err
+	}
+	if chunk.ForName(f.ChunkProfile) == nil {
+		return nil, fmt.Errorf("unknown chunk profile %q", f.ChunkProfile)
+	}
+	return operand.SetProperty(properties, operand.PropertyChunkProfile, operand.TextProperty(f.ChunkProfile)), nil
+}
+
+func property(v any) (*filev1.Property, error) {
+	switch v := v.(type) {
+	case string:
+		return operand.TextProperty(v), nil
+	case int:
+		return operand.NumberProperty(float64(v)), nil
+	case float64:
+		return operand.NumberProperty(v), nil
+	case []any:
+		var texts []string
+		var numbers []float64
+		for _, e := range v {
+			switch e := e.(type) {
+			case string:
+				texts = append(texts, e)
+			case int:
+				numbers = append(numbers, float64(e))
+			case float64:
+				numbers = append(numbers, e)
+			default:
+				return nil, fmt.Errorf("unsupported list element %v", e)
+			}
+		}
+		if len(texts) > 0 && len(numbers) > 0 {
+			return nil, errors.New("lists can't mix text and numbers")
+		}
+		if len(numbers) > 0 {
+			return operand.NumberArrayProperty(numbers...), nil
+		}
+		return operand.TextArrayProperty(texts...), nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", v)
+	}
+}
+
+// policy converts a declared retention policy.
+func (r *Retention) policy() (operand.RetentionPolicy, error) {
+	policy := operand.RetentionPolicy{Name: r.Name, MaxCount: r.MaxCount, ExpiresAt: r.ExpiresAt}
+	if r.MaxAge != "" {
+		d, err := time.ParseDuration(r.MaxAge)
+		if err != nil {
+			return policy, fmt.Errorf("invalid max_age: %w", err)
+		}
+		policy.MaxAge = d
+	}
+	match, err := r.Match.convert()
+	if err != nil {
+		return policy, err
+	}
+	for key, value := range match.GetProperties() {
+		if policy.Filter == nil {
+			policy.Filter = new(operandv1.Filter)
+		}
+		policy.Filter.Conditions = append(policy.Filter.Conditions, &operandv1.Condition{
+			Condition: &operandv1.Condition_Property{Property: &operandv1.KeyedProperty{Key: key, Property: value}},
+		})
+	}
+	if policy.MaxAge == 0 && policy.MaxCount == 0 && !policy.ExpiresAt {
+		return policy, errors.New("one of max_age, max_count and expires_at must be set")
+	}
+	return policy, nil
+}
@@ -0,0 +1,191 @@
+package operand
+
+import (
+	"net/http"
+	"strings"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Redacted is the value substituted for anything masked by a Redactor.
+const Redacted = "[REDACTED]"
+
+// alwaysRedactedHeaders are masked regardless of the redactor's configuration.
+var alwaysRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// secretFields hold credentials, and are masked regardless of the redactor's configuration.
+var secretFields = map[protoreflect.FullName]bool{
+	"tenant.v1.APIKey.full":                       true,
+	"tenant.v1.OAuthLink.access_token":            true,
+	"file.v1.GithubRepositoryParams.access_token": true,
+}
+
+// urlFields hold URLs which may be signed, and have their query string masked.
+var urlFields = map[protoreflect.FullName]bool{
+	"file.v1.File.download_url": true,
+}
+
+// contentFields hold document content (or queries about it).
+var contentFields = map[protoreflect.FullName]bool{
+	"operand.v1.SearchRequest.query":           true,
+	"operand.v1.ContentMatch.snippet":          true,
+	"operand.v1.ContentMatch.before_snippets":  true,
+	"operand.v1.ContentMatch.after_snippets":   true,
+	"operand.v1.ConverseRequest.input":         true,
+	"operand.v1.ConverseResponse.message_part": true,
+	"file.v1.CreateFileRequest.data_chunk":     true,
+}
+
+// Redactor decides what's masked in logging and debug output. Credentials (e.g. the
+// Authorization header, or API keys in responses) are always masked.
+type Redactor struct {
+	// Headers are the names of additional headers whose values are masked.
+	Headers []string
+	// PropertyKeys are the keys of properties whose values are masked.
+	PropertyKeys []string
+	// ShowContent disables the masking of document content, i.e. snippets, answers,
+	// queries and file data. It shouldn't be enabled in production.
+	ShowContent bool
+}
+
+// DefaultRedactor returns the redactor used by default, which masks credentials and
+// document content.
+func DefaultRedactor() *Redactor {
+	return &Redactor{Headers: []string{"X-Api-Key"}}
+}
+
+// WithRedactor sets the redactor applied to all logging and debug output.
+func WithRedactor(r *Redactor) Option {
+	return func(c *Client) { c.redactor = r }
+}
+
+// Redactor returns the redactor used by the client.
+func (c *Client) Redactor() *Redactor {
+	return c.redactor
+}
+
+// Header returns a copy of the header with sensitive values masked.
+func (r *Redactor) Header(h http.Header) http.Header {
+	redacted := h.Clone()
+	for _, names := range [][]string{alwaysRedactedHeaders, r.Headers} {
+		for _, name := range names {
+			if _, ok := redacted[http.CanonicalHeaderKey(name)]; ok {
+				redacted.Set(name, Redacted)
+			}
+		}
+	}
+	return redacted
+}
+
+// Content returns the content, or Redacted if content is masked.
+func (r *Redactor) Content(s string) string {
+	if r.ShowContent || s == "" {
+		return s
+	}
+	return Redacted
+}
+
+// Properties returns a copy of the properties with sensitive values masked.
+func (r *Redactor) Properties(p *filev1.Properties) *filev1.Properties {
+	if p == nil {
+		return nil
+	}
+	redacted := proto.Clone(p).(*filev1.Properties)
+	r.redactProperties(redacted)
+	return redacted
+}
+
+func (r *Redactor) redactProperties(p *filev1.Properties) {
+	for _, key := range r.PropertyKeys {
+		if _, ok := p.Properties[key]; ok {
+			p.Properties[key] = TextProperty(Redacted)
+		}
+	}
+}
+
+// Message returns a copy of the message with sensitive fields masked.
+func (r *Redactor) Message(m proto.Message) proto.Message {
+	if m == nil {
+		return nil
+	}
+	redacted := proto.Clone(m)
+	r.redactMessage(redacted.ProtoReflect())
+	return redacted
+}
+
+// JSON returns the message, with sensitive fields masked, encoded as JSON.
+func (r *Redactor) JSON(m proto.Message) string {
+	b, err := protojson.Marshal(r.Message(m))
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return string(b)
+}
+
+func (r *Redactor) redactMessage(m protoreflect.Message) {
+	if p, ok := m.Interface().(*filev1.Properties); ok {
+		r.redactProperties(p)
+		return
+	}
+
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := fd.FullName()
+		if secretFields[name] || (contentFields[name] && !r.ShowContent) {
+			m.Set(fd, redactedValue(fd, v))
+			return true
+		}
+		if urlFields[name] {
+			m.Set(fd, protoreflect.ValueOfString(redactURL(v.String())))
+			return true
+		}
+		if fd.Message() == nil {
+			return true
+		}
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				r.redactMessage(list.Get(i).Message())
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+					r.redactMessage(mv.Message())
+					return true
+				})
+			}
+		default:
+			r.redactMessage(v.Message())
+		}
+		return true
+	})
+}
+
+// redactedValue returns the masked equivalent of a string or bytes field.
+func redactedValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) protoreflect.Value {
+	mask := func(v protoreflect.Value) protoreflect.Value {
+		if fd.Kind() == protoreflect.BytesKind {
+			return protoreflect.ValueOfBytes([]byte(Redacted))
+		}
+		return protoreflect.ValueOfString(Redacted)
+	}
+	if !fd.IsList() {
+		return mask(v)
+	}
+	list := v.List()
+	for i := 0; i < list.Len(); i++ {
+		list.Set(i, mask(list.Get(i)))
+	}
+	return v
+}
+
+// redactURL strips the query string from a URL, since it may hold a signature.
+func redactURL(u string) string {
+	if i := strings.IndexByte(u, '?'); i >= 0 {
+		return u[:i] + "?" + Redacted
+	}
+	return u
+}
@@ -1,4 +1,4 @@
 #!/bin/bash
 
 # Regenerates the generated files in the project.
-buf generate buf.build/operand/mcp
\ No newline at end of file
+buf generate buf.build/operand/mcpgo generate ./...
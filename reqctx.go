@@ -0,0 +1,65 @@
+package operand
+
+import (
+	"context"
+	"net/http"
+)
+
+// Headers which carry the request context set by WithTenantCtx, WithEndUserCtx and
+// WithLocale.
+const (
+	HeaderTenant  = "Operand-Tenant"
+	HeaderEndUser = "Operand-End-User"
+	HeaderLocale  = "Accept-Language"
+)
+
+type requestContextKey struct{}
+
+// requestContext is the request context carried by a context.
+type requestContext struct {
+	tenant, endUser, locale string
+}
+
+func requestContextOf(ctx context.Context) requestContext {
+	rc, _ := ctx.Value(requestContextKey{}).(requestContext)
+	return rc
+}
+
+// WithTenantCtx returns a context whose calls are made on behalf of the given
+// tenant, e.g. by middleware which resolves the tenant of an incoming request, so
+// that every call made while handling it inherits the tenant.
+func WithTenantCtx(ctx context.Context, tenant string) context.Context {
+	rc := requestContextOf(ctx)
+	rc.tenant = tenant
+	return context.WithValue(ctx, requestContextKey{}, rc)
+}
+
+// WithEndUserCtx returns a context whose calls are attributed to the given end user
+// of the application.
+func WithEndUserCtx(ctx context.Context, user string) context.Context {
+	rc := requestContextOf(ctx)
+	rc.endUser = user
+	return context.WithValue(ctx, requestContextKey{}, rc)
+}
+
+// WithLocale returns a context whose calls prefer the given locale (a BCP 47
+// language tag, e.g. "de-CH"), e.g. for error messages and the analysis of queries.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	rc := requestContextOf(ctx)
+	rc.locale = locale
+	return context.WithValue(ctx, requestContextKey{}, rc)
+}
+
+// setRequestContext sets the headers carrying the context's request context.
+func setRequestContext(ctx context.Context, header http.Header) {
+	rc := requestContextOf(ctx)
+	for key, v := range map[string]string{
+		HeaderTenant:  rc.tenant,
+		HeaderEndUser: rc.endUser,
+		HeaderLocale:  rc.locale,
+	} {
+		if v != "" {
+			header.Set(key, v)
+		}
+	}
+}
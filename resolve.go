@@ -0,0 +1,170 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Resolver resolves the endpoint of the API dynamically, e.g. from service
+// discovery for on-premises deployments (see WithResolver).
+type Resolver interface {
+	// Resolve returns the endpoint to make calls to, e.g. "https://10.0.0.7:8443".
+	Resolve(ctx context.Context) (string, error)
+}
+
+// ResolverFunc adapts a function to a Resolver.
+type ResolverFunc func(ctx context.Context) (string, error)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// SRVResolver resolves the endpoint from DNS SRV records, as served by e.g. Consul
+// ("operand.service.consul"), to the target with the highest priority, chosen by
+// weight among equals.
+type SRVResolver struct {
+	// Service, Proto and Name are looked up as with net.LookupSRV: if Service and
+	// Proto are empty, Name is looked up directly.
+	Service, Proto, Name string
+	// Scheme is the scheme of the endpoint. Defaults to "https".
+	Scheme string
+	// Resolver looks up the records. Defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+}
+
+var _ Resolver = SRVResolver{}
+
+// Resolve looks up the SRV records.
+func (r SRVResolver) Resolve(ctx context.Context) (string, error) {
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	_, addrs, err := resolver.LookupSRV(ctx, r.Service, r.Proto, r.Name)
+	if err != nil {
+		return "", err
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no SRV records for %s", r.Name)
+	}
+	scheme := r.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	host := strings.TrimSuffix(addrs[0].Target, ".")
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(int(addrs[0].Port))), nil
+}
+
+// resolvedHost is the host of the endpoint of a client with a resolver. Requests
+// to it are sent to the resolved endpoint instead. The .invalid TLD is reserved,
+// so that it can't ever be reached by mistake.
+const resolvedHost = "operand.resolved.invalid"
+
+// WithResolver resolves the endpoint of the API with a resolver, instead of setting
+// it. The endpoint is resolved before the first call, and resolved again after a
+// call fails to reach it, or it responds with a 502, 503 or 504 status, so that
+// retries (see WithRetry) are made to the endpoint resolved from then on. Setting
+// the endpoint otherwise (see SetEndpoint) stops the resolution.
+func WithResolver(r Resolver) Option {
+	return func(c *Client) {
+		c.resolver = &resolvingTransport{r: r}
+		c.endpoint.Store(newEndpoint("https://" + resolvedHost))
+	}
+}
+
+// resolvingTransport sends requests to the resolved endpoint. It wraps the
+// transport of the client's HTTP client, once all options have been applied.
+type resolvingTransport struct {
+	r    Resolver
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	resolved *url.URL // Nil until resolved, and after a failure.
+}
+
+// wrap returns a copy of the HTTP client which sends its requests to the resolved
+// endpoint.
+func (t *resolvingTransport) wrap(httpClient *http.Client) *http.Client {
+	wrapped := *httpClient
+	t.base = httpClient.Transport
+	if t.base == nil {
+		t.base = http.DefaultTransport
+	}
+	wrapped.Transport = t
+	return &wrapped
+}
+
+func (t *resolvingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != resolvedHost {
+		return t.base.RoundTrip(req)
+	}
+	endpoint, err := t.endpoint(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("resolving endpoint: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme = endpoint.Scheme
+	req.URL.Host = endpoint.Host
+	req.URL.Path = strings.TrimSuffix(endpoint.Path, "/") + req.URL.Path
+	req.URL.RawPath = ""
+	req.Host = ""
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.invalidate(endpoint)
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		t.invalidate(endpoint)
+	}
+	return resp, nil
+}
+
+// endpoint returns the resolved endpoint, resolving it if need be.
+func (t *resolvingTransport) endpoint(ctx context.Context) (*url.URL, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.resolved != nil {
+		return t.resolved, nil
+	}
+	raw, err := t.r.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", raw)
+	}
+	t.resolved = u
+	return u, nil
+}
+
+// invalidate makes the next request resolve the endpoint again, unless it has
+// already been resolved again since the given one.
+func (t *resolvingTransport) invalidate(endpoint *url.URL) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.resolved == endpoint {
+		t.resolved = nil
+	}
+}
+
+// isResolvedURL reports whether a URL is on the resolved endpoint.
+func (t *resolvingTransport) isResolvedURL(u *url.URL) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.resolved != nil && u.Scheme == t.resolved.Scheme && u.Host == t.resolved.Host
+}
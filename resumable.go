@@ -0,0 +1,350 @@
+package operand
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// DefaultResumableChunkSize is the size of the parts of a resumable upload, by
+// default.
+const DefaultResumableChunkSize = 8 << 20
+
+// PropertyResumableUpload marks the folder holding the parts of a resumable upload,
+// and holds the key of the upload.
+const PropertyResumableUpload = "operand_resumable_upload"
+
+// UploadState is the persisted state of a resumable upload.
+type UploadState struct {
+	Key       string       `json:"key"`
+	FolderID  string       `json:"folder_id"`
+	ChunkSize int64        `json:"chunk_size"`
+	Parts     []UploadPart `json:"parts"`
+}
+
+// UploadPart is a confirmed part of a resumable upload.
+type UploadPart struct {
+	FileID string `json:"file_id"`
+	Offset int64  `json:"offset"`
+	Size   int64  `json:"size"`
+}
+
+// offset returns the offset the upload continues from.
+func (s *UploadState) offset() int64 {
+	if len(s.Parts) == 0 {
+		return 0
+	}
+	last := s.Parts[len(s.Parts)-1]
+	return last.Offset + last.Size
+}
+
+// UploadStateStore persists the state of resumable uploads, keyed by upload key.
+type UploadStateStore interface {
+	// Load returns the state of an upload, or nil if there is none.
+	Load(ctx context.Context, key string) (*UploadState, error)
+	Save(ctx context.Context, state *UploadState) error
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryUploadStateStore is an in-memory UploadStateStore, which allows uploads to
+// be resumed within a process.
+type MemoryUploadStateStore struct {
+	mu     sync.Mutex
+	states map[string]*UploadState
+}
+
+var _ UploadStateStore = (*MemoryUploadStateStore)(nil)
+
+// NewMemoryUploadStateStore returns a new MemoryUploadStateStore.
+func NewMemoryUploadStateStore() *MemoryUploadStateStore {
+	return &MemoryUploadStateStore{states: make(map[string]*UploadState)}
+}
+
+// Load returns the state of an upload.
+func (s *MemoryUploadStateStore) Load(_ context.Context, key string) (*UploadState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok {
+		return nil, nil
+	}
+	clone := *state
+	clone.Parts = append([]UploadPart(nil), state.Parts...)
+	return &clone, nil
+}
+
+// Save saves the state of an upload.
+func (s *MemoryUploadStateStore) Save(_ context.Context, state *UploadState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clone := *state
+	clone.Parts = append([]UploadPart(nil), state.Parts...)
+	s.states[state.Key] = &clone
+	return nil
+}
+
+// Delete deletes the state of an upload.
+func (s *MemoryUploadStateStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+// DirUploadStateStore is an UploadStateStore which keeps the state of each upload
+// in a JSON file within a local directory, so that uploads can be resumed across
+// restarts.
+type DirUploadStateStore string
+
+var _ UploadStateStore = DirUploadStateStore("")
+
+func (d DirUploadStateStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(string(d), hex.EncodeToString(sum[:16])+".json")
+}
+
+// Load returns the state of an upload.
+func (d DirUploadStateStore) Load(_ context.Context, key string) (*UploadState, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	state := new(UploadState)
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Save saves the state of an upload, atomically replacing any previous state.
+func (d DirUploadStateStore) Save(_ context.Context, state *UploadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(string(d), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(string(d), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), d.path(state.Key))
+}
+
+// Delete deletes the state of an upload.
+func (d DirUploadStateStore) Delete(_ context.Context, key string) error {
+	err := os.Remove(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ResumableUpload uploads a large file in parts, recording each confirmed part in a
+// store, so that an upload interrupted by a network failure can be resumed from the
+// last confirmed part, rather than restarting from zero.
+//
+// The API has no resumable upload sessions, and can't stitch files together
+// server-side, so, like a chunked file (see CreateChunkedFile), the file is stored
+// as a folder with the given name, holding the parts in order. Read it back with
+// ReadParts, or DownloadFile, which streams the parts of such folders as a single
+// file.
+type ResumableUpload struct {
+	c *Client
+	// Key identifies the upload across attempts, e.g. the path of the local file.
+	Key        string
+	Name       string
+	Parent     *string
+	Properties *filev1.Properties
+	// ChunkSize is the size of each part. If zero, DefaultResumableChunkSize is used.
+	// It's fixed when the upload starts.
+	ChunkSize int64
+	// Store persists the state of the upload. If nil, a MemoryUploadStateStore is
+	// used, so the upload can only be resumed by the same ResumableUpload.
+	Store UploadStateStore
+	// OnPart, if set, is called after each part is confirmed, with the number of
+	// bytes uploaded so far.
+	OnPart func(uploaded int64)
+}
+
+// NewResumableUpload returns a resumable upload of a file with the given key.
+func (c *Client) NewResumableUpload(key, name string, parent *string, properties *filev1.Properties) *ResumableUpload {
+	return &ResumableUpload{c: c, Key: key, Name: name, Parent: parent, Properties: properties}
+}
+
+// Upload uploads the data, starting from the last confirmed part of any previous
+// attempt. On failure, it can be called again with the same data to resume. Once
+// complete, it returns the folder holding the parts, and forgets the upload.
+func (u *ResumableUpload) Upload(ctx context.Context, data io.ReadSeeker) (*filev1.File, error) {
+	if u.Store == nil {
+		u.Store = NewMemoryUploadStateStore()
+	}
+	state, err := u.Store.Load(ctx, u.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upload state: %w", err)
+	}
+	if state == nil {
+		if state, err = u.start(ctx); err != nil {
+			return nil, err
+		}
+	} else if err := u.discardUnconfirmed(ctx, state); err != nil {
+		return nil, err
+	}
+
+	progress := uploadProgress(ctx)
+	var total int64 = -1
+	if progress != nil {
+		if total, err = data.Seek(0, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	}
+	buf := make([]byte, state.ChunkSize)
+	offset := state.offset()
+	if _, err := data.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	for {
+		n, err := io.ReadFull(data, buf)
+		if err == io.EOF {
+			break
+		} else if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		index := len(state.Parts)
+		properties := SetProperty(nil, PropertyChunkIndex, NumberProperty(float64(index)))
+		properties = SetProperty(properties, PropertyChunkStart, NumberProperty(float64(offset)))
+		properties = SetProperty(properties, PropertyChunkEnd, NumberProperty(float64(offset+int64(n))))
+		partCtx := ctx
+		if progress != nil {
+			partOffset := offset
+			partCtx = WithUploadProgress(ctx, func(sent, _ int64) { progress(partOffset+sent, total) })
+		}
+		resp, err := u.c.createFile(partCtx, partName(index), &state.FolderID, bytes.NewReader(buf[:n]), properties)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload part %d: %w", index, err)
+		}
+		state.Parts = append(state.Parts, UploadPart{FileID: resp.File.Id, Offset: offset, Size: int64(n)})
+		if err := u.Store.Save(ctx, state); err != nil {
+			return nil, fmt.Errorf("failed to save upload state: %w", err)
+		}
+		offset += int64(n)
+		if u.OnPart != nil {
+			u.OnPart(offset)
+		}
+		if n < len(buf) {
+			break
+		}
+	}
+
+	folder, err := u.c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: state.FolderID}},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if err := u.Store.Delete(ctx, u.Key); err != nil {
+		return nil, fmt.Errorf("failed to delete upload state: %w", err)
+	}
+	return folder.Msg.File, nil
+}
+
+// Start runs Upload in the background, and returns a handle to track it. Cancelling
+// the handle stops the upload, which can be resumed later with the same key.
+func (u *ResumableUpload) Start(ctx context.Context, data io.ReadSeeker) *OperationHandle[*filev1.File] {
+	return startOperation(ctx, u.Key, func(ctx context.Context) (*filev1.File, error) {
+		return u.Upload(ctx, data)
+	})
+}
+
+// start creates the folder of a new upload, and records it.
+func (u *ResumableUpload) start(ctx context.Context) (*UploadState, error) {
+	chunkSize := u.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultResumableChunkSize
+	}
+	properties := SetProperty(cloneProperties(u.Properties), PropertyResumableUpload, TextProperty(u.Key))
+	resp, err := u.c.CreateFile(ctx, u.Name, u.Parent, nil, properties)
+	if err != nil {
+		return nil, err
+	}
+	state := &UploadState{Key: u.Key, FolderID: resp.File.Id, ChunkSize: chunkSize}
+	if err := u.Store.Save(ctx, state); err != nil {
+		return nil, fmt.Errorf("failed to save upload state: %w", err)
+	}
+	return state, nil
+}
+
+// discardUnconfirmed deletes parts which were uploaded, but not confirmed, by a
+// previous attempt, e.g. as it failed before receiving the response.
+func (u *ResumableUpload) discardUnconfirmed(ctx context.Context, state *UploadState) error {
+	files, err := u.c.ListFolder(ctx, state.FolderID)
+	if err != nil {
+		return err
+	}
+	confirmed := make(map[string]bool, len(state.Parts))
+	for _, p := range state.Parts {
+		confirmed[p.FileID] = true
+	}
+	for _, f := range files {
+		if !confirmed[f.Id] {
+			if err := u.c.DeleteFile(ctx, f.Id); err != nil {
+				return fmt.Errorf("failed to discard unconfirmed part %s: %w", f.Id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func partName(index int) string {
+	return fmt.Sprintf("part-%06d", index)
+}
+
+// ReadParts writes the content of a file uploaded with ResumableUpload, i.e. the
+// concatenation of its parts, to w.
+func (c *Client) ReadParts(ctx context.Context, folderID string, w io.Writer) error {
+	parts, err := c.ListFolder(ctx, folderID)
+	if err != nil {
+		return err
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		a, _ := PropertyNumber(parts[i].Properties, PropertyChunkIndex)
+		b, _ := PropertyNumber(parts[j].Properties, PropertyChunkIndex)
+		return a < b
+	})
+	for _, part := range parts {
+		resp, err := c.download(ctx, part.DownloadUrl)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package operand_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	operand "github.com/operandinc/go-sdk"
+	"github.com/operandinc/go-sdk/operandtest"
+)
+
+func TestResumableUploadResumesFromConfirmedParts(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	content := strings.Repeat("0123456789", 10)
+
+	// The first attempt is interrupted after two parts.
+	ctx, cancel := context.WithCancel(context.Background())
+	upload := client.NewResumableUpload("key", "file.txt", nil, nil)
+	upload.ChunkSize = 16
+	upload.Store = operand.NewMemoryUploadStateStore()
+	var uploaded []int64
+	upload.OnPart = func(n int64) {
+		uploaded = append(uploaded, n)
+		if n == 2*upload.ChunkSize {
+			cancel()
+		}
+	}
+	if _, err := upload.Upload(ctx, strings.NewReader(content)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	folder, err := upload.Upload(context.Background(), strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the remaining parts are uploaded once resumed, and nothing is uploaded
+	// again once they're confirmed.
+	want := []int64{16, 32, 48, 64, 80, 96, 100}
+	if len(uploaded) != len(want) {
+		t.Fatalf("uploaded parts up to %v, want %v", uploaded, want)
+	}
+	for i := range want {
+		if uploaded[i] != want[i] {
+			t.Fatalf("uploaded parts up to %v, want %v", uploaded, want)
+		}
+	}
+	var total int
+	for _, file := range srv.Files() {
+		if !operand.IsFolder(file) {
+			total += int(file.GetSizeBytes())
+		}
+	}
+	if total != len(content) {
+		t.Errorf("uploaded %d bytes, want %d", total, len(content))
+	}
+
+	r, _, err := client.DownloadFile(context.Background(), folder.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != content {
+		t.Errorf("downloaded %q, want %q", got, content)
+	}
+}
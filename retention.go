@@ -0,0 +1,180 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+// PropertyExpiresAt holds the time (in unix seconds) at which a file expires, and
+// is the default ExpiresAtProperty of retention policies.
+const PropertyExpiresAt = "operand_expires_at"
+
+// RetentionPolicy describes when files expire. A file expires if it's matched by the
+// policy and any of its limits are exceeded.
+type RetentionPolicy struct {
+	Name string
+	// Filter, if set, restricts the policy to the files whose properties match it
+	// (see MatchesFilter).
+	Filter *operandv1.Filter
+	// MaxAge, if set, expires files which haven't been updated for longer.
+	MaxAge time.Duration
+	// MaxCount, if set, expires all but the most recently updated MaxCount files
+	// matched by the policy within each folder.
+	MaxCount int
+	// ExpiresAt expires files once the time held by their ExpiresAtProperty (which
+	// defaults to PropertyExpiresAt) has passed.
+	ExpiresAt         bool
+	ExpiresAtProperty string
+}
+
+// Retention applies retention policies to a tree of files. Files are checked
+// against each policy in order, and expire as soon as one policy expires them.
+// Folders never expire. To apply the policies periodically, run Apply from a
+// Background subsystem.
+type Retention struct {
+	Client   *Client
+	RootID   string // Empty for the root.
+	Policies []RetentionPolicy
+	// DryRun reports the files which would expire, without changing anything.
+	DryRun bool
+	// TrashID, if set, is a folder into which expired files are moved, rather than
+	// being deleted. The trash is never itself subject to the policies.
+	TrashID string
+}
+
+// Expired is a file expired by a retention policy.
+type Expired struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Policy    string    `json:"policy"`
+	Reason    string    `json:"reason"`
+	// Done is set if the file was deleted (or trashed). Err is set if it couldn't be.
+	Done bool  `json:"done"`
+	Err  error `json:"-"`
+}
+
+// RetentionReport is the result of applying retention policies.
+type RetentionReport struct {
+	EvaluatedAt time.Time  `json:"evaluated_at"`
+	DryRun      bool       `json:"dry_run"`
+	Scanned     int        `json:"scanned"`
+	Expired     []*Expired `json:"expired"`
+}
+
+type retained struct {
+	file *filev1.File
+	path string
+}
+
+// Apply evaluates the policies, and deletes (or trashes) the expired files. Failures
+// to delete individual files, e.g. because they're protected by a legal hold (see
+// ErrProtected), are recorded in the report, rather than stopping the run.
+func (r *Retention) Apply(ctx context.Context) (*RetentionReport, error) {
+	now := r.Client.clock.Now()
+	report := &RetentionReport{EvaluatedAt: now.UTC(), DryRun: r.DryRun}
+
+	var files []retained
+	err := r.Client.Walk(ctx, r.RootID, func(file *filev1.File, path string) error {
+		if r.TrashID != "" && file.Id == r.TrashID {
+			return SkipDir
+		}
+		if !IsFolder(file) {
+			report.Scanned++
+			files = append(files, retained{file: file, path: path})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make(map[string]bool)
+	expire := func(f retained, policy *RetentionPolicy, reason string) {
+		if expired[f.file.Id] {
+			return
+		}
+		expired[f.file.Id] = true
+		report.Expired = append(report.Expired, &Expired{
+			ID:        f.file.Id,
+			Path:      f.path,
+			UpdatedAt: f.file.GetUpdatedAt().AsTime(),
+			Policy:    policy.Name,
+			Reason:    reason,
+		})
+	}
+
+	for i := range r.Policies {
+		policy := &r.Policies[i]
+		perFolder := make(map[string][]retained)
+		for _, f := range files {
+			if expired[f.file.Id] || !MatchesFilter(policy.Filter, f.file.GetProperties()) {
+				continue
+			}
+			updatedAt := f.file.GetUpdatedAt().AsTime()
+			if policy.MaxAge > 0 && now.Sub(updatedAt) > policy.MaxAge {
+				expire(f, policy, fmt.Sprintf("not updated for more than %s", policy.MaxAge))
+				continue
+			}
+			if policy.ExpiresAt {
+				key := policy.ExpiresAtProperty
+				if key == "" {
+					key = PropertyExpiresAt
+				}
+				if at, ok := PropertyNumber(f.file.GetProperties(), key); ok && now.Unix() >= int64(at) {
+					expire(f, policy, fmt.Sprintf("expired at %s", time.Unix(int64(at), 0).UTC().Format(time.RFC3339)))
+					continue
+				}
+			}
+			perFolder[f.file.GetParentId()] = append(perFolder[f.file.GetParentId()], f)
+		}
+
+		if policy.MaxCount <= 0 {
+			continue
+		}
+		for _, folder := range perFolder {
+			if len(folder) <= policy.MaxCount {
+				continue
+			}
+			sort.SliceStable(folder, func(i, j int) bool {
+				return folder[i].file.GetUpdatedAt().AsTime().After(folder[j].file.GetUpdatedAt().AsTime())
+			})
+			for _, f := range folder[policy.MaxCount:] {
+				expire(f, policy, fmt.Sprintf("more than %d files in folder", policy.MaxCount))
+			}
+		}
+	}
+
+	if r.DryRun {
+		return report, nil
+	}
+	for _, e := range report.Expired {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if r.TrashID != "" {
+			e.Err = r.trash(ctx, e.ID)
+		} else {
+			e.Err = r.Client.DeleteFile(ctx, e.ID)
+		}
+		e.Done = e.Err == nil
+	}
+	return report, nil
+}
+
+func (r *Retention) trash(ctx context.Context, id string) error {
+	if err := r.Client.checkDeletable(ctx, id); err != nil {
+		return err
+	}
+	_, err := r.Client.FileService().UpdateFile(ctx, connect.NewRequest(&filev1.UpdateFileRequest{
+		Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: id}},
+		ParentId: &r.TrashID,
+	}))
+	return err
+}
@@ -0,0 +1,199 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+)
+
+// Defaults of RetryPolicy.
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryBaseDelay   = 100 * time.Millisecond
+	DefaultRetryMaxDelay    = 5 * time.Second
+	DefaultRetryJitter      = 0.5
+)
+
+// DefaultRetryCodes are the codes of the failures which are retried by default.
+// Failures to reach the API (e.g. connection resets) and 502, 503 and 504 responses
+// are reported as connect.CodeUnavailable.
+var DefaultRetryCodes = []connect.Code{connect.CodeUnavailable, connect.CodeResourceExhausted}
+
+// RetryPolicy configures the retries of failed calls (see WithRetry).
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a call, including the first.
+	// Defaults to DefaultRetryMaxAttempts.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, which doubles after each
+	// attempt, up to MaxDelay. Default to DefaultRetryBaseDelay and
+	// DefaultRetryMaxDelay. A longer delay requested by the server (see
+	// Error.RetryDelay and Error.RateLimit) takes precedence.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Jitter is the fraction of each delay which is randomized, between 0 and 1.
+	// Zero means DefaultRetryJitter; set it to a negative value to disable jitter.
+	Jitter float64
+	// Codes are the codes of the failures which are retried. Defaults to
+	// DefaultRetryCodes.
+	Codes []connect.Code
+	// OnRetry, if set, is called before each retry, with the number of the failed
+	// attempt, its error, and the delay before the next one.
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// WithRetry retries unary RPCs and uploads which fail transiently, with exponential
+// backoff. Uploads are only retried if their data is nil (i.e. folders) or an
+// io.Seeker, as it must be read again. Streams and downloads aren't retried. All
+// attempts share the operation ID of the call (see WithOperationID).
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) {
+		if policy.MaxAttempts <= 0 {
+			policy.MaxAttempts = DefaultRetryMaxAttempts
+		}
+		if policy.BaseDelay <= 0 {
+			policy.BaseDelay = DefaultRetryBaseDelay
+		}
+		if policy.MaxDelay <= 0 {
+			policy.MaxDelay = DefaultRetryMaxDelay
+		}
+		if policy.Jitter == 0 {
+			policy.Jitter = DefaultRetryJitter
+		} else if policy.Jitter < 0 {
+			policy.Jitter = 0
+		} else if policy.Jitter > 1 {
+			policy.Jitter = 1
+		}
+		if policy.Codes == nil {
+			policy.Codes = DefaultRetryCodes
+		}
+		c.retry = &policy
+	}
+}
+
+// retryable reports whether a failed call may be retried.
+func (p *RetryPolicy) retryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	code := errorCode(err)
+	for _, c := range p.Codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the delay before the retry following the given (1-based) attempt.
+func (p *RetryPolicy) delay(c *Client, attempt int, err error) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(float64(d) * p.Jitter * c.rand.Float64())
+	}
+	var apiErr *Error
+	if !errors.As(c.apiError(err), &apiErr) {
+		return d
+	}
+	if apiErr.RetryDelay > d {
+		d = apiErr.RetryDelay
+	}
+	if apiErr.RateLimit != nil {
+		if wait, ok := apiErr.RateLimit.Wait(c.clock.Now()); ok && wait > d {
+			d = wait
+		}
+	}
+	return d
+}
+
+// withRetries calls fn until it succeeds, fails permanently, or runs out of
+// attempts, according to the client's retry policy and throttle. Throttled attempts
+// count toward the attempts of the retry policy, if any. If the client has neither,
+// fn is only called once.
+func (c *Client) withRetries(ctx context.Context, fn func() error) error {
+	p := c.retry
+	var throttled time.Duration
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		var d time.Duration
+		if wait, ok := c.throttleWait(err); ok && throttled+wait <= c.throttle && (p == nil || attempt < p.MaxAttempts) {
+			d = wait
+			throttled += wait
+		} else if p != nil && attempt < p.MaxAttempts && p.retryable(ctx, err) {
+			d = p.delay(c, attempt, err)
+		} else {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && c.clock.Now().Add(d).After(deadline) {
+			return err // The call would time out before the retry.
+		}
+		if p != nil && p.OnRetry != nil {
+			p.OnRetry(attempt, err, d)
+		}
+		recordRetry(ctx)
+		select {
+		case <-c.clock.After(d):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// rewindable returns a function which rewinds upload data before a retry, or nil
+// if the data can't be read again.
+func rewindable(data io.Reader) func() error {
+	if data == nil {
+		return func() error { return nil }
+	}
+	s, ok := data.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	start, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return func() error {
+		_, err := s.Seek(start, io.SeekStart)
+		return err
+	}
+}
+
+// retryInterceptor retries (and throttles) unary RPCs. It sits within the operation
+// interceptor, so that all attempts share an operation ID.
+type retryInterceptor struct {
+	c *Client
+}
+
+var _ connect.Interceptor = retryInterceptor{}
+
+func (ri retryInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		var resp connect.AnyResponse
+		err := ri.c.withRetries(ctx, func() error {
+			var err error
+			resp, err = next(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+func (retryInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next // Streams can't be replayed.
+}
+
+func (retryInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
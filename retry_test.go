@@ -0,0 +1,69 @@
+package operand_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+)
+
+// rateLimitedServer returns a server which rejects every request with a rate limit
+// whose reset has already passed, and counts the requests.
+func rateLimitedServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set(operand.HeaderRateLimitLimit, "10")
+		w.Header().Set(operand.HeaderRateLimitRemaining, "0")
+		w.Header().Set(operand.HeaderRateLimitReset, strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code":"resource_exhausted","message":"rate limited"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestThrottleWithPastResetBacksOff(t *testing.T) {
+	srv, requests := rateLimitedServer(t)
+	client := operand.NewClient("key", operand.WithEndpoint(srv.URL), operand.WithThrottle(350*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := client.TenantService().ListAPIKeys(ctx, connect.NewRequest(&tenantv1.ListAPIKeysRequest{}))
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Fatalf("got %v, want a resource_exhausted error", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("the call was only stopped by its deadline")
+	}
+	// Each wait is at least DefaultRetryBaseDelay, so the 350ms throttle allows
+	// three retries.
+	if got := requests.Load(); got != 4 {
+		t.Errorf("made %d requests, want 4", got)
+	}
+}
+
+func TestThrottleCountsTowardRetryAttempts(t *testing.T) {
+	srv, requests := rateLimitedServer(t)
+	client := operand.NewClient("key",
+		operand.WithEndpoint(srv.URL),
+		operand.WithThrottle(time.Minute),
+		operand.WithRetry(operand.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}),
+	)
+
+	_, err := client.TenantService().ListAPIKeys(context.Background(), connect.NewRequest(&tenantv1.ListAPIKeysRequest{}))
+	if connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Fatalf("got %v, want a resource_exhausted error", err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("made %d requests, want 3", got)
+	}
+}
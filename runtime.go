@@ -0,0 +1,161 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultStopTimeout is the time a subsystem is given to stop, unless configured.
+const DefaultStopTimeout = 10 * time.Second
+
+// Subsystem is a long-running component, e.g. a scheduler, importer, or uploader.
+type Subsystem interface {
+	// Start starts the subsystem. It shouldn't block once the subsystem is running.
+	Start(ctx context.Context) error
+	// Stop stops the subsystem, giving up once the context is done.
+	Stop(ctx context.Context) error
+}
+
+// Hooks is a Subsystem made of a pair of functions, either of which may be nil.
+type Hooks struct {
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+var _ Subsystem = Hooks{}
+
+// Start calls OnStart.
+func (h Hooks) Start(ctx context.Context) error {
+	if h.OnStart == nil {
+		return nil
+	}
+	return h.OnStart(ctx)
+}
+
+// Stop calls OnStop.
+func (h Hooks) Stop(ctx context.Context) error {
+	if h.OnStop == nil {
+		return nil
+	}
+	return h.OnStop(ctx)
+}
+
+// Background returns a Subsystem which runs fn under the supervisor (restarting it
+// if it fails) from when it's started, until it's stopped. Stopping it cancels the
+// context passed to fn, and waits for fn to return. If sup is nil, a Supervisor
+// with the default configuration is used.
+func Background(sup *Supervisor, name string, fn func(ctx context.Context) error) Subsystem {
+	if sup == nil {
+		sup = &Supervisor{}
+	}
+	return &background{sup: sup, name: name, fn: fn}
+}
+
+type background struct {
+	sup  *Supervisor
+	name string
+	fn   func(ctx context.Context) error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (b *background) Start(context.Context) error {
+	if b.done != nil {
+		return errors.New("already started")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel, b.done = cancel, make(chan struct{})
+	b.sup.wg.Add(1)
+	go func() {
+		defer b.sup.wg.Done()
+		defer close(b.done)
+		b.sup.supervise(ctx, b.name, b.fn)
+	}()
+	return nil
+}
+
+func (b *background) Stop(ctx context.Context) error {
+	if b.done == nil {
+		return nil
+	}
+	b.cancel()
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Runtime composes subsystems which depend on one another, e.g. a scheduler which
+// feeds an importer which feeds an uploader. Subsystems are started in the order in
+// which they're added, so each should be added after those it depends on, and are
+// stopped in reverse order.
+type Runtime struct {
+	mu      sync.Mutex
+	stages  []stage
+	started int // Number of stages started.
+}
+
+type stage struct {
+	name        string
+	subsystem   Subsystem
+	stopTimeout time.Duration
+}
+
+// Add adds a subsystem to the runtime. The subsystem is given stopTimeout to stop,
+// or DefaultStopTimeout if it's zero. Subsystems can't be added once started.
+func (rt *Runtime) Add(name string, s Subsystem, stopTimeout time.Duration) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.started > 0 {
+		panic("operand: Runtime.Add called after Start")
+	}
+	if stopTimeout <= 0 {
+		stopTimeout = DefaultStopTimeout
+	}
+	rt.stages = append(rt.stages, stage{name: name, subsystem: s, stopTimeout: stopTimeout})
+}
+
+// Start starts each subsystem in order. If one fails to start, those that have
+// already been started are stopped, and the error is returned.
+func (rt *Runtime) Start(ctx context.Context) error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	for rt.started < len(rt.stages) {
+		s := rt.stages[rt.started]
+		if err := s.subsystem.Start(ctx); err != nil {
+			rt.stop(context.Background())
+			return fmt.Errorf("failed to start %s: %w", s.name, err)
+		}
+		rt.started++
+	}
+	return nil
+}
+
+// Stop stops each started subsystem in reverse order, giving each its own stop
+// timeout (or less, if the context is done sooner). All subsystems are stopped even
+// if some fail to, in which case the first error is returned.
+func (rt *Runtime) Stop(ctx context.Context) error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.stop(ctx)
+}
+
+func (rt *Runtime) stop(ctx context.Context) error {
+	var first error
+	for ; rt.started > 0; rt.started-- {
+		s := rt.stages[rt.started-1]
+		stopCtx, cancel := context.WithTimeout(ctx, s.stopTimeout)
+		err := s.subsystem.Stop(stopCtx)
+		cancel()
+		if err != nil && first == nil {
+			first = fmt.Errorf("failed to stop %s: %w", s.name, err)
+		}
+	}
+	return first
+}
@@ -0,0 +1,88 @@
+// Package s3blob implements operand.BlobStore for S3-compatible object storage,
+// such as AWS S3, MinIO, Cloudflare R2, or Google Cloud Storage (via its XML API).
+package s3blob
+
+import (
+	"context"
+	"io"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	operand "github.com/operandinc/go-sdk"
+)
+
+// Store is a BlobStore backed by a bucket. Blobs are streamed to the bucket as
+// they're written, using multipart uploads for large blobs, so nothing is buffered
+// on local disk.
+type Store struct {
+	client   *s3.Client
+	uploader *manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+var _ operand.BlobStore = (*Store)(nil)
+
+// New returns a new Store which stores blobs in the bucket, with keys prefixed by
+// prefix (which may be empty).
+func New(client *s3.Client, bucket, prefix string) *Store {
+	return &Store{
+		client:   client,
+		uploader: manager.NewUploader(client),
+		bucket:   bucket,
+		prefix:   prefix,
+	}
+}
+
+func (s *Store) key(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+// Create starts uploading a blob. The upload completes once the returned writer is
+// closed, and Close returns any error from the upload.
+func (s *Store) Create(ctx context.Context, name string) (io.WriteCloser, error) {
+	pr, pw := io.Pipe()
+	w := &writer{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(s.key(name)),
+			Body:   pr,
+		})
+		w.err = err
+		// Unblock any pending writes if the upload failed.
+		pr.CloseWithError(err)
+	}()
+	return w, nil
+}
+
+// Open opens a blob for reading.
+func (s *Store) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+type writer struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+	err  error
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+func (w *writer) Close() error {
+	w.pw.Close()
+	<-w.done
+	return w.err
+}
@@ -0,0 +1,98 @@
+package operand
+
+import (
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"golang.org/x/text/unicode/norm"
+)
+
+// PropertyOriginalName holds the original name of a file whose name was changed by
+// a NameSanitizer, so that it can be restored (see OriginalName).
+const PropertyOriginalName = "operand_original_name"
+
+// DefaultMaxNameLength is the maximum length of names, in bytes, used by
+// DefaultNameSanitizer. It's the limit of most local file systems.
+const DefaultMaxNameLength = 255
+
+// NameSanitizer makes file names consistent and safe before they're uploaded. Path
+// separators and control characters are always replaced.
+type NameSanitizer struct {
+	// Normalize applies Unicode NFC normalization, so that names which look the same
+	// (e.g. from macOS and Linux file systems) are the same.
+	Normalize bool
+	// FoldCase lower-cases names.
+	FoldCase bool
+	// MaxLength is the maximum length of a name in bytes, preserving its extension.
+	// Zero means no limit.
+	MaxLength int
+	// Replacement replaces path separators and control characters. Defaults to "_".
+	Replacement string
+}
+
+// DefaultNameSanitizer returns a sanitizer which normalizes names, and limits them
+// to DefaultMaxNameLength.
+func DefaultNameSanitizer() *NameSanitizer {
+	return &NameSanitizer{Normalize: true, MaxLength: DefaultMaxNameLength}
+}
+
+// Sanitize returns the sanitized name.
+func (s *NameSanitizer) Sanitize(name string) string {
+	if s.Normalize {
+		name = norm.NFC.String(name)
+	}
+	if s.FoldCase {
+		name = strings.ToLower(name)
+	}
+	replacement := s.Replacement
+	if replacement == "" {
+		replacement = "_"
+	}
+	var b strings.Builder
+	for _, r := range name {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			b.WriteString(replacement)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	name = b.String()
+	if name == "" || name == "." || name == ".." {
+		name = replacement
+	}
+
+	if s.MaxLength > 0 && len(name) > s.MaxLength {
+		ext := path.Ext(name)
+		if len(ext) >= s.MaxLength {
+			ext = ""
+		}
+		base := name[:s.MaxLength-len(ext)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		name = base + ext
+	}
+	return name
+}
+
+// Apply sanitizes a name. If it's changed, the original name is recorded in the
+// returned properties (a copy of the given ones).
+func (s *NameSanitizer) Apply(name string, properties *filev1.Properties) (string, *filev1.Properties) {
+	sanitized := s.Sanitize(name)
+	if sanitized == name {
+		return name, properties
+	}
+	return sanitized, SetProperty(cloneProperties(properties), PropertyOriginalName, TextProperty(name))
+}
+
+// OriginalName returns the name a file had before it was sanitized, or its name if
+// it wasn't.
+func OriginalName(file *filev1.File) string {
+	if name, ok := PropertyText(file.GetProperties(), PropertyOriginalName); ok {
+		return name
+	}
+	return file.GetName()
+}
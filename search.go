@@ -0,0 +1,192 @@
+package operand
+
+import (
+	"context"
+
+	"github.com/bufbuild/connect-go"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+// DefaultRerankCandidates is the number of candidates fetched from the API when a
+// reranker is configured without an explicit candidate count.
+const DefaultRerankCandidates = 50
+
+// SearchOption configures a call to Client.Search.
+type SearchOption func(*searchOptions)
+
+type searchOptions struct {
+	maxResults       int32
+	parentID         *string
+	filter           *operandv1.Filter
+	adjacentSnippets *int32
+	reranker         Reranker
+	candidates       int32
+	access           *accessPolicy
+	language         string
+	detectLanguage   bool
+	hydrate          *HydrateOptions
+}
+
+// WithMaxResults sets the maximum number of matches returned by Search.
+func WithMaxResults(n int32) SearchOption {
+	return func(o *searchOptions) { o.maxResults = n }
+}
+
+// WithParent restricts Search to the files within the given directory.
+// An empty parent ID restricts the search to the root.
+func WithParent(parentID string) SearchOption {
+	return func(o *searchOptions) { o.parentID = &parentID }
+}
+
+// WithFilter applies a property filter to Search.
+func WithFilter(filter *operandv1.Filter) SearchOption {
+	return func(o *searchOptions) { o.filter = filter }
+}
+
+// WithAdjacentSnippets includes up to n adjacent snippets for each match.
+func WithAdjacentSnippets(n int32) SearchOption {
+	return func(o *searchOptions) { o.adjacentSnippets = &n }
+}
+
+// WithReranker applies the given reranker to the results of Search. The API is
+// asked for candidates matches (DefaultRerankCandidates if zero), or the requested
+// number of results if that's more, which are then reranked and truncated to the
+// requested number of results.
+func WithReranker(r Reranker, candidates int32) SearchOption {
+	return func(o *searchOptions) {
+		o.reranker = r
+		o.candidates = candidates
+	}
+}
+
+// Reranker reorders search matches, e.g. by calling out to a cross-encoder or an LLM.
+// Implementations may also drop matches they consider irrelevant.
+type Reranker interface {
+	Rerank(
+		ctx context.Context,
+		query string,
+		resp *operandv1.SearchResponse,
+	) ([]*operandv1.ContentMatch, error)
+}
+
+// RerankerFunc is an adapter to allow the use of ordinary functions as rerankers.
+type RerankerFunc func(
+	ctx context.Context,
+	query string,
+	resp *operandv1.SearchResponse,
+) ([]*operandv1.ContentMatch, error)
+
+// Rerank calls f(ctx, query, resp).
+func (f RerankerFunc) Rerank(
+	ctx context.Context,
+	query string,
+	resp *operandv1.SearchResponse,
+) ([]*operandv1.ContentMatch, error) {
+	return f(ctx, query, resp)
+}
+
+// Search is a utility method for searching over the contents of files. It wraps the
+// Operand Service's Search RPC, and applies any configured access restrictions and
+// reranker to the results. The call is bounded by the budget of the context, if any
+// (see WithBudget).
+func (c *Client) Search(
+	ctx context.Context,
+	query string,
+	opts ...SearchOption,
+) (*operandv1.SearchResponse, error) {
+	o := new(searchOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	if budget, ok := BudgetOf(ctx); ok {
+		budget.limitSearch(o)
+	}
+
+	ctx = c.withOperation(ctx)
+	ctx, cancel := c.budgetTimeout(ctx)
+	defer cancel()
+	started := c.clock.Now()
+	resp, err := c.search(ctx, query, o)
+	c.auditSearch(ctx, started, query, o, len(resp.GetMatches()), err)
+	return resp, err
+}
+
+func (c *Client) search(
+	ctx context.Context,
+	query string,
+	o *searchOptions,
+) (*operandv1.SearchResponse, error) {
+	ctx = o.searchLanguage(ctx, query)
+	req := &operandv1.SearchRequest{
+		Query:            query,
+		MaxResults:       o.maxResults,
+		ParentId:         o.parentID,
+		Filter:           o.filter,
+		AdjacentSnippets: o.adjacentSnippets,
+	}
+	if o.reranker != nil {
+		req.MaxResults = o.candidates
+		if req.MaxResults == 0 {
+			req.MaxResults = DefaultRerankCandidates
+		}
+		// Fewer candidates than results would return fewer results than requested.
+		req.MaxResults = max(req.MaxResults, o.maxResults)
+	}
+
+	var (
+		result *operandv1.SearchResponse
+		err    error
+	)
+	if o.access != nil {
+		result, err = c.searchRestricted(ctx, req, o.access)
+	} else {
+		result, err = c.searchOnce(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if o.reranker != nil {
+		matches, err := o.reranker.Rerank(ctx, query, result)
+		if err != nil {
+			return nil, err
+		}
+		if o.maxResults > 0 && len(matches) > int(o.maxResults) {
+			matches = matches[:o.maxResults]
+		}
+		result.Matches = matches
+		pruneFiles(result)
+	}
+
+	if o.hydrate != nil {
+		if err := c.hydrate(ctx, result, o.hydrate); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (c *Client) searchOnce(
+	ctx context.Context,
+	req *operandv1.SearchRequest,
+) (*operandv1.SearchResponse, error) {
+	resp, err := c.OperandService().Search(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg, nil
+}
+
+// pruneFiles removes files from the response which are no longer referenced by any match.
+func pruneFiles(resp *operandv1.SearchResponse) {
+	referenced := make(map[string]bool, len(resp.Matches))
+	for _, m := range resp.Matches {
+		referenced[m.FileId] = true
+	}
+	for id := range resp.Files {
+		if !referenced[id] {
+			delete(resp.Files, id)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package operand_test
+
+import (
+	"context"
+	"testing"
+
+	operand "github.com/operandinc/go-sdk"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+)
+
+func TestRerankedSearchFetchesAtLeastMaxResults(t *testing.T) {
+	var requested int32
+	client := searchClient(t, &searchService{search: func(_ context.Context, req *operandv1.SearchRequest) (*operandv1.SearchResponse, error) {
+		requested = req.MaxResults
+		return &operandv1.SearchResponse{}, nil
+	}})
+	keep := operand.RerankerFunc(func(_ context.Context, _ string, resp *operandv1.SearchResponse) ([]*operandv1.ContentMatch, error) {
+		return resp.Matches, nil
+	})
+
+	_, err := client.Search(context.Background(), "query", operand.WithMaxResults(20), operand.WithReranker(keep, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requested != 20 {
+		t.Errorf("requested %d candidates, want 20", requested)
+	}
+}
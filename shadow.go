@@ -0,0 +1,203 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	operandv1 "github.com/operandinc/go-sdk/operand/v1"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Defaults for Shadow.
+const (
+	DefaultShadowTimeout     = 30 * time.Second
+	DefaultShadowMaxInFlight = 16
+)
+
+// Shadow duplicates reads made by a client against a secondary endpoint, e.g. a
+// new region or a staging environment, and compares the results, so that it can be
+// validated with production traffic. Shadow calls are fire-and-forget: they don't
+// delay or affect the results of the primary calls.
+type Shadow struct {
+	// Secondary is the client for the secondary endpoint.
+	Secondary *Client
+	// Sample is the fraction of reads which are shadowed. Zero shadows all of them.
+	Sample float64
+	// Timeout bounds each shadow call. If zero, DefaultShadowTimeout is used.
+	Timeout time.Duration
+	// MaxInFlight is the maximum number of concurrent shadow calls. Reads made while
+	// it's reached aren't shadowed. If zero, DefaultShadowMaxInFlight is used.
+	MaxInFlight int
+	// Compare compares the responses of a call, and returns a description of how
+	// they differ, or "" if they match. Defaults to CompareShadow.
+	Compare func(procedure string, primary, secondary proto.Message) string
+	// OnResult is called (concurrently) with the result of each shadow call.
+	OnResult func(*ShadowResult)
+}
+
+// ShadowResult is the outcome of a shadowed call.
+type ShadowResult struct {
+	Procedure        string
+	PrimaryLatency   time.Duration
+	SecondaryLatency time.Duration
+	PrimaryErr       error
+	SecondaryErr     error
+	// Diff describes how the responses differ. It's empty if they match.
+	Diff string
+}
+
+// Match reports whether the secondary behaved like the primary.
+func (r *ShadowResult) Match() bool {
+	return r.Diff == "" && (r.PrimaryErr == nil) == (r.SecondaryErr == nil)
+}
+
+// WithShadow enables shadow mode.
+func WithShadow(s *Shadow) Option {
+	return func(c *Client) {
+		c.shadow = s
+		maxInFlight := s.MaxInFlight
+		if maxInFlight <= 0 {
+			maxInFlight = DefaultShadowMaxInFlight
+		}
+		c.shadowSem = make(chan struct{}, maxInFlight)
+	}
+}
+
+// shadowCalls are the read-only procedures which are shadowed.
+var shadowCalls = map[string]func(ctx context.Context, c *Client, req proto.Message) (proto.Message, error){
+	"/file.v1.FileService/GetFile": func(ctx context.Context, c *Client, req proto.Message) (proto.Message, error) {
+		resp, err := c.FileService().GetFile(ctx, connect.NewRequest(req.(*filev1.GetFileRequest)))
+		return msgOf(resp, err)
+	},
+	"/file.v1.FileService/ListFiles": func(ctx context.Context, c *Client, req proto.Message) (proto.Message, error) {
+		resp, err := c.FileService().ListFiles(ctx, connect.NewRequest(req.(*filev1.ListFilesRequest)))
+		return msgOf(resp, err)
+	},
+	"/operand.v1.OperandService/Search": func(ctx context.Context, c *Client, req proto.Message) (proto.Message, error) {
+		resp, err := c.OperandService().Search(ctx, connect.NewRequest(req.(*operandv1.SearchRequest)))
+		return msgOf(resp, err)
+	},
+	"/tenant.v1.TenantService/Usage": func(ctx context.Context, c *Client, req proto.Message) (proto.Message, error) {
+		resp, err := c.TenantService().Usage(ctx, connect.NewRequest(req.(*tenantv1.UsageRequest)))
+		return msgOf(resp, err)
+	},
+}
+
+func msgOf[T any](resp *connect.Response[T], err error) (proto.Message, error) {
+	if err != nil {
+		return nil, err
+	}
+	return any(resp.Msg).(proto.Message), nil
+}
+
+// CompareShadow is the default comparison of shadowed responses. As file IDs
+// differ between environments, search results are compared by the names of the
+// matching files, in order; other responses must be equal.
+func CompareShadow(procedure string, primary, secondary proto.Message) string {
+	if primary == nil || secondary == nil {
+		return ""
+	}
+	p, ok := primary.(*operandv1.SearchResponse)
+	if !ok {
+		if proto.Equal(primary, secondary) {
+			return ""
+		}
+		return "responses differ"
+	}
+	s := secondary.(*operandv1.SearchResponse)
+	if len(p.Matches) != len(s.Matches) {
+		return fmt.Sprintf("%d matches, secondary has %d", len(p.Matches), len(s.Matches))
+	}
+	for i := range p.Matches {
+		pn := p.Files[p.Matches[i].FileId].GetName()
+		sn := s.Files[s.Matches[i].FileId].GetName()
+		if pn != sn {
+			return fmt.Sprintf("match %d is %q, secondary has %q", i+1, pn, sn)
+		}
+	}
+	return ""
+}
+
+// shadowInterceptor duplicates reads against the secondary.
+type shadowInterceptor struct {
+	c *Client
+}
+
+var _ connect.Interceptor = shadowInterceptor{}
+
+func (si shadowInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		start := si.c.clock.Now()
+		resp, err := next(ctx, req)
+		primaryLatency := si.c.clock.Now().Sub(start)
+
+		s := si.c.shadow
+		call, ok := shadowCalls[req.Spec().Procedure]
+		if !ok || (s.Sample > 0 && si.c.rand.Float64() >= s.Sample) {
+			return resp, err
+		}
+		select {
+		case si.c.shadowSem <- struct{}{}:
+		default:
+			return resp, err // Too many shadow calls in flight.
+		}
+
+		result := &ShadowResult{
+			Procedure:      req.Spec().Procedure,
+			PrimaryLatency: primaryLatency,
+			PrimaryErr:     err,
+		}
+		var primary proto.Message
+		if err == nil {
+			primary = proto.Clone(resp.Any().(proto.Message))
+		}
+		shadowReq := proto.Clone(req.Any().(proto.Message))
+		go si.shadow(context.WithoutCancel(ctx), call, shadowReq, primary, result)
+		return resp, err
+	}
+}
+
+// shadow makes a shadow call, and reports its result.
+func (si shadowInterceptor) shadow(
+	ctx context.Context,
+	call func(context.Context, *Client, proto.Message) (proto.Message, error),
+	req, primary proto.Message,
+	result *ShadowResult,
+) {
+	defer func() { <-si.c.shadowSem }()
+	s := si.c.shadow
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultShadowTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := si.c.clock.Now()
+	secondary, err := call(ctx, s.Secondary, req)
+	result.SecondaryLatency = si.c.clock.Now().Sub(start)
+	result.SecondaryErr = err
+
+	compare := s.Compare
+	if compare == nil {
+		compare = CompareShadow
+	}
+	if primary != nil && secondary != nil {
+		result.Diff = compare(result.Procedure, primary, secondary)
+	}
+	if s.OnResult != nil {
+		s.OnResult(result)
+	}
+}
+
+func (shadowInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next // Only unary reads are shadowed.
+}
+
+func (shadowInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
@@ -0,0 +1,196 @@
+package operand
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+)
+
+// DefaultStatsWindow is the number of most recent calls per method which latency
+// percentiles are computed over.
+const DefaultStatsWindow = 1024
+
+// Methods tracked by Client.Stats, in addition to RPCs (which are identified by
+// their procedure, e.g. "/file.v1.FileService/GetFile").
+const (
+	MethodUpload   = "upload"   // Uploads made by CreateFile.
+	MethodDownload = "download" // Downloads of file content, up to the response headers.
+)
+
+// MethodStats are the latency statistics of a method.
+type MethodStats struct {
+	Method string
+	Calls  int64 // The total number of calls.
+	Errors int64 // The total number of calls which failed.
+	// Latency percentiles over the most recent calls (see DefaultStatsWindow).
+	P50, P90, P99, Max time.Duration
+}
+
+// SLO is a latency objective for a method. When the p99 latency of the method goes
+// above the threshold, the SLO's callback is called (once, until it recovers).
+type SLO struct {
+	// Method is the method the SLO applies to. Empty applies it to every method.
+	Method string
+	P99    time.Duration
+	// MinCalls is the number of calls needed in the window before the SLO is
+	// evaluated, so that a few slow calls after startup don't trigger it.
+	// Defaults to 100.
+	MinCalls int
+	// OnBreach is called with the method's statistics when the SLO is breached.
+	OnBreach func(MethodStats)
+}
+
+// WithSLO adds a latency objective to the client.
+func WithSLO(slo SLO) Option {
+	return func(c *Client) {
+		if slo.MinCalls <= 0 {
+			slo.MinCalls = 100
+		}
+		c.stats.slos = append(c.stats.slos, &sloState{SLO: slo, breached: make(map[string]bool)})
+	}
+}
+
+// Stats returns the latency statistics of every method called so far, sorted by
+// method. Statistics are kept in memory, in a fixed-size ring buffer per method,
+// to give some visibility without a metrics stack.
+func (c *Client) Stats() []MethodStats {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	stats := make([]MethodStats, 0, len(c.stats.methods))
+	for method, m := range c.stats.methods {
+		stats = append(stats, m.stats(method))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Method < stats[j].Method })
+	return stats
+}
+
+type latencyStats struct {
+	mu      sync.Mutex
+	methods map[string]*methodLatencies
+	slos    []*sloState
+	metrics MetricsCollector // Set by WithMetrics.
+}
+
+type methodLatencies struct {
+	calls, errors int64
+	window        []time.Duration // Ring buffer.
+	next          int
+}
+
+type sloState struct {
+	SLO
+	breached map[string]bool // Method -> whether the SLO is currently breached.
+}
+
+func newLatencyStats() *latencyStats {
+	return &latencyStats{methods: make(map[string]*methodLatencies)}
+}
+
+// record records the latency of a call, evaluates any SLOs for the method, and
+// reports it to the metrics collector, if any.
+func (s *latencyStats) record(method string, latency time.Duration, err error) {
+	if s.metrics != nil {
+		code := "ok"
+		if err != nil {
+			code = errorCode(err).String()
+		}
+		s.metrics.ObserveCall(method, code, latency)
+	}
+	s.mu.Lock()
+	m := s.methods[method]
+	if m == nil {
+		m = &methodLatencies{}
+		s.methods[method] = m
+	}
+	m.calls++
+	if err != nil {
+		m.errors++
+	}
+	if len(m.window) < DefaultStatsWindow {
+		m.window = append(m.window, latency)
+	} else {
+		m.window[m.next] = latency
+	}
+	m.next = (m.next + 1) % DefaultStatsWindow
+
+	var (
+		breaches []func(MethodStats)
+		stats    MethodStats
+	)
+	for _, slo := range s.slos {
+		if (slo.Method != "" && slo.Method != method) || len(m.window) < slo.MinCalls {
+			continue
+		}
+		if stats.Method == "" {
+			stats = m.stats(method)
+		}
+		breached := stats.P99 > slo.P99
+		if breached && !slo.breached[method] && slo.OnBreach != nil {
+			breaches = append(breaches, slo.OnBreach)
+		}
+		slo.breached[method] = breached
+	}
+	s.mu.Unlock()
+
+	// Callbacks are called without holding the lock, so that they may call Stats.
+	for _, fn := range breaches {
+		fn(stats)
+	}
+}
+
+func (m *methodLatencies) stats(method string) MethodStats {
+	sorted := append([]time.Duration(nil), m.window...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	stats := MethodStats{Method: method, Calls: m.calls, Errors: m.errors}
+	if len(sorted) > 0 {
+		stats.P50 = percentile(sorted, 50)
+		stats.P90 = percentile(sorted, 90)
+		stats.P99 = percentile(sorted, 99)
+		stats.Max = sorted[len(sorted)-1]
+	}
+	return stats
+}
+
+// statsInterceptor records the latency of RPCs.
+type statsInterceptor struct {
+	stats *latencyStats
+	clock Clock
+}
+
+var _ connect.Interceptor = statsInterceptor{}
+
+func (si statsInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		start := si.clock.Now()
+		resp, err := next(ctx, req)
+		si.stats.record(req.Spec().Procedure, si.clock.Now().Sub(start), err)
+		return resp, err
+	}
+}
+
+func (si statsInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		return &statsConn{StreamingClientConn: next(ctx, spec), si: si, start: si.clock.Now()}
+	}
+}
+
+func (statsInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next // No-op (client-only interceptor).
+}
+
+// statsConn records the latency of a stream, from when it was opened until it's
+// closed.
+type statsConn struct {
+	connect.StreamingClientConn
+	si    statsInterceptor
+	start time.Time
+}
+
+func (c *statsConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	c.si.stats.record(c.Spec().Procedure, c.si.clock.Now().Sub(c.start), err)
+	return err
+}
@@ -0,0 +1,178 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// PropertySummaryOf is set on summary sidecars, and holds the ID of the summarized file.
+const PropertySummaryOf = "operand_summary_of"
+
+// SummaryExtension is appended to the name of a file to name its summary sidecar.
+const SummaryExtension = ".summary.txt"
+
+// Summarizer generates a summary of a file, typically by calling out to an LLM.
+type Summarizer interface {
+	Summarize(ctx context.Context, file *filev1.File, content []byte) (string, error)
+}
+
+// SummarizerFunc is an adapter to allow the use of ordinary functions as summarizers.
+type SummarizerFunc func(ctx context.Context, file *filev1.File, content []byte) (string, error)
+
+// Summarize calls f(ctx, file, content).
+func (f SummarizerFunc) Summarize(ctx context.Context, file *filev1.File, content []byte) (string, error) {
+	return f(ctx, file, content)
+}
+
+// IsSummary reports whether the file is a summary sidecar, returning the ID of the
+// summarized file if so.
+func IsSummary(file *filev1.File) (string, bool) {
+	return PropertyText(file.GetProperties(), PropertySummaryOf)
+}
+
+// CreateSummary summarizes a file with the given content, and uploads the summary as
+// a sidecar file next to it. Searches which match the summary can be mapped back to
+// the original via IsSummary. The sidecar inherits the file's properties (other than
+// those set by the SDK), so that it matches the same filters.
+func (c *Client) CreateSummary(
+	ctx context.Context,
+	file *filev1.File,
+	content []byte,
+	s Summarizer,
+) (*filev1.File, error) {
+	summary, err := s.Summarize(ctx, file, content)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := new(filev1.Properties)
+	for key, v := range file.GetProperties().GetProperties() {
+		if !strings.HasPrefix(key, "operand_") {
+			properties = SetProperty(properties, key, v)
+		}
+	}
+	properties = SetProperty(properties, PropertySummaryOf, TextProperty(file.Id))
+
+	name := strings.TrimSuffix(file.Name, path.Ext(file.Name)) + SummaryExtension
+	resp, err := c.CreateFile(ctx, name, file.ParentId, strings.NewReader(summary), properties)
+	if err != nil {
+		return nil, err
+	}
+	return resp.File, nil
+}
+
+// Summarize downloads a file and creates a summary sidecar for it (see CreateSummary).
+func (c *Client) Summarize(ctx context.Context, file *filev1.File, s Summarizer) (*filev1.File, error) {
+	resp, err := c.download(ctx, file.DownloadUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return c.CreateSummary(ctx, file, content, s)
+}
+
+// SummaryBackfill creates summary sidecars for the existing files within a tree.
+type SummaryBackfill struct {
+	Client     *Client
+	Summarizer Summarizer
+	// MinSize is the size, in bytes, below which files aren't summarized. Summaries
+	// mostly help with long documents.
+	MinSize int64
+	// Concurrency is the number of files summarized concurrently. Defaults to 1.
+	Concurrency int
+	// OnFailure, if set, is called when a file can't be summarized. If it returns
+	// nil, the backfill continues. Otherwise (or if it isn't set), the backfill
+	// stops, and the error is returned.
+	OnFailure func(file *filev1.File, err error) error
+}
+
+// Run summarizes each file below the given folder (empty for the root) which doesn't
+// already have a summary, skipping folders, chunks, and summaries themselves. It
+// returns the number of summaries created.
+func (b *SummaryBackfill) Run(ctx context.Context, rootID string) (int, error) {
+	var (
+		files      []*filev1.File
+		summarized = make(map[string]bool)
+	)
+	err := b.Client.Walk(ctx, rootID, func(file *filev1.File, _ string) error {
+		if id, ok := IsSummary(file); ok {
+			summarized[id] = true
+			return nil
+		}
+		if _, ok := ChunkSpan(file); ok || IsFolder(file) || file.GetSizeBytes() < b.MinSize {
+			return nil
+		}
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		created  int
+		firstErr error
+		sem      = make(chan struct{}, concurrency)
+	)
+	for _, file := range files {
+		if summarized[file.Id] {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(file *filev1.File) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			_, err := b.Client.Summarize(ctx, file, b.Summarizer)
+			if err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+				return
+			}
+			err = fmt.Errorf("failed to summarize %s: %w", file.Id, err)
+			if b.OnFailure != nil {
+				err = b.OnFailure(file, err)
+			}
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}(file)
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return created, firstErr
+}
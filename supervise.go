@@ -0,0 +1,106 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Default backoff between restarts of a supervised goroutine.
+const (
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = time.Minute
+)
+
+// PanicError is the error reported when a panic is recovered.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Protect calls fn, turning a panic into a *PanicError.
+func Protect(fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// Supervisor runs background goroutines (e.g. watchers, schedulers, and consumers),
+// recovering from panics and restarting them with exponential backoff when they fail.
+// The zero value is ready to use.
+type Supervisor struct {
+	// Clock is used to wait between restarts. Defaults to SystemClock.
+	Clock Clock
+	// OnError, if set, is called with the name of the goroutine whenever it fails,
+	// including when it panics (see PanicError).
+	OnError func(name string, err error)
+	// MinBackoff and MaxBackoff bound the wait between restarts, which doubles after
+	// each consecutive failure. Default to DefaultMinBackoff and DefaultMaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	wg sync.WaitGroup
+}
+
+// Go runs fn in a new goroutine until it returns nil, or the context is cancelled.
+// If fn returns an error or panics, it's restarted after a backoff. The backoff is
+// reset once fn has run for longer than MaxBackoff without failing.
+func (s *Supervisor) Go(ctx context.Context, name string, fn func(ctx context.Context) error) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.supervise(ctx, name, fn)
+	}()
+}
+
+// Wait waits for all goroutines started with Go to stop.
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Supervisor) supervise(ctx context.Context, name string, fn func(ctx context.Context) error) {
+	clock := s.Clock
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	minBackoff, maxBackoff := s.MinBackoff, s.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+
+	backoff := minBackoff
+	for {
+		started := clock.Now()
+		err := Protect(func() error { return fn(ctx) })
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		if s.OnError != nil {
+			s.OnError(name, err)
+		}
+
+		if clock.Now().Sub(started) > maxBackoff {
+			backoff = minBackoff
+		}
+		select {
+		case <-clock.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
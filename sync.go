@@ -0,0 +1,137 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// PropertyContentHash holds the hash of the content of a file uploaded by Sync
+// (see UploadOptions.Hash), which is used to skip files which haven't changed.
+const PropertyContentHash = "operand_content_hash"
+
+// DefaultSyncLockTTL is the duration the lock of a Sync is held for, between
+// renewals, by default.
+const DefaultSyncLockTTL = time.Minute
+
+// Sync mirrors a local directory into a folder. Each run uploads new and changed
+// files, overwriting the previous versions, and leaves unchanged files in place.
+type Sync struct {
+	Client *Client
+	// Dir is the local directory which is mirrored.
+	Dir string
+	// FolderID is the folder which the directory is mirrored into. Empty for the root.
+	FolderID string
+	// Options configures the uploads. The collision policy is ignored, as files are
+	// always overwritten.
+	Options UploadOptions
+	// Delete deletes files within the folder which no longer exist locally. Note
+	// that this includes any files added to the folder by other means.
+	Delete bool
+	// Locker, if set, is held while syncing, so that when several replicas of a
+	// sync daemon run, only one of them makes changes at a time. Runs by the other
+	// replicas fail with ErrLeaseHeld.
+	Locker Locker
+	// LockTTL is the duration the lock is held for, between renewals.
+	// If zero, DefaultSyncLockTTL is used.
+	LockTTL time.Duration
+}
+
+// SyncReport is the result of a sync.
+type SyncReport struct {
+	Uploaded  int
+	Unchanged int
+	Deleted   int
+	// IDs maps the slash-separated paths of the synced files and folders, relative
+	// to the directory, to their file IDs.
+	IDs map[string]string
+}
+
+// Run syncs the directory once.
+func (s *Sync) Run(ctx context.Context) (*SyncReport, error) {
+	if s.Locker == nil {
+		return s.run(ctx)
+	}
+	ttl := s.LockTTL
+	if ttl <= 0 {
+		ttl = DefaultSyncLockTTL
+	}
+	var report *SyncReport
+	err := withLock(ctx, s.Client.clock, s.Locker, ttl, func(ctx context.Context) error {
+		var err error
+		report, err = s.run(ctx)
+		return err
+	})
+	return report, err
+}
+
+func (s *Sync) run(ctx context.Context) (*SyncReport, error) {
+	u := newDirUpload(s.Client, s.FolderID, s.Options)
+	u.sync = true
+	if err := u.run(ctx, s.Dir); err != nil {
+		return nil, err
+	}
+	report := &SyncReport{Uploaded: u.uploaded, Unchanged: u.unchanged, IDs: u.ids}
+	if !s.Delete {
+		return report, nil
+	}
+
+	synced := make(map[string]bool, len(u.ids))
+	for _, id := range u.ids {
+		synced[id] = true
+	}
+	var stale []*filev1.File
+	err := s.Client.Walk(ctx, s.FolderID, func(file *filev1.File, _ string) error {
+		if synced[file.Id] {
+			return nil
+		}
+		stale = append(stale, file)
+		if IsFolder(file) {
+			return SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	for _, file := range stale {
+		remove := s.Client.DeleteFile
+		if IsFolder(file) {
+			remove = s.Client.DeleteTree
+		}
+		if err := remove(ctx, file.Id); err != nil {
+			return report, fmt.Errorf("failed to delete %s: %w", file.Id, err)
+		}
+		report.Deleted++
+	}
+	return report, nil
+}
+
+// unchangedFile returns the file with the given name, if it has all of the given
+// properties. Content hashes are compared with SameContentHash.
+func unchangedFile(files []*filev1.File, name string, properties *filev1.Properties) *filev1.File {
+	for _, f := range files {
+		if f.Name != name || IsFolder(f) {
+			continue
+		}
+		existing := f.GetProperties().GetProperties()
+		unchanged := true
+		for key, v := range properties.GetProperties() {
+			same := proto.Equal(existing[key], v)
+			if key == PropertyContentHash {
+				same = SameContentHash(existing[key].GetText(), v.GetText())
+			}
+			if !same {
+				unchanged = false
+				break
+			}
+		}
+		if unchanged {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package operand
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// TemplateData is the data available to property templates.
+type TemplateData struct {
+	// Path is the slash-separated path of the file, relative to the root of the
+	// upload, or the name of the document for imports.
+	Path       string
+	Name       string
+	Ext        string // The extension of the name, including the dot.
+	Size       int64
+	ModTime    time.Time // Zero if unknown.
+	SourceURL  string    // Empty if unknown.
+	ExternalID string    // Empty if unknown.
+}
+
+// PropertyTemplates generate properties from text/template templates, e.g.
+//
+//	{"section": "{{.Path | dir}}", "year": "{{.ModTime.Year}}", "origin": "{{.SourceURL}}"}
+//
+// so that metadata conventions can be enforced across uploads and importers. In
+// addition to the standard functions, templates may use lower, upper, dir and
+// base. Properties whose templates render empty aren't set.
+type PropertyTemplates struct {
+	keys      []string
+	templates map[string]*template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"dir": func(p string) string {
+		if i := strings.LastIndex(p, "/"); i >= 0 {
+			return p[:i]
+		}
+		return ""
+	},
+	"base": func(p string) string {
+		return p[strings.LastIndex(p, "/")+1:]
+	},
+}
+
+// NewPropertyTemplates parses templates, keyed by the property they generate.
+func NewPropertyTemplates(templates map[string]string) (*PropertyTemplates, error) {
+	t := &PropertyTemplates{templates: make(map[string]*template.Template, len(templates))}
+	for key, text := range templates {
+		tmpl, err := template.New(key).Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template for property %q: %w", key, err)
+		}
+		t.keys = append(t.keys, key)
+		t.templates[key] = tmpl
+	}
+	sort.Strings(t.keys)
+	return t, nil
+}
+
+// Apply renders the templates, and sets the resulting properties on a copy of the
+// given properties. It's safe to call on nil PropertyTemplates, which return the
+// properties as they are.
+func (t *PropertyTemplates) Apply(data *TemplateData, properties *filev1.Properties) (*filev1.Properties, error) {
+	if t == nil || len(t.keys) == 0 {
+		return properties, nil
+	}
+	properties = cloneProperties(properties)
+	var b strings.Builder
+	for _, key := range t.keys {
+		b.Reset()
+		if err := t.templates[key].Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("failed to render property %q: %w", key, err)
+		}
+		if b.Len() > 0 {
+			properties = SetProperty(properties, key, TextProperty(b.String()))
+		}
+	}
+	return properties, nil
+}
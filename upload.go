@@ -0,0 +1,479 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// PropertySymlinkTarget holds the target of a symbolic link uploaded under
+// SymlinkRecord. DownloadDir recreates such files as links.
+const PropertySymlinkTarget = "operand_symlink_target"
+
+// DefaultUploadConcurrency is the number of files uploaded concurrently by
+// UploadDir and Sync, by default.
+const DefaultUploadConcurrency = 4
+
+// SymlinkPolicy decides how UploadDir and Sync handle symbolic links.
+type SymlinkPolicy int
+
+const (
+	// SymlinkSkip ignores symbolic links. This is the default.
+	SymlinkSkip SymlinkPolicy = iota
+	// SymlinkFollow uploads the target of each link as if it were at the link's
+	// location. Links to directories which have already been uploaded (including
+	// cycles) aren't uploaded again; the link's path maps to the existing folder.
+	SymlinkFollow
+	// SymlinkRecord uploads each link as an empty file, with its target recorded in
+	// PropertySymlinkTarget.
+	SymlinkRecord
+)
+
+// UploadOptions configures UploadDir.
+type UploadOptions struct {
+	// Collision is applied to each file and folder uploaded. Under CollisionSkip and
+	// CollisionOverwrite, existing folders are merged into, so that a directory can
+	// be uploaded again to pick up new files.
+	Collision CollisionPolicy
+	// Sanitizer, if set, sanitizes the names of files and folders. Collision
+	// policies apply to the sanitized names.
+	Sanitizer *NameSanitizer
+	// Attributes captures the modification time, mode and extended attributes of
+	// files into properties (see PropertyModTime), so that DownloadDir can restore
+	// them.
+	Attributes bool
+	// Symlinks decides how symbolic links are handled.
+	Symlinks SymlinkPolicy
+	// DedupLinks uploads files with several paths, i.e. hard links (or, when
+	// following symbolic links, link targets), only once. All of their paths map to
+	// the same file ID. It's only supported on Unix.
+	DedupLinks bool
+	// DirConfig applies the configuration files (see DirConfigName) found in the
+	// directory and its subdirectories.
+	DirConfig bool
+	// Templates, if set, generate properties for each file and folder.
+	Templates *PropertyTemplates
+	// Include, if set, restricts the upload to files matching at least one of the
+	// patterns. Exclude skips files and directories matching any of the patterns.
+	// Patterns use the syntax of path.Match. Patterns without a slash match base
+	// names; others match slash-separated paths relative to the directory. All
+	// directories which aren't excluded are created, even if no files within them
+	// are included.
+	Include, Exclude []string
+	// Hash is the algorithm of the content hashes recorded by Sync. Defaults to
+	// SHA256.
+	Hash HashAlgorithm
+	// Concurrency is the number of files uploaded concurrently. Folders are
+	// created one at a time, before their contents. If zero,
+	// DefaultUploadConcurrency is used.
+	Concurrency int
+}
+
+// matchesAny reports whether the slash-separated relative path matches any of the
+// patterns (see UploadOptions.Include).
+func matchesAny(patterns []string, rel string) (bool, error) {
+	for _, pattern := range patterns {
+		subject := rel
+		if !strings.Contains(pattern, "/") {
+			subject = path.Base(rel)
+		}
+		matched, err := path.Match(pattern, subject)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UploadDir uploads a local directory into the given folder (empty for the root),
+// creating a folder for each subdirectory, and uploading files concurrently. It
+// returns a mapping from the slash-separated paths of the uploaded files and
+// folders, relative to dir, to their file IDs. If an upload fails, no further
+// uploads are started, and the mapping of what was uploaded so far is returned
+// along with the error.
+func (c *Client) UploadDir(
+	ctx context.Context,
+	dir string,
+	parentID string,
+	opts UploadOptions,
+) (map[string]string, error) {
+	u := newDirUpload(c, parentID, opts)
+	err := u.run(ctx, dir)
+	return u.ids, err
+}
+
+// dirUpload is the state of an UploadDir or a Sync.
+type dirUpload struct {
+	c        *Client
+	opts     UploadOptions
+	rootID   string
+	ids      map[string]string         // Relative path -> file ID.
+	siblings map[string][]*filev1.File // Folder ID -> files, if listed.
+	files    map[fileKey]string        // Uploaded files -> relative path, if DedupLinks.
+	dirs     map[fileKey]string        // Visited directories -> relative path.
+	refs     [][2]string               // Relative paths -> the relative paths they reference.
+
+	// If sync is set, files whose content and attributes match the existing file
+	// with the same name are left in place, and counted as unchanged.
+	sync                bool
+	uploaded, unchanged int
+
+	// Files are uploaded by goroutines; mu guards ids, siblings, the counts and err.
+	mu  sync.Mutex
+	wg  sync.WaitGroup
+	sem chan struct{}
+	err error // The first failure of a file upload.
+}
+
+func newDirUpload(c *Client, parentID string, opts UploadOptions) *dirUpload {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultUploadConcurrency
+	}
+	return &dirUpload{
+		sem:      make(chan struct{}, concurrency),
+		c:        c,
+		opts:     opts,
+		rootID:   parentID,
+		ids:      make(map[string]string),
+		siblings: make(map[string][]*filev1.File),
+		files:    make(map[fileKey]string),
+		dirs:     make(map[fileKey]string),
+	}
+}
+
+// run uploads the directory.
+func (u *dirUpload) run(ctx context.Context, dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if key, ok := keyOf(info); ok {
+		u.dirs[key] = "."
+	}
+	err = u.walk(ctx, dir, ".", &dirSettings{})
+	u.wg.Wait()
+	if err == nil {
+		err = u.err
+	}
+	// Resolve references once everything they may refer to has been uploaded.
+	for _, ref := range u.refs {
+		if id, ok := u.ids[ref[1]]; ok {
+			u.ids[ref[0]] = id
+		} else if ref[1] == "." && u.rootID != "" {
+			u.ids[ref[0]] = u.rootID
+		}
+	}
+	return err
+}
+
+// spawn uploads a file in the background, unless an upload has already failed.
+func (u *dirUpload) spawn(ctx context.Context, upload func() error) error {
+	select {
+	case u.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	u.mu.Lock()
+	err := u.err
+	u.mu.Unlock()
+	if err != nil {
+		<-u.sem
+		return err
+	}
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+		defer func() { <-u.sem }()
+		if err := upload(); err != nil {
+			u.mu.Lock()
+			if u.err == nil {
+				u.err = err
+			}
+			u.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// walk uploads the contents of a directory, in lexical order.
+func (u *dirUpload) walk(ctx context.Context, dir, relDir string, settings *dirSettings) error {
+	if u.opts.DirConfig {
+		config, err := ReadDirConfig(dir)
+		if err != nil {
+			return fmt.Errorf("%s: %w", relDir, err)
+		}
+		settings = settings.with(relDir, config)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, d := range entries {
+		if u.opts.DirConfig && d.Name() == DirConfigName {
+			continue
+		}
+		p, rel := filepath.Join(dir, d.Name()), path.Join(relDir, d.Name())
+		if err := u.entry(ctx, p, rel, d, settings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entry uploads a single directory entry, and the contents of directories.
+func (u *dirUpload) entry(ctx context.Context, p, rel string, d fs.DirEntry, settings *dirSettings) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	if settings.ignored(rel, d.IsDir()) {
+		return nil
+	}
+	if excluded, err := matchesAny(u.opts.Exclude, rel); err != nil || excluded {
+		return err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		switch u.opts.Symlinks {
+		case SymlinkSkip:
+			return nil
+		case SymlinkRecord:
+			if included, err := u.included(rel); err != nil || !included {
+				return err
+			}
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return u.spawn(ctx, func() error { return u.upload(ctx, p, rel, false, target, settings) })
+		case SymlinkFollow:
+			if info, err = os.Stat(p); err != nil {
+				return fmt.Errorf("failed to follow %s: %w", rel, err)
+			}
+		}
+	}
+
+	key, hasKey := keyOf(info)
+	switch {
+	case info.IsDir():
+		if visited, ok := u.dirs[key]; hasKey && ok {
+			u.reference(rel, visited)
+			return nil
+		}
+		if hasKey {
+			u.dirs[key] = rel
+		}
+		if err := u.upload(ctx, p, rel, true, "", settings); err != nil {
+			return err
+		}
+		return u.walk(ctx, p, rel, settings)
+	case info.Mode().IsRegular():
+		if included, err := u.included(rel); err != nil || !included {
+			return err
+		}
+		if u.opts.DedupLinks && hasKey {
+			if uploaded, ok := u.files[key]; ok {
+				u.reference(rel, uploaded)
+				return nil
+			}
+			u.files[key] = rel
+		}
+		return u.spawn(ctx, func() error { return u.upload(ctx, p, rel, false, "", settings) })
+	default:
+		return nil // Skip devices, sockets, etc.
+	}
+}
+
+// included reports whether a file passes the include patterns, if any.
+func (u *dirUpload) included(rel string) (bool, error) {
+	if len(u.opts.Include) == 0 {
+		return true, nil
+	}
+	return matchesAny(u.opts.Include, rel)
+}
+
+// reference maps a path to the file uploaded from another path. As the upload may
+// still be in flight, it's resolved at the end of the run.
+func (u *dirUpload) reference(rel, uploaded string) {
+	u.refs = append(u.refs, [2]string{rel, uploaded})
+}
+
+// upload uploads a single file or directory, or records a symbolic link if target
+// is set.
+func (u *dirUpload) upload(
+	ctx context.Context,
+	p, rel string,
+	isDir bool,
+	target string,
+	settings *dirSettings,
+) error {
+	parentID := u.rootID
+	if dir := path.Dir(rel); dir != "." {
+		u.mu.Lock()
+		parentID = u.ids[dir]
+		u.mu.Unlock()
+	}
+	var parent *string
+	if parentID != "" {
+		parent = &parentID
+	}
+
+	name := path.Base(rel)
+	var properties *filev1.Properties
+	if u.opts.Sanitizer != nil {
+		name, properties = u.opts.Sanitizer.Apply(name, nil)
+	}
+	properties = settings.properties(properties)
+	if u.opts.Templates != nil {
+		var err error
+		if properties, err = u.templateProperties(p, rel, target != "", properties); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", rel, err)
+		}
+	}
+	if target != "" {
+		properties = SetProperty(cloneProperties(properties), PropertySymlinkTarget, TextProperty(target))
+	} else if u.opts.Attributes && !isDir {
+		var err error
+		if properties, err = captureAttributes(p, properties); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", rel, err)
+		}
+	}
+
+	policy := u.opts.Collision
+	if settings.collision != nil {
+		policy = *settings.collision
+	}
+	if u.sync {
+		policy = CollisionOverwrite
+	}
+	siblings, err := u.listed(ctx, parentID, policy)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", rel, err)
+	}
+	if u.sync {
+		if !isDir {
+			if target == "" {
+				hash, err := u.opts.Hash.sumFile(p)
+				if err != nil {
+					return fmt.Errorf("failed to upload %s: %w", rel, err)
+				}
+				properties = SetProperty(cloneProperties(properties), PropertyContentHash, TextProperty(hash))
+			}
+			if existing := unchangedFile(siblings, name, properties); existing != nil {
+				u.mu.Lock()
+				u.ids[rel] = existing.Id
+				u.unchanged++
+				u.mu.Unlock()
+				return nil
+			}
+		}
+	}
+
+	var file *filev1.File
+	err = func() error {
+		var data io.Reader
+		switch {
+		case target != "":
+			data = strings.NewReader("")
+		case !isDir:
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			data = f
+		}
+		resp, err := u.c.createWithPolicy(ctx, policy, name, parent, data, properties, siblings)
+		if resp != nil {
+			file = resp.File
+		}
+		return err
+	}()
+	if file != nil {
+		u.mu.Lock()
+		u.ids[rel] = file.Id
+		if isDir && !containsFile(siblings, file) {
+			u.siblings[file.Id] = []*filev1.File{} // Newly created, so empty.
+		}
+		if !isDir {
+			u.uploaded++
+		}
+		if listed, ok := u.siblings[parentID]; ok {
+			u.siblings[parentID] = append(listed, file)
+		}
+		u.mu.Unlock()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", rel, err)
+	}
+	return nil
+}
+
+// templateProperties applies the templates to the file at the given path.
+func (u *dirUpload) templateProperties(
+	p, rel string,
+	link bool,
+	properties *filev1.Properties,
+) (*filev1.Properties, error) {
+	stat := os.Stat
+	if link {
+		stat = os.Lstat
+	}
+	info, err := stat(p)
+	if err != nil {
+		return nil, err
+	}
+	data := &TemplateData{
+		Path:    rel,
+		Name:    path.Base(rel),
+		Ext:     path.Ext(rel),
+		ModTime: info.ModTime(),
+	}
+	if !info.IsDir() {
+		data.Size = info.Size()
+	}
+	return u.opts.Templates.Apply(data, properties)
+}
+
+// listed returns the files in a folder, listing it the first time it's needed by
+// an upload under the given collision policy. Folders needn't be listed to upload
+// under CollisionAllow.
+func (u *dirUpload) listed(ctx context.Context, parentID string, policy CollisionPolicy) ([]*filev1.File, error) {
+	u.mu.Lock()
+	siblings, ok := u.siblings[parentID]
+	u.mu.Unlock()
+	if ok || policy == CollisionAllow {
+		return siblings, nil
+	}
+	siblings, err := u.c.ListFolder(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if listed, ok := u.siblings[parentID]; ok {
+		return listed, nil // Listed concurrently.
+	}
+	u.siblings[parentID] = siblings
+	return siblings, nil
+}
+
+func containsFile(files []*filev1.File, file *filev1.File) bool {
+	for _, f := range files {
+		if f.Id == file.Id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package operand_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	operand "github.com/operandinc/go-sdk"
+	"github.com/operandinc/go-sdk/operandtest"
+)
+
+func TestUploadDirAppliesDirectoryCollisionPolicy(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	folder := createFile(t, client, "folder", "", "", nil)
+	sub := createFile(t, client, "sub", folder.Id, "", nil)
+	existing := createFile(t, client, "a.txt", sub.Id, "existing", nil)
+
+	// The client-wide policy allows duplicates, but the directory's configuration
+	// skips them.
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		operand.DirConfigName: "collision: skip\n",
+		"a.txt":               "new",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := client.UploadDir(context.Background(), dir, sub.Id, operand.UploadOptions{
+		Collision: operand.CollisionAllow,
+		DirConfig: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids["a.txt"] != existing.Id {
+		t.Errorf("a.txt maps to %s, want the existing file %s", ids["a.txt"], existing.Id)
+	}
+	files, err := client.ListFolder(context.Background(), sub.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("sub has %d files, want 1", len(files))
+	}
+	if content, _ := srv.Content(existing.Id); string(content) != "existing" {
+		t.Errorf("a.txt was replaced: %q", content)
+	}
+}
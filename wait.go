@@ -0,0 +1,65 @@
+package operand
+
+import (
+	"context"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// Defaults of WaitOptions.
+const (
+	DefaultWaitInterval    = 500 * time.Millisecond
+	DefaultWaitMaxInterval = 10 * time.Second
+)
+
+// WaitOptions configures the polling of WaitForFileIndexed.
+type WaitOptions struct {
+	// Interval is the delay before polling again, which doubles after each poll, up
+	// to MaxInterval. Default to DefaultWaitInterval and DefaultWaitMaxInterval.
+	Interval    time.Duration
+	MaxInterval time.Duration
+}
+
+// WaitForFileIndexed waits for the indexing of a file to finish, polling its status
+// with exponential backoff, and returns its terminal status: ready, failed or
+// unsupported. Unlike TrackIndexing, a file which can't be indexed isn't an error;
+// an error is only returned if polling fails, or the context is done first. The
+// options may be nil, for the defaults.
+func (c *Client) WaitForFileIndexed(ctx context.Context, fileID string, opts *WaitOptions) (filev1.IndexingStatus, error) {
+	interval, maxInterval := DefaultWaitInterval, DefaultWaitMaxInterval
+	if opts != nil && opts.Interval > 0 {
+		interval = opts.Interval
+	}
+	if opts != nil && opts.MaxInterval > 0 {
+		maxInterval = opts.MaxInterval
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+
+	ctx = c.withOperation(ctx)
+	for {
+		resp, err := c.FileService().GetFile(ctx, connect.NewRequest(&filev1.GetFileRequest{
+			Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: fileID}},
+		}))
+		if err != nil {
+			return filev1.IndexingStatus_INDEXING_STATUS_UNSPECIFIED, err
+		}
+		switch status := resp.Msg.File.IndexingStatus; status {
+		case filev1.IndexingStatus_INDEXING_STATUS_READY,
+			filev1.IndexingStatus_INDEXING_STATUS_FAILED,
+			filev1.IndexingStatus_INDEXING_STATUS_UNSUPPORTED:
+			return status, nil
+		}
+		select {
+		case <-c.clock.After(interval):
+		case <-ctx.Done():
+			return filev1.IndexingStatus_INDEXING_STATUS_UNSPECIFIED, ctx.Err()
+		}
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
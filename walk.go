@@ -0,0 +1,157 @@
+package operand
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+)
+
+// SkipDir may be returned by a WalkFunc to skip the contents of a folder.
+var SkipDir = errors.New("skip this directory")
+
+// WalkFunc is called by Walk for each file. The path is the slash-separated path of
+// the file relative to the root of the walk.
+type WalkFunc func(file *filev1.File, path string) error
+
+// IsFolder reports whether the file is a folder. Folders have no size.
+func IsFolder(file *filev1.File) bool {
+	return file.SizeBytes == nil
+}
+
+// Walk walks the tree of files below the given folder (empty for the root) depth
+// first, calling fn for each file, including folders (before their contents). If fn
+// returns SkipDir for a folder, its contents are skipped. Any other error stops the
+// walk, and is returned.
+//
+// Walks of the whole tenant list every file in a single paginated listing, rather
+// than listing each folder in turn, so that they take a number of calls
+// proportional to the number of files rather than the number of folders. The
+// listing is streamed, so such walks visit each folder before its contents, but
+// not necessarily depth first. Files whose folder isn't listed, e.g. files shared
+// from folders the caller can't see, are visited last, as if they were at the top
+// level of the tree, so their paths are only relative to their unlisted folder.
+func (c *Client) Walk(ctx context.Context, rootID string, fn WalkFunc) error {
+	var err error
+	if rootID == "" {
+		err = c.walkAll(ctx, fn)
+	} else {
+		err = c.walk(ctx, rootID, "", fn)
+	}
+	if errors.Is(err, SkipDir) {
+		return nil
+	}
+	return err
+}
+
+// walkAll walks the whole tenant, from a flat listing of all files, streamed a
+// page at a time. Files are visited as soon as their folder has been, so only
+// the files listed before their folder are held until it's listed, along with
+// the paths of the folders visited so far.
+func (c *Client) walkAll(ctx context.Context, fn WalkFunc) error {
+	dirs := map[string]string{"": ""} // The paths of the visited folders, by ID.
+	skipped := make(map[string]bool)  // Folders whose contents are skipped.
+	pending := make(map[string][]*filev1.File)
+	var pendingOrder []string // The keys of pending, in the order they're listed.
+	// skip drops the contents of a skipped folder, including those held so far.
+	var skip func(file *filev1.File)
+	skip = func(file *filev1.File) {
+		if !IsFolder(file) {
+			return
+		}
+		skipped[file.Id] = true
+		children := pending[file.Id]
+		delete(pending, file.Id)
+		for _, child := range children {
+			skip(child)
+		}
+	}
+
+	var visit func(file *filev1.File, dir string) error
+	visit = func(file *filev1.File, dir string) error {
+		p := path.Join(dir, file.Name)
+		err := fn(file, p)
+		if errors.Is(err, SkipDir) {
+			skip(file)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !IsFolder(file) {
+			return nil
+		}
+		dirs[file.Id] = p
+		children := pending[file.Id]
+		delete(pending, file.Id)
+		for _, child := range children {
+			if err := visit(child, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	it := c.IterFiles(ctx, &filev1.ListFilesRequest{Filter: &filev1.FileFilter{}})
+	for it.Next() {
+		file := it.File()
+		parentID := file.GetParentId()
+		if dir, ok := dirs[parentID]; ok {
+			if err := visit(file, dir); err != nil {
+				return err
+			}
+		} else if skipped[parentID] {
+			skip(file)
+		} else {
+			if _, ok := pending[parentID]; !ok {
+				pendingOrder = append(pendingOrder, parentID)
+			}
+			pending[parentID] = append(pending[parentID], file)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	// The files still held are within folders which weren't listed, or within
+	// such files.
+	held := make(map[string]bool)
+	for _, files := range pending {
+		for _, file := range files {
+			held[file.Id] = true
+		}
+	}
+	for _, parentID := range pendingOrder {
+		if held[parentID] {
+			continue // Visited along with its own folder.
+		}
+		for _, file := range pending[parentID] {
+			if err := visit(file, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) walk(ctx context.Context, parentID, dir string, fn WalkFunc) error {
+	files, err := c.ListFolder(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		p := path.Join(dir, file.Name)
+		err := fn(file, p)
+		if errors.Is(err, SkipDir) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if IsFolder(file) {
+			if err := c.walk(ctx, file.Id, p, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
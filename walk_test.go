@@ -0,0 +1,121 @@
+package operand_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	operand "github.com/operandinc/go-sdk"
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"github.com/operandinc/go-sdk/file/v1/filev1connect"
+	"github.com/operandinc/go-sdk/operandtest"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWalkAllStreamsPages(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+	var listings atomic.Int32
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/ListFiles") {
+			listings.Add(1)
+		}
+		srv.Handler().ServeHTTP(w, r)
+	}))
+	defer gateway.Close()
+	client := operand.NewClient(operandtest.DefaultAPIKey, operand.WithEndpoint(gateway.URL))
+	for i := 0; i < 2*operandtest.DefaultPageSize+1; i++ {
+		createFile(t, client, fmt.Sprint(i, ".txt"), "", "-", nil)
+	}
+
+	stop := errors.New("stop")
+	err := client.Walk(context.Background(), "", func(*filev1.File, string) error {
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("got %v, want %v", err, stop)
+	}
+	if got := listings.Load(); got != 1 {
+		t.Errorf("listed %d pages before the first file was visited, want 1", got)
+	}
+}
+
+func TestWalkAllVisitsFoldersBeforeTheirContents(t *testing.T) {
+	srv := operandtest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+	// The files are listed before the folders they're moved into.
+	a := createFile(t, client, "a.txt", "", "-", nil)
+	skipped := createFile(t, client, "skipped.txt", "", "-", nil)
+	folder := createFile(t, client, "folder", "", "", nil)
+	skip := createFile(t, client, "skip", "", "", nil)
+	for _, move := range []struct{ file, parent *filev1.File }{{a, folder}, {skipped, skip}, {skip, folder}} {
+		_, err := client.FileService().UpdateFile(context.Background(), connect.NewRequest(&filev1.UpdateFileRequest{
+			Selector: &filev1.FileSelector{Selector: &filev1.FileSelector_Id{Id: move.file.Id}},
+			ParentId: &move.parent.Id,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var paths []string
+	err := client.Walk(context.Background(), "", func(file *filev1.File, p string) error {
+		paths = append(paths, p)
+		if p == "folder/skip" {
+			return operand.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"folder", "folder/a.txt", "folder/skip"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("visited %q, want %q", paths, want)
+	}
+}
+
+// listService is a fake File Service, which lists a fixed set of files.
+type listService struct {
+	filev1connect.UnimplementedFileServiceHandler
+	files []*filev1.File
+}
+
+func (s *listService) ListFiles(context.Context, *connect.Request[filev1.ListFilesRequest]) (*connect.Response[filev1.ListFilesResponse], error) {
+	return connect.NewResponse(&filev1.ListFilesResponse{Files: s.files, Pagination: &filev1.PaginationResponse{}}), nil
+}
+
+func TestWalkAllVisitsFilesOfUnlistedFolders(t *testing.T) {
+	size := proto.Int64(1)
+	svc := &listService{files: []*filev1.File{
+		{Id: "a", Name: "a.txt", SizeBytes: size},
+		{Id: "shared", Name: "shared.txt", ParentId: proto.String("hidden"), SizeBytes: size},
+		{Id: "sub", Name: "sub", ParentId: proto.String("hidden")},
+		{Id: "b", Name: "b.txt", ParentId: proto.String("sub"), SizeBytes: size},
+	}}
+	mux := http.NewServeMux()
+	mux.Handle(filev1connect.NewFileServiceHandler(svc))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	client := operand.NewClient("key", operand.WithEndpoint(srv.URL))
+
+	var paths []string
+	err := client.Walk(context.Background(), "", func(_ *filev1.File, p string) error {
+		paths = append(paths, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.txt", "shared.txt", "sub", "sub/b.txt"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("visited %q, want %q", paths, want)
+	}
+}
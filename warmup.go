@@ -0,0 +1,116 @@
+package operand
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultWarmupConcurrency is the number of queries run concurrently by Warmup by
+// default.
+const DefaultWarmupConcurrency = 4
+
+// Warmup primes server-side (and, e.g. with a caching reranker, client-side) caches
+// by replaying common queries, e.g. after a large reindex, a tenant clone, or a
+// deployment, so that the first real users don't pay for cold caches.
+type Warmup struct {
+	Client *Client
+	// Queries are the queries to replay, most important first.
+	Queries []string
+	// Options are applied to every search, e.g. WithParent to scope the warm-up.
+	Options []SearchOption
+	// Concurrency is the number of queries run concurrently.
+	// If zero, DefaultWarmupConcurrency is used.
+	Concurrency int
+	// MaxQueries is the maximum number of queries replayed. Zero means no limit.
+	MaxQueries int
+	// Budget is the maximum duration of the warm-up. Once it has elapsed, no further
+	// queries are started. Zero means no limit.
+	Budget time.Duration
+}
+
+// WarmupReport is the result of a warm-up.
+type WarmupReport struct {
+	Queries int // The number of queries run.
+	Failed  int // The number of queries which failed.
+	// Skipped is the number of queries not run due to MaxQueries or Budget.
+	Skipped int
+	Elapsed time.Duration
+	// Latency percentiles of the queries run, which show how cold the caches were.
+	P50, P95, Max time.Duration
+}
+
+// Run replays the queries. Failed queries don't stop the warm-up, as it's best
+// effort; an error is only returned if the context is cancelled.
+func (w *Warmup) Run(ctx context.Context) (*WarmupReport, error) {
+	clock := w.Client.clock
+	start := clock.Now()
+	queries := w.Queries
+	if w.MaxQueries > 0 && len(queries) > w.MaxQueries {
+		queries = queries[:w.MaxQueries]
+	}
+	concurrency := w.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultWarmupConcurrency
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		latencies []time.Duration
+		failed    int
+		sem       = make(chan struct{}, concurrency)
+	)
+	for _, query := range queries {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil || (w.Budget > 0 && clock.Now().Sub(start) >= w.Budget) {
+			break
+		}
+		wg.Add(1)
+		go func(query string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			began := clock.Now()
+			_, err := w.Client.Search(ctx, query, w.Options...)
+			latency := clock.Now().Sub(began)
+
+			mu.Lock()
+			defer mu.Unlock()
+			latencies = append(latencies, latency)
+			if err != nil {
+				failed++
+			}
+		}(query)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &WarmupReport{
+		Queries: len(latencies),
+		Failed:  failed,
+		Skipped: len(w.Queries) - len(latencies),
+		Elapsed: clock.Now().Sub(start),
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		report.P50 = percentile(latencies, 50)
+		report.P95 = percentile(latencies, 95)
+		report.Max = latencies[len(latencies)-1]
+	}
+	return report, nil
+}
+
+// percentile returns the pth percentile of sorted, using the nearest-rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
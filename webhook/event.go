@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	tenantv1 "github.com/operandinc/go-sdk/tenant/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The types of events.
+const (
+	// EventFileIndexed is sent when a file has been indexed, and can be searched.
+	EventFileIndexed = "file.indexed"
+	// EventFileIndexingFailed is sent when a file couldn't be indexed, e.g.
+	// because its format isn't supported.
+	EventFileIndexingFailed = "file.indexing_failed"
+	// EventFileDeleted is sent when a file has been deleted.
+	EventFileDeleted = "file.deleted"
+	// EventTenantUpdated is sent when the user of the tenant has been updated.
+	EventTenantUpdated = "tenant.updated"
+)
+
+// Event is a webhook event.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	// Data is the payload of the event, which depends on its type. It's decoded by
+	// ParseEvent into Payload.
+	Data json.RawMessage `json:"data"`
+	// Payload is the typed payload of the event: *FileEvent for the file events
+	// (e.g. EventFileIndexed), *TenantEvent for EventTenantUpdated, or nil for
+	// types unknown to the SDK, whose payloads are left in Data.
+	Payload any `json:"-"`
+}
+
+// FileEvent is the payload of events about a file.
+type FileEvent struct {
+	// File is the file, as of the event.
+	File *filev1.File
+}
+
+// TenantEvent is the payload of events about the tenant.
+type TenantEvent struct {
+	// User is the user of the tenant, as of the event.
+	User *tenantv1.User
+}
+
+// ParseEvent parses the payload of a webhook, once verified (see Verifier), into
+// an event with its typed payload.
+func ParseEvent(payload []byte) (*Event, error) {
+	event := new(Event)
+	if err := json.Unmarshal(payload, event); err != nil {
+		return nil, fmt.Errorf("webhook: parsing event: %w", err)
+	}
+	var err error
+	switch event.Type {
+	case EventFileIndexed, EventFileIndexingFailed, EventFileDeleted:
+		file := new(filev1.File)
+		err = parseData(event.Data, "file", file)
+		event.Payload = &FileEvent{File: file}
+	case EventTenantUpdated:
+		user := new(tenantv1.User)
+		err = parseData(event.Data, "user", user)
+		event.Payload = &TenantEvent{User: user}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("webhook: parsing %s event: %w", event.Type, err)
+	}
+	return event, nil
+}
+
+// parseData parses the message in the given field of the data of an event.
+func parseData(data json.RawMessage, field string, m proto.Message) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	value, ok := fields[field]
+	if !ok {
+		return fmt.Errorf("missing %s", field)
+	}
+	// Fields added to messages since the SDK was generated are dropped.
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(value, m)
+}
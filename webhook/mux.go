@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// HandlerFunc handles a verified webhook event. Failing makes the webhook fail,
+// so that Operand sends it again later.
+type HandlerFunc func(ctx context.Context, event *Event) error
+
+// Mux is an http.Handler which verifies webhooks, and dispatches their events to
+// the handlers registered for their types:
+//
+//	mux := webhook.NewMux(webhook.Verifier{Secret: secret})
+//	mux.Handle(webhook.EventFileIndexed, func(ctx context.Context, event *webhook.Event) error {
+//		file := event.Payload.(*webhook.FileEvent).File
+//		...
+//	})
+//	http.Handle("/webhooks/operand", mux)
+//
+// Webhooks which fail verification are rejected with 400 Bad Request. Events
+// without a handler are acknowledged, and dropped, unless a default handler is
+// set (see HandleDefault). Events whose handler fails get 500 Internal Server
+// Error, so that they're sent again.
+type Mux struct {
+	v Verifier
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+	fallback HandlerFunc
+}
+
+var _ http.Handler = (*Mux)(nil)
+
+// NewMux returns a mux which verifies webhooks with the given verifier.
+func NewMux(v Verifier) *Mux {
+	return &Mux{v: v, handlers: make(map[string]HandlerFunc)}
+}
+
+// Handle registers the handler of events of the given type (e.g.
+// EventFileIndexed), replacing any registered before.
+func (m *Mux) Handle(eventType string, h HandlerFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[eventType] = h
+}
+
+// HandleDefault registers the handler of events of types without a handler, e.g.
+// types unknown to the SDK.
+func (m *Mux) HandleDefault(h HandlerFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallback = h
+}
+
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	payload, err := m.v.VerifyRequest(r)
+	if errors.Is(err, ErrNoSecret) {
+		http.Error(w, "webhook secret not configured", http.StatusInternalServerError)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event, err := ParseEvent(payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.mu.RLock()
+	h, ok := m.handlers[event.Type]
+	if !ok {
+		h = m.fallback
+	}
+	m.mu.RUnlock()
+	if h != nil {
+		if err := h(r.Context(), event); err != nil {
+			// The error isn't sent back, as it may reveal internals of the service.
+			http.Error(w, "handling event failed", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,182 @@
+// Package webhook verifies and parses the webhooks sent by Operand, e.g. when files
+// finish indexing.
+//
+// Each webhook is a POST request with a JSON event as its body (see Event), signed
+// with the secret of the endpoint it's sent to. The signature is in the
+// Operand-Signature header, of the form
+//
+//	t=1700000000,v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+//
+// where t is the Unix time the webhook was sent at, and v1 is the hex-encoded
+// HMAC-SHA256 of the time, a period, and the body, keyed by the secret. Several
+// v1 signatures are sent while a secret is being rotated.
+//
+//	v := webhook.Verifier{Secret: secret}
+//	http.HandleFunc("/webhooks/operand", func(w http.ResponseWriter, r *http.Request) {
+//		payload, err := v.VerifyRequest(r)
+//		if err != nil {
+//			http.Error(w, err.Error(), http.StatusBadRequest)
+//			return
+//		}
+//		event, err := webhook.ParseEvent(payload)
+//		...
+//	})
+//
+// Mux does the above, and dispatches events to handlers by type.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the header holding the signature of webhooks.
+const SignatureHeader = "Operand-Signature"
+
+// DefaultTolerance is the maximum age of webhooks accepted by a Verifier, by
+// default.
+const DefaultTolerance = 5 * time.Minute
+
+// DefaultMaxPayload is the maximum size of the payloads read by VerifyRequest, by
+// default.
+const DefaultMaxPayload = 1 << 20
+
+var (
+	// ErrMissingSignature is returned when a webhook isn't signed.
+	ErrMissingSignature = errors.New("webhook: missing signature")
+	// ErrInvalidSignature is returned when no signature of a webhook matches its
+	// payload, e.g. because it wasn't sent by Operand, or was tampered with.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	// ErrExpired is returned when a webhook was sent outside of the tolerance, e.g.
+	// because it's being replayed.
+	ErrExpired = errors.New("webhook: timestamp outside of tolerance")
+	// ErrNoSecret is returned when a Verifier has no secret, with which any
+	// signature could be forged.
+	ErrNoSecret = errors.New("webhook: no secret")
+)
+
+// Verifier verifies the signatures of webhooks.
+type Verifier struct {
+	// Secret is the signing secret of the webhook endpoint. It's required.
+	Secret []byte
+	// PreviousSecrets are also accepted, e.g. while the secret is being rotated.
+	// Empty secrets are ignored.
+	PreviousSecrets [][]byte
+	// Tolerance is the maximum difference between the time a webhook was sent at
+	// and the current time. Defaults to DefaultTolerance. Negative disables the
+	// check, which leaves webhooks open to replays.
+	Tolerance time.Duration
+	// MaxPayload is the maximum size of the payloads read by VerifyRequest.
+	// Defaults to DefaultMaxPayload.
+	MaxPayload int64
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Verify verifies the signature of a webhook, given the value of its
+// SignatureHeader and its payload. Signatures are compared in constant time.
+func (v Verifier) Verify(header string, payload []byte) error {
+	if len(v.Secret) == 0 {
+		return ErrNoSecret
+	}
+	if header == "" {
+		return ErrMissingSignature
+	}
+	var (
+		timestamp  string
+		signatures [][]byte
+	)
+	for _, field := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp %q", ErrInvalidSignature, timestamp)
+	}
+	if len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+
+	valid := false
+	for _, secret := range append([][]byte{v.Secret}, v.PreviousSecrets...) {
+		if len(secret) == 0 {
+			continue
+		}
+		expected := sign(secret, timestamp, payload)
+		for _, sig := range signatures {
+			if hmac.Equal(sig, expected) {
+				valid = true
+			}
+		}
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	tolerance := v.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+	if tolerance > 0 {
+		now := time.Now
+		if v.Now != nil {
+			now = v.Now
+		}
+		if age := now().Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+			return ErrExpired
+		}
+	}
+	return nil
+}
+
+// VerifyRequest reads the payload of a webhook request, and verifies its
+// signature. Payloads larger than MaxPayload are rejected.
+func (v Verifier) VerifyRequest(r *http.Request) ([]byte, error) {
+	maxPayload := v.MaxPayload
+	if maxPayload <= 0 {
+		maxPayload = DefaultMaxPayload
+	}
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxPayload+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(payload)) > maxPayload {
+		return nil, fmt.Errorf("webhook: payload larger than %d bytes", maxPayload)
+	}
+	if err := v.Verify(r.Header.Get(SignatureHeader), payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// Sign returns the value of the SignatureHeader of a webhook sent at the given
+// time, e.g. to test handlers of webhooks.
+func Sign(secret []byte, t time.Time, payload []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(sign(secret, timestamp, payload))
+}
+
+// sign returns the HMAC-SHA256 of the timestamp and payload.
+func sign(secret []byte, timestamp string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,30 @@
+package webhook_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/operandinc/go-sdk/webhook"
+)
+
+func TestVerifyWithoutSecretFails(t *testing.T) {
+	payload := []byte(`{"type":"file.indexed"}`)
+	header := webhook.Sign(nil, time.Now(), payload)
+	for _, v := range []webhook.Verifier{{}, {Secret: []byte{}}} {
+		if err := v.Verify(header, payload); !errors.Is(err, webhook.ErrNoSecret) {
+			t.Errorf("got %v, want %v", err, webhook.ErrNoSecret)
+		}
+	}
+}
+
+func TestVerifyIgnoresEmptyPreviousSecrets(t *testing.T) {
+	payload := []byte(`{"type":"file.indexed"}`)
+	v := webhook.Verifier{Secret: []byte("secret"), PreviousSecrets: [][]byte{nil, {}}}
+	if err := v.Verify(webhook.Sign(nil, time.Now(), payload), payload); !errors.Is(err, webhook.ErrInvalidSignature) {
+		t.Errorf("got %v, want %v", err, webhook.ErrInvalidSignature)
+	}
+	if err := v.Verify(webhook.Sign([]byte("secret"), time.Now(), payload), payload); err != nil {
+		t.Errorf("the secret's signature was rejected: %v", err)
+	}
+}
@@ -0,0 +1,428 @@
+package operand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	filev1 "github.com/operandinc/go-sdk/file/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// DefaultWriteBehindConcurrency is the number of uploads a WriteBehind performs
+// concurrently, by default.
+const DefaultWriteBehindConcurrency = 2
+
+// WriteBehind queues uploads locally and performs them in the background, so that
+// request-path code doesn't wait on upload latency. Failed uploads are retried with
+// exponential backoff for as long as they fail transiently.
+//
+// Queued uploads are held in memory, or, if the queue has a directory, persisted to
+// it, so that they survive restarts.
+type WriteBehind struct {
+	c   *Client
+	dir string
+
+	// Concurrency is the number of uploads performed concurrently. Defaults to
+	// DefaultWriteBehindConcurrency.
+	Concurrency int
+	// MaxAttempts, if positive, bounds the attempts of each upload, after which it
+	// fails. Otherwise, uploads are retried until they succeed, or fail permanently.
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the wait between attempts, which doubles after
+	// each failure. Default to DefaultRetryBaseDelay and DefaultMaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnDone, if set, is called (concurrently) when an upload completes, fails or is
+	// cancelled.
+	OnDone func(h *OperationHandle[*filev1.File])
+	// OnError, if set, is called (concurrently) when an attempt fails.
+	OnError func(h *OperationHandle[*filev1.File], err error)
+
+	mu      sync.Mutex
+	queue   []*queuedUpload
+	pending map[string]*queuedUpload // Uploads which haven't finished, by ID.
+	wake    chan struct{}
+}
+
+// queuedUpload is an upload queued with WriteBehind.
+type queuedUpload struct {
+	h    *OperationHandle[*filev1.File]
+	meta uploadMeta
+	data []byte // The data of in-memory uploads.
+	// stop cancels the upload while it's in progress.
+	stop context.CancelFunc
+}
+
+// uploadMeta is the persisted form of a queued upload.
+type uploadMeta struct {
+	Name       string          `json:"name"`
+	Parent     *string         `json:"parent,omitempty"`
+	Folder     bool            `json:"folder,omitempty"`
+	Properties json.RawMessage `json:"properties,omitempty"` // Encoded with protojson.
+	QueuedAt   time.Time       `json:"queued_at"`
+}
+
+// NewWriteBehind returns a write-behind queue of uploads, persisted to dir unless
+// it's empty. Uploads persisted by a previous process are queued again. Uploads are
+// only performed while the queue runs (see Run).
+func (c *Client) NewWriteBehind(dir string) (*WriteBehind, error) {
+	w := &WriteBehind{
+		c:       c,
+		dir:     dir,
+		pending: make(map[string]*queuedUpload),
+		wake:    make(chan struct{}, 1),
+	}
+	if dir == "" {
+		return w, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		q := w.newQueued(id)
+		if err := json.Unmarshal(data, &q.meta); err != nil {
+			return nil, fmt.Errorf("invalid queued upload %s: %w", id, err)
+		}
+		w.queue = append(w.queue, q)
+		w.pending[id] = q
+	}
+	sort.SliceStable(w.queue, func(i, j int) bool {
+		return w.queue[i].meta.QueuedAt.Before(w.queue[j].meta.QueuedAt)
+	})
+	return w, nil
+}
+
+func (w *WriteBehind) newQueued(id string) *queuedUpload {
+	q := &queuedUpload{h: newOperationHandle[*filev1.File](id)}
+	q.h.setCancel(func() { w.cancel(q) })
+	return q
+}
+
+// Enqueue queues the upload of a file, with the same arguments as CreateFile, and
+// returns a handle to track it. The data is read (and persisted, if the queue has
+// a directory) before it returns. Uploads are made with the context of Run, so
+// values of ctx (e.g. collision policies) don't apply to them.
+func (w *WriteBehind) Enqueue(
+	ctx context.Context,
+	name string,
+	parent *string,
+	data io.Reader, // Nullable, if nil, a folder is created.
+	properties *filev1.Properties,
+) (*OperationHandle[*filev1.File], error) {
+	q := w.newQueued(w.c.newOperationID())
+	q.meta = uploadMeta{Name: name, Parent: parent, Folder: data == nil, QueuedAt: w.c.clock.Now().UTC()}
+	if properties != nil {
+		marshaled, err := protojson.Marshal(properties)
+		if err != nil {
+			return nil, err
+		}
+		q.meta.Properties = marshaled
+	}
+	if w.dir != "" {
+		if err := w.persist(ctx, q, data); err != nil {
+			return nil, fmt.Errorf("failed to persist upload: %w", err)
+		}
+	} else if data != nil {
+		var err error
+		if q.data, err = io.ReadAll(data); err != nil {
+			return nil, err
+		}
+	}
+
+	w.mu.Lock()
+	w.queue = append(w.queue, q)
+	w.pending[q.h.ID()] = q
+	w.mu.Unlock()
+	w.notify()
+	return q.h, nil
+}
+
+// Handle returns the handle of a pending upload, e.g. one queued before a restart,
+// or nil if there's no such upload.
+func (w *WriteBehind) Handle(id string) *OperationHandle[*filev1.File] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if q, ok := w.pending[id]; ok {
+		return q.h
+	}
+	return nil
+}
+
+// Pending returns the number of uploads which haven't finished yet.
+func (w *WriteBehind) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// Flush waits for the uploads queued so far to finish. The queue must be running
+// for them to do so.
+func (w *WriteBehind) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	pending := make([]*queuedUpload, 0, len(w.pending))
+	for _, q := range w.pending {
+		pending = append(pending, q)
+	}
+	w.mu.Unlock()
+	for _, q := range pending {
+		select {
+		case <-q.h.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Run performs queued uploads until the context is cancelled. Uploads in progress
+// at that point stay queued.
+func (w *WriteBehind) Run(ctx context.Context) error {
+	concurrency := w.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultWriteBehindConcurrency
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work(ctx)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (w *WriteBehind) work(ctx context.Context) {
+	for {
+		q, uploadCtx := w.next(ctx)
+		if q == nil {
+			select {
+			case <-w.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		w.notify() // Wake another worker, in case more uploads are queued.
+		file, err := w.upload(uploadCtx, q)
+		if err != nil && ctx.Err() != nil {
+			w.requeue(q)
+			return
+		}
+		w.finish(q, file, err)
+	}
+}
+
+// next pops the next upload off the queue, if any, and returns it along with the
+// context to perform it with.
+func (w *WriteBehind) next(ctx context.Context) (*queuedUpload, context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) == 0 {
+		return nil, nil
+	}
+	q := w.queue[0]
+	w.queue = w.queue[1:]
+	ctx, q.stop = context.WithCancel(ctx)
+	q.h.setStatus(OperationRunning)
+	return q, ctx
+}
+
+// requeue puts an interrupted upload back at the front of the queue.
+func (w *WriteBehind) requeue(q *queuedUpload) {
+	w.mu.Lock()
+	q.stop()
+	q.stop = nil
+	q.h.setStatus(OperationPending)
+	w.queue = append([]*queuedUpload{q}, w.queue...)
+	w.mu.Unlock()
+}
+
+// cancel stops an upload if it's in progress, or removes it from the queue.
+func (w *WriteBehind) cancel(q *queuedUpload) {
+	w.mu.Lock()
+	if q.stop != nil {
+		q.stop()
+		w.mu.Unlock()
+		return
+	}
+	for i, queued := range w.queue {
+		if queued == q {
+			w.queue = append(w.queue[:i:i], w.queue[i+1:]...)
+			w.mu.Unlock()
+			w.finish(q, nil, context.Canceled)
+			return
+		}
+	}
+	w.mu.Unlock()
+}
+
+func (w *WriteBehind) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// upload makes attempts at an upload until it succeeds, fails permanently, runs out
+// of attempts, or the context is cancelled.
+func (w *WriteBehind) upload(ctx context.Context, q *queuedUpload) (*filev1.File, error) {
+	var properties *filev1.Properties
+	if len(q.meta.Properties) > 0 {
+		properties = new(filev1.Properties)
+		if err := protojson.Unmarshal(q.meta.Properties, properties); err != nil {
+			return nil, err
+		}
+	}
+	minBackoff, maxBackoff := w.MinBackoff, w.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = DefaultRetryBaseDelay
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	codes := DefaultRetryCodes
+	if w.c.retry != nil {
+		codes = w.c.retry.Codes
+	}
+	policy := &RetryPolicy{Codes: codes}
+
+	backoff := minBackoff
+	for attempt := 1; ; attempt++ {
+		file, err := w.attempt(ctx, q, properties)
+		if err == nil {
+			return file, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		if w.OnError != nil {
+			w.OnError(q.h, err)
+		}
+		if !policy.retryable(ctx, err) || (w.MaxAttempts > 0 && attempt >= w.MaxAttempts) {
+			return nil, err
+		}
+		select {
+		case <-w.c.clock.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// attempt makes a single attempt at an upload.
+func (w *WriteBehind) attempt(ctx context.Context, q *queuedUpload, properties *filev1.Properties) (*filev1.File, error) {
+	var data io.Reader
+	if !q.meta.Folder {
+		if w.dir == "" {
+			data = bytes.NewReader(q.data)
+		} else {
+			f, err := os.Open(w.dataPath(q.h.ID()))
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			data = f
+		}
+	}
+	resp, err := w.c.CreateFile(ctx, q.meta.Name, q.meta.Parent, data, properties)
+	if err != nil {
+		return nil, err
+	}
+	return resp.File, nil
+}
+
+// finish records the result of an upload, and forgets it.
+func (w *WriteBehind) finish(q *queuedUpload, file *filev1.File, err error) {
+	id := q.h.ID()
+	if w.dir != "" {
+		os.Remove(w.metaPath(id))
+		os.Remove(w.dataPath(id))
+	}
+	w.mu.Lock()
+	delete(w.pending, id)
+	if q.stop != nil {
+		q.stop()
+	}
+	q.data = nil
+	w.mu.Unlock()
+	q.h.finish(file, err)
+	if w.OnDone != nil {
+		w.OnDone(q.h)
+	}
+}
+
+func (w *WriteBehind) metaPath(id string) string {
+	return filepath.Join(w.dir, id+".json")
+}
+
+func (w *WriteBehind) dataPath(id string) string {
+	return filepath.Join(w.dir, id+".data")
+}
+
+// persist writes the data of an upload, and then its metadata, which marks it as
+// queued.
+func (w *WriteBehind) persist(_ context.Context, q *queuedUpload, data io.Reader) error {
+	id := q.h.ID()
+	if data != nil {
+		if err := writeFileAtomic(w.dir, w.dataPath(id), data); err != nil {
+			return err
+		}
+	}
+	meta, err := json.Marshal(&q.meta)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(w.dir, w.metaPath(id), bytes.NewReader(meta)); err != nil {
+		os.Remove(w.dataPath(id))
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic writes a file within dir, replacing it atomically.
+func writeFileAtomic(dir, path string, r io.Reader) error {
+	f, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package operand
+
+import "errors"
+
+// getXattrs returns no extended attributes, as they aren't supported on this
+// platform.
+func getXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func setXattr(path, name string, value []byte) error {
+	return errors.New("extended attributes aren't supported on this platform")
+}